@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/selection"
+)
+
+// cropsRow is one segmentation of the crops command output.
+type cropsRow struct {
+	NBkps       int     `json:"n_bkps"`
+	PenaltyMin  float64 `json:"penalty_min"`
+	PenaltyMax  float64 `json:"penalty_max"`
+	Cost        float64 `json:"cost"`
+	Breakpoints []int   `json:"breakpoints"`
+}
+
+// runCrops implements the crops command.
+func runCrops(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := cmdutils.NewFlagSet("crops", stderr)
+	cmdutils.SetUsage(fs, "ruptures crops [flags] [file]",
+		`List every distinct optimal PELT segmentation for the penalties of [-pen-min, -pen-max]
+(CROPS), with its penalty interval, cost and number of change points. The cost
+against the number of change points is the data of an elbow plot.
+
+With -select, the number of change points is chosen automatically among the
+segmentations and only the selected one is printed, as with detect; the json
+format adds the diagnostics of the selection. The aic, bic and mbic methods
+require the l2 cost.`)
+	opts := cmdutils.DefaultOptions()
+	opts.RegisterFlags(fs)
+	var in inputOptions
+	in.register(fs)
+	applyVerbose := registerVerbose(fs, stderr)
+	penMin := fs.Float64("pen-min", 0.1, "smallest penalty of the range")
+	penMax := fs.Float64("pen-max", 1e4, "largest penalty of the range")
+	method := fs.String("select", "", "choose the number of change points ("+strings.Join(selection.Methods(), ", ")+")")
+	if err := cmdutils.Parse(fs, args, &opts); err != nil {
+		return err
+	}
+	applyVerbose()
+	if fs.NArg() > 1 {
+		return fmt.Errorf("expected at most one input file, got %d", fs.NArg())
+	}
+	if *method != "" && !slices.Contains(selection.Methods(), *method) {
+		return fmt.Errorf("unknown selection method %q (available: %s)", *method, strings.Join(selection.Methods(), ", "))
+	}
+	switch *method {
+	case selection.MethodAIC, selection.MethodBIC, selection.MethodMBIC:
+		if opts.Cost != "l2" {
+			return fmt.Errorf("-select %s is calibrated for the l2 cost, not %q; use elbow or slope", *method, opts.Cost)
+		}
+	}
+	if opts.Detector != "pelt" {
+		return fmt.Errorf("crops requires the pelt detector, not %q", opts.Detector)
+	}
+
+	signal, _, err := loadSignal(fs.Arg(0), stdin, in)
+	if err != nil {
+		return err
+	}
+	costFunc, err := opts.NewCost()
+	if err != nil {
+		return err
+	}
+	detector := pelt.NewPelt(costFunc, opts.MinSize, opts.Jump)
+	if err := detector.Fit(signal); err != nil {
+		return err
+	}
+	segs, err := detector.CropsContext(context.Background(), *penMin, *penMax)
+	if err != nil {
+		return err
+	}
+
+	if *method != "" {
+		res, err := selection.Select(*method, selection.FromCrops(segs), signal)
+		if err != nil {
+			return err
+		}
+		return writeSelection(stdout, opts.Format, res)
+	}
+
+	rows := make([]cropsRow, len(segs))
+	for i, s := range segs {
+		rows[i] = cropsRow{s.NBkps, s.PenaltyMin, s.PenaltyMax, s.Cost, s.Breakpoints}
+	}
+	return writeCrops(stdout, opts.Format, rows)
+}
+
+// selectionReport is the JSON output of crops -select.
+type selectionReport struct {
+	Method      string     `json:"method"`
+	NBkps       int        `json:"n_bkps"`
+	Cost        float64    `json:"cost"`
+	Breakpoints []int      `json:"breakpoints"`
+	Penalty     float64    `json:"penalty,omitempty"`
+	Sigma       float64    `json:"sigma,omitempty"`
+	Candidates  []cropsRow `json:"candidates"`
+	Scores      []float64  `json:"scores"`
+}
+
+// writeSelection prints the selected segmentation like detect does, with the
+// diagnostics of the selection in the json format.
+func writeSelection(w io.Writer, format string, res selection.Result) error {
+	if format != "json" {
+		return writeReport(w, format, report{Breakpoints: res.Selected.Breakpoints})
+	}
+	r := selectionReport{
+		Method:      res.Method,
+		NBkps:       res.Selected.NBkps,
+		Cost:        res.Selected.Cost,
+		Breakpoints: res.Selected.Breakpoints,
+		Penalty:     res.Penalty,
+		Sigma:       res.Sigma,
+		Scores:      res.Scores,
+	}
+	for _, c := range res.Candidates {
+		r.Candidates = append(r.Candidates, cropsRow{NBkps: c.NBkps, Cost: c.Cost, Breakpoints: c.Breakpoints})
+	}
+	return writeJSON(w, r)
+}
+
+// writeCrops prints the segmentations as an aligned table ("text"), JSON or CSV.
+func writeCrops(w io.Writer, format string, rows []cropsRow) error {
+	if format == "json" {
+		return writeJSON(w, rows)
+	}
+	header := []string{"n_bkps", "penalty_min", "penalty_max", "cost", "breakpoints"}
+	records := make([][]string, len(rows))
+	for i, r := range rows {
+		bkps := make([]string, len(r.Breakpoints))
+		for j, b := range r.Breakpoints {
+			bkps[j] = strconv.Itoa(b)
+		}
+		records[i] = []string{
+			strconv.Itoa(r.NBkps),
+			strconv.FormatFloat(r.PenaltyMin, 'g', 6, 64),
+			strconv.FormatFloat(r.PenaltyMax, 'g', 6, 64),
+			strconv.FormatFloat(r.Cost, 'g', 8, 64),
+			strings.Join(bkps, " "),
+		}
+	}
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		cw.WriteAll(records)
+		return cw.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range records {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"
+	"github.com/theDataFlowClub/ruptures/core/inference"
+	"github.com/theDataFlowClub/ruptures/core/multires"
+	"github.com/theDataFlowClub/ruptures/core/refine"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// runDetect implements the detect command.
+func runDetect(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := cmdutils.NewFlagSet("detect", stderr)
+	cmdutils.SetUsage(fs, "ruptures detect [flags] [file]",
+		"Detect change points in a CSV/TSV signal read from file or standard input.")
+	opts := cmdutils.DefaultOptions()
+	opts.RegisterFlags(fs)
+	registerPenaltyModel(fs, &opts)
+	var in inputOptions
+	in.register(fs)
+	applyVerbose := registerVerbose(fs, stderr)
+	showProgress := fs.Bool("progress", false, "draw a progress bar on standard error")
+	withStats := fs.Bool("stats", false, "report the length, cost, mean, median and variance of every segment (json and csv formats)")
+	ciMethod := fs.String("ci", "", "report a confidence interval of every change point computed by this method ("+strings.Join(inference.Methods(), ", ")+"; json and csv formats)")
+	ciLevel := fs.Float64("ci-level", inference.DefaultLevel, "confidence level of the -ci intervals")
+	test := fs.String("significance", "", "test every change point ("+strings.Join(append([]string{"auto"}, inference.Tests()...), ", ")+"; json and csv formats)")
+	alpha := fs.Float64("alpha", inference.DefaultAlpha, "level of the -significance tests")
+	correction := fs.String("correction", inference.CorrectionHolm, "multiple-testing correction of the -significance tests ("+strings.Join(inference.Corrections(), ", ")+")")
+	prune := fs.Bool("prune", false, "remove the change points that are not significant (implies -significance auto)")
+	downsample := fs.Int("downsample", 1, "detect on the means of blocks of this many samples, then locate every change point in the original signal (l2 cost only)")
+	levels := fs.Int("levels", 1, "number of times the signal is downsampled by -downsample")
+	refineWidth := fs.Int("refine", 0, "move every change point to the best split within this many samples, e.g. after a run with -jump (0 disables)")
+	if err := cmdutils.Parse(fs, args, &opts); err != nil {
+		return err
+	}
+	applyVerbose()
+	if *showProgress {
+		opts.Progress = progressBar(stderr, opts.Detector)
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("expected at most one input file, got %d", fs.NArg())
+	}
+	fileName := fs.Arg(0)
+
+	signal, columns, err := loadSignal(fileName, stdin, in)
+	if err != nil {
+		return err
+	}
+	var intervals inference.IntervalEstimator
+	if *ciMethod != "" {
+		if intervals, err = inference.NewIntervalEstimator(*ciMethod, opts.MinSize, *ciLevel, opts.Seed); err != nil {
+			return err
+		}
+		if b, ok := intervals.(*inference.Bootstrap); ok {
+			// Replicates use the cost with the flags' parameters (e.g. -gamma).
+			b.NewCost = opts.NewCost
+		}
+	}
+
+	var sig *inference.Significance
+	if *test != "" || *prune {
+		sig = inference.NewSignificance(opts.MinSize, *alpha, opts.Seed)
+		sig.Correction, sig.NewCost = *correction, opts.NewCost
+		if *test != "auto" {
+			sig.Test = *test
+		}
+	}
+
+	ctx := context.Background()
+	r := report{Detector: opts.Detector, NSamples: len(signal), Columns: columns}
+	var costFunc base.CostFunction
+	if *downsample != 1 {
+		if r.Breakpoints, costFunc, err = detectMultires(ctx, &opts, signal, *downsample, *levels); err != nil {
+			return err
+		}
+		if *withStats {
+			res, err := base.NewResult(signal, costFunc, r.Breakpoints, opts.Penalty)
+			if err != nil {
+				return err
+			}
+			r.Segments, r.SegmentsCost, r.PenalizedCost = res.Segments, &res.Cost, &res.PenalizedCost
+		}
+	} else if *withStats {
+		var res base.Result
+		if res, costFunc, err = detectResult(ctx, &opts, signal); err != nil {
+			return err
+		}
+		r.Breakpoints = res.Breakpoints
+		r.Segments, r.SegmentsCost, r.PenalizedCost = res.Segments, &res.Cost, &res.PenalizedCost
+	} else if r.Breakpoints, costFunc, err = detect(ctx, &opts, signal); err != nil {
+		return err
+	}
+	r.Cost, r.Penalty = costFunc.Model(), opts.Penalty
+	if *refineWidth != 0 {
+		if err := refineReport(ctx, refine.NewRefiner(*refineWidth, opts.MinSize), signal, costFunc, &r); err != nil {
+			return err
+		}
+	}
+	if sig != nil {
+		if err := significance(ctx, sig, *prune, signal, costFunc, &r); err != nil {
+			return fmt.Errorf("significance: %w", err)
+		}
+	}
+	if intervals != nil {
+		if r.Intervals, err = intervals.IntervalsContext(ctx, signal, costFunc, r.Breakpoints); err != nil {
+			return fmt.Errorf("confidence intervals: %w", err)
+		}
+	}
+	return writeReport(stdout, opts.Format, r)
+}
+
+// refineReport refines the change points of the report and recomputes the segment
+// statistics, if any, when a change point moved.
+func refineReport(ctx context.Context, refiner *refine.Refiner, signal types.Matrix, c base.CostFunction, r *report) error {
+	refined, err := refiner.RefineContext(ctx, signal, c, r.Breakpoints)
+	if err != nil {
+		return err
+	}
+	if r.Segments != nil && !slices.Equal(refined, r.Breakpoints) {
+		res, err := base.NewResult(signal, c, refined, r.Penalty)
+		if err != nil {
+			return err
+		}
+		r.Segments, r.SegmentsCost, r.PenalizedCost = res.Segments, &res.Cost, &res.PenalizedCost
+	}
+	r.Breakpoints = refined
+	return nil
+}
+
+// significance tests the change points of the report and stores the tests in it.
+// With prune, the change points that are not significant are removed from the
+// report, and the segment statistics, if any, are recomputed.
+func significance(ctx context.Context, sig *inference.Significance, prune bool, signal types.Matrix, c base.CostFunction, r *report) error {
+	if !prune {
+		tests, err := sig.EvaluateContext(ctx, signal, c, r.Breakpoints)
+		r.Tests = tests
+		return err
+	}
+	kept, tests, err := sig.PruneContext(ctx, signal, c, r.Breakpoints)
+	if err != nil {
+		return err
+	}
+	if r.Segments != nil && len(kept) != len(r.Breakpoints) {
+		res, err := base.NewResult(signal, c, kept, r.Penalty)
+		if err != nil {
+			return err
+		}
+		r.Segments, r.SegmentsCost, r.PenalizedCost = res.Segments, &res.Cost, &res.PenalizedCost
+	}
+	r.Breakpoints, r.Tests = kept, tests
+	return nil
+}
+
+// detect fits the detector selected by opts on the signal and returns the
+// predicted breakpoints together with the cost function, fitted on the signal.
+// The prediction is aborted with ctx.Err() once ctx is done.
+func detect(ctx context.Context, opts *cmdutils.Options, signal types.Matrix) ([]int, base.CostFunction, error) {
+	detector, costFunc, err := fitDetector(opts, signal)
+	if err != nil {
+		return nil, nil, err
+	}
+	bkps, err := opts.PredictContext(ctx, detector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", opts.Detector, err)
+	}
+	return bkps, costFunc, nil
+}
+
+// detectResult is like detect, but returns the segmentation as a base.Result with
+// the statistics and costs of its segments.
+func detectResult(ctx context.Context, opts *cmdutils.Options, signal types.Matrix) (base.Result, base.CostFunction, error) {
+	detector, costFunc, err := fitDetector(opts, signal)
+	if err != nil {
+		return base.Result{}, nil, err
+	}
+	res, err := opts.PredictResultContext(ctx, detector, signal, costFunc)
+	if err != nil {
+		return base.Result{}, nil, fmt.Errorf("%s: %w", opts.Detector, err)
+	}
+	return res, costFunc, nil
+}
+
+// detectMultires detects the change points coarse to fine (see package multires).
+// The detector runs on the signal averaged over blocks of factor^levels samples,
+// with the minimum segment length and the jump divided by the block length, and
+// a numeric penalty too: the noise variance of block means is divided by the
+// block length, which makes the penalty consistent only for the l2 cost, the only
+// one accepted. The change points are then located in the original signal, and
+// those that do not pay for the penalty there are removed, unless opts fixes
+// the number of change points. The cost function is fitted on the original signal.
+func detectMultires(ctx context.Context, opts *cmdutils.Options, signal types.Matrix, factor, levels int) ([]int, base.CostFunction, error) {
+	if opts.Cost != "l2" {
+		return nil, nil, fmt.Errorf("-downsample requires -cost l2, got %s", opts.Cost)
+	}
+	if err := opts.ResolvePenalty(signal); err != nil {
+		return nil, nil, err
+	}
+	pipeline := multires.NewPipeline(factor, levels, opts.MinSize, opts.NewCost)
+	if opts.NBkps == 0 {
+		pipeline.Penalty = opts.Penalty
+	}
+	bkps, err := pipeline.RunContext(ctx, signal, func(ctx context.Context, coarse types.Matrix) ([]int, error) {
+		// The pipeline has checked that factor ≥ 2 and levels ≥ 1.
+		block := 1
+		for range levels {
+			block *= factor
+		}
+		coarseOpts := *opts
+		coarseOpts.MinSize = (opts.MinSize + block - 1) / block
+		coarseOpts.Jump = (opts.Jump + block - 1) / block
+		if opts.NBkps == 0 {
+			coarseOpts.Penalty = opts.Penalty / float64(block)
+		}
+		bkps, _, err := detect(ctx, &coarseOpts, coarse)
+		if err == nil && opts.NBkps > 0 {
+			// The penalty found for the coarse signal, on the scale of the original one.
+			opts.Penalty = coarseOpts.Penalty * float64(block)
+		}
+		return bkps, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	costFunc, err := opts.NewCost()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := costFunc.Fit(signal); err != nil {
+		return nil, nil, err
+	}
+	return bkps, costFunc, nil
+}
+
+// fitDetector resolves the penalty and builds the detector and cost function
+// selected by opts, both fitted on the signal.
+func fitDetector(opts *cmdutils.Options, signal types.Matrix) (base.Estimator, base.CostFunction, error) {
+	if err := opts.ResolvePenalty(signal); err != nil {
+		return nil, nil, err
+	}
+	costFunc, err := opts.NewCost()
+	if err != nil {
+		return nil, nil, err
+	}
+	detector, err := opts.NewDetector(costFunc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := detector.Fit(signal); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", opts.Detector, err)
+	}
+	return detector, costFunc, nil
+}
+
+// progressBar returns a base.ProgressFunc drawing a one-line progress bar on w,
+// terminated by a newline once the whole signal has been processed.
+func progressBar(w io.Writer, label string) base.ProgressFunc {
+	const width = 30
+	return func(p base.Progress) {
+		filled := int(p.Fraction() * width)
+		fmt.Fprintf(w, "\r%s [%s%s] %3.0f%% %d candidates %s",
+			label, strings.Repeat("#", filled), strings.Repeat(" ", width-filled),
+			100*p.Fraction(), p.Candidates, p.Elapsed.Round(time.Millisecond))
+		if p.Processed == p.Total {
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+)
+
+// score is a float64 that encodes as JSON null when it is not finite
+// (e.g. the Hausdorff distance when one segmentation has no change point).
+type score float64
+
+// MarshalJSON implements json.Marshaler.
+func (s score) MarshalJSON() ([]byte, error) {
+	if math.IsInf(float64(s), 0) || math.IsNaN(float64(s)) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float64(s))
+}
+
+// evaluation holds the metrics of one case, or their mean over all cases.
+type evaluation struct {
+	Case            string `json:"case"`
+	NTrue           int    `json:"n_true"`
+	NPred           int    `json:"n_pred"`
+	Precision       score  `json:"precision"`
+	Recall          score  `json:"recall"`
+	F1              score  `json:"f1"`
+	Hausdorff       score  `json:"hausdorff"`
+	RandIndex       score  `json:"rand_index"`
+	AnnotationError score  `json:"annotation_error"`
+	// HausdorffExcluded counts the cases whose Hausdorff distance is infinite
+	// (one segmentation has no change point), which the mean leaves out.
+	HausdorffExcluded int `json:"hausdorff_excluded"`
+}
+
+// evaluationCase pairs the true breakpoints of a case with the source of its prediction:
+// either a breakpoints file or a signal file on which detection runs inline.
+type evaluationCase struct {
+	Name       string
+	TruthFile  string
+	PredFile   string
+	SignalFile string
+}
+
+// runEvaluate implements the evaluate command.
+func runEvaluate(args []string, stdout, stderr io.Writer) error {
+	fs := cmdutils.NewFlagSet("evaluate", stderr)
+	cmdutils.SetUsage(fs, "ruptures evaluate [flags] (-truth FILE (-pred FILE | -signal FILE) | -dir DIR)",
+		`Compare predicted breakpoints with the ground truth and print precision, recall,
+F1, Hausdorff distance, Rand index and annotation error.
+
+With -signal, detection runs inline with the detection flags. With -dir, every
+<case>.truth.{json,csv,txt} file of the directory is a case, predicted either by
+<case>.pred.{json,csv,txt} or by running detection on <case>.{csv,tsv,json};
+the mean over all cases (with total counts) is printed last. The mean Hausdorff
+distance leaves out the cases where it is infinite (one segmentation has no change
+point); hausdorff_excluded counts them.`)
+	opts := cmdutils.DefaultOptions()
+	opts.RegisterFlags(fs)
+	registerPenaltyModel(fs, &opts)
+	var in inputOptions
+	in.register(fs)
+	applyVerbose := registerVerbose(fs, stderr)
+	var single evaluationCase
+	fs.StringVar(&single.TruthFile, "truth", "", "file with the true breakpoints")
+	fs.StringVar(&single.PredFile, "pred", "", "file with the predicted breakpoints")
+	fs.StringVar(&single.SignalFile, "signal", "", "signal file on which to run detection")
+	dir := fs.String("dir", "", "directory of cases to evaluate")
+	margin := fs.Int("margin", 10, "tolerance, in samples, for precision and recall")
+	minF1 := fs.Float64("min-f1", 0, "fail when the mean F1 score is below this value")
+	maxHausdorff := fs.Float64("max-hausdorff", 0, "fail when the mean finite Hausdorff distance exceeds this value (0 disables)")
+	if err := cmdutils.Parse(fs, args, &opts); err != nil {
+		return err
+	}
+	applyVerbose()
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if *margin < 1 {
+		return fmt.Errorf("-margin must be at least 1, got %d", *margin)
+	}
+
+	var cases []evaluationCase
+	switch {
+	case *dir != "" && single.TruthFile != "":
+		return errors.New("-dir and -truth are mutually exclusive")
+	case *dir != "":
+		var err error
+		if cases, err = discoverCases(*dir); err != nil {
+			return err
+		}
+	case single.TruthFile == "":
+		return errors.New("one of -truth or -dir is required")
+	case (single.PredFile == "") == (single.SignalFile == ""):
+		return errors.New("exactly one of -pred or -signal is required with -truth")
+	default:
+		base := filepath.Base(single.TruthFile)
+		single.Name = strings.TrimSuffix(strings.TrimSuffix(base, filepath.Ext(base)), ".truth")
+		cases = []evaluationCase{single}
+	}
+
+	results := make([]evaluation, 0, len(cases)+1)
+	for _, c := range cases {
+		ev, err := evaluateCase(c, &opts, in, *margin)
+		if err != nil {
+			return fmt.Errorf("case %s: %w", c.Name, err)
+		}
+		results = append(results, ev)
+	}
+	mean := meanEvaluation(results)
+	if len(results) > 1 {
+		results = append(results, mean)
+	}
+
+	if err := writeEvaluations(stdout, opts.Format, results); err != nil {
+		return err
+	}
+	if float64(mean.F1) < *minF1 {
+		return fmt.Errorf("quality gate failed: mean F1 %.4f < %.4f", float64(mean.F1), *minF1)
+	}
+	if *maxHausdorff > 0 {
+		switch {
+		case math.IsInf(float64(mean.Hausdorff), 0):
+			return errors.New("quality gate failed: no case has a finite Hausdorff distance")
+		case float64(mean.Hausdorff) > *maxHausdorff:
+			return fmt.Errorf("quality gate failed: mean Hausdorff %.4f > %.4f (%d cases with an infinite distance excluded)",
+				float64(mean.Hausdorff), *maxHausdorff, mean.HausdorffExcluded)
+		}
+	}
+	return nil
+}
+
+// discoverCases lists the cases of a directory, sorted by name.
+func discoverCases(dir string) ([]evaluationCase, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var cases []evaluationCase
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if entry.IsDir() || !strings.HasSuffix(strings.TrimSuffix(name, ext), ".truth") {
+			continue
+		}
+		c := evaluationCase{
+			Name:      strings.TrimSuffix(strings.TrimSuffix(name, ext), ".truth"),
+			TruthFile: filepath.Join(dir, name),
+		}
+		c.PredFile = firstExisting(dir, c.Name+".pred", ".json", ".csv", ".txt")
+		if c.PredFile == "" {
+			c.SignalFile = firstExisting(dir, c.Name, ".csv", ".tsv", ".json")
+		}
+		if c.PredFile == "" && c.SignalFile == "" {
+			return nil, fmt.Errorf("case %s: no prediction or signal file next to %s", c.Name, name)
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no *.truth.* files in %s", dir)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases, nil
+}
+
+// firstExisting returns the first path dir/base+ext that exists, or "".
+func firstExisting(dir, base string, exts ...string) string {
+	for _, ext := range exts {
+		path := filepath.Join(dir, base+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// evaluateCase computes the metrics of a single case.
+func evaluateCase(c evaluationCase, opts *cmdutils.Options, in inputOptions, margin int) (evaluation, error) {
+	trueBkps, err := readBreakpointsFile(c.TruthFile)
+	if err != nil {
+		return evaluation{}, err
+	}
+	var predBkps []int
+	if c.PredFile != "" {
+		predBkps, err = readBreakpointsFile(c.PredFile)
+	} else {
+		signal, _, lerr := loadSignal(c.SignalFile, nil, in)
+		if lerr != nil {
+			return evaluation{}, lerr
+		}
+		predBkps, _, err = detect(context.Background(), opts, signal)
+	}
+	if err != nil {
+		return evaluation{}, err
+	}
+
+	precision, recall, err := metrics.PrecisionRecall(trueBkps, predBkps, margin)
+	if err != nil {
+		return evaluation{}, err
+	}
+	f1, _ := metrics.F1Score(trueBkps, predBkps, margin)
+	hausdorff, _ := metrics.Hausdorff(trueBkps, predBkps)
+	randIndex, _ := metrics.RandIndex(trueBkps, predBkps)
+	annotationError, _ := metrics.AnnotationError(trueBkps, predBkps)
+	excluded := 0
+	if math.IsInf(hausdorff, 0) {
+		excluded = 1
+	}
+	return evaluation{
+		Case:              c.Name,
+		NTrue:             len(trueBkps) - 1,
+		NPred:             len(predBkps) - 1,
+		Precision:         score(precision),
+		Recall:            score(recall),
+		F1:                score(f1),
+		Hausdorff:         score(hausdorff),
+		RandIndex:         score(randIndex),
+		AnnotationError:   score(annotationError),
+		HausdorffExcluded: excluded,
+	}, nil
+}
+
+// meanEvaluation averages the metrics of all results. The mean Hausdorff distance
+// is that of the cases where it is finite, and infinite when there is none.
+func meanEvaluation(results []evaluation) evaluation {
+	mean := evaluation{Case: "mean"}
+	if len(results) == 0 {
+		return mean
+	}
+	n := score(len(results))
+	for _, r := range results {
+		mean.NTrue += r.NTrue
+		mean.NPred += r.NPred
+		mean.Precision += r.Precision / n
+		mean.Recall += r.Recall / n
+		mean.F1 += r.F1 / n
+		mean.RandIndex += r.RandIndex / n
+		mean.AnnotationError += r.AnnotationError / n
+		if math.IsInf(float64(r.Hausdorff), 0) {
+			mean.HausdorffExcluded++
+		} else {
+			mean.Hausdorff += r.Hausdorff
+		}
+	}
+	if finite := len(results) - mean.HausdorffExcluded; finite > 0 {
+		mean.Hausdorff /= score(finite)
+	} else {
+		mean.Hausdorff = score(math.Inf(1))
+	}
+	return mean
+}
+
+// writeEvaluations prints the results as an aligned table ("text"), JSON or CSV.
+func writeEvaluations(w io.Writer, format string, results []evaluation) error {
+	header := []string{"case", "n_true", "n_pred", "precision", "recall", "f1", "hausdorff", "rand_index", "annotation_error", "hausdorff_excluded"}
+	rows := make([][]string, len(results))
+	for i, r := range results {
+		rows[i] = []string{r.Case, strconv.Itoa(r.NTrue), strconv.Itoa(r.NPred)}
+		for _, v := range []score{r.Precision, r.Recall, r.F1, r.Hausdorff, r.RandIndex, r.AnnotationError} {
+			rows[i] = append(rows[i], strconv.FormatFloat(float64(v), 'f', 4, 64))
+		}
+		rows[i] = append(rows[i], strconv.Itoa(r.HausdorffExcluded))
+	}
+
+	switch format {
+	case "json":
+		return writeJSON(w, results)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		cw.WriteAll(rows)
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// readBreakpointsFile reads breakpoints from a JSON file (an array of integers or an
+// object with a "breakpoints" field, as written by detect and generate) or from a
+// CSV/text file. In CSV files the "end" column is used when present (detect -format csv),
+// the first column otherwise; whitespace-separated values (detect -format text) are accepted.
+func readBreakpointsFile(name string) ([]int, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		var doc struct {
+			Breakpoints []int `json:"breakpoints"`
+		}
+		if err := json.Unmarshal(data, &doc); err == nil && doc.Breakpoints != nil {
+			return doc.Breakpoints, nil
+		}
+		var bkps []int
+		if err := json.Unmarshal(data, &bkps); err != nil {
+			return nil, fmt.Errorf("%s: expected a JSON array of breakpoints or an object with a \"breakpoints\" field", name)
+		}
+		return bkps, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	column := 0
+	if len(records) > 0 && !isNumericRow(strings.Fields(strings.Join(records[0], " "))) {
+		for j, field := range records[0] {
+			if strings.TrimSpace(field) == "end" {
+				column = j
+			}
+		}
+		records = records[1:]
+	}
+	var bkps []int
+	for i, record := range records {
+		if column >= len(record) {
+			return nil, fmt.Errorf("%s: row %d has no column %d", name, i+1, column)
+		}
+		for _, field := range strings.Fields(record[column]) {
+			b, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("%s: row %d: %w", name, i+1, err)
+			}
+			bkps = append(bkps, b)
+		}
+	}
+	return bkps, nil
+}
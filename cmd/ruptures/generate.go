@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"
+	"github.com/theDataFlowClub/ruptures/core/datasets"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// dataset is the JSON document written by the generate command.
+type dataset struct {
+	Kind        string       `json:"kind"`
+	Seed        int64        `json:"seed"`
+	NSamples    int          `json:"n_samples"`
+	NFeatures   int          `json:"n_features"`
+	Breakpoints []int        `json:"breakpoints"`
+	Signal      types.Matrix `json:"signal"`
+}
+
+// runGenerate implements the generate command.
+func runGenerate(args []string, stdout, stderr io.Writer) error {
+	fs := cmdutils.NewFlagSet("generate", stderr)
+	cmdutils.SetUsage(fs, "ruptures generate [flags]",
+		"Write a synthetic signal with known change points, together with its true breakpoints.")
+	p := datasets.DefaultParams()
+	kind := fs.String("kind", "constant", "generator ("+strings.Join(datasets.Kinds(), ", ")+")")
+	fs.IntVar(&p.NSamples, "n-samples", p.NSamples, "number of samples")
+	fs.IntVar(&p.NFeatures, "n-features", p.NFeatures, "number of features (ignored by the normal generator)")
+	fs.IntVar(&p.NBkps, "n-bkps", p.NBkps, "number of change points")
+	fs.Float64Var(&p.NoiseStd, "noise-std", p.NoiseStd, "standard deviation of the Gaussian noise")
+	fs.Float64Var(&p.DeltaMin, "delta-min", p.DeltaMin, "minimum absolute jump of the mean (constant generator)")
+	fs.Float64Var(&p.DeltaMax, "delta-max", p.DeltaMax, "maximum absolute jump of the mean (constant generator)")
+	fs.Int64Var(&p.Seed, "seed", p.Seed, "seed of the random number generator")
+	format := fs.String("format", "csv", "signal output format (csv, json)")
+	out := fs.String("o", "-", `signal output file ("-" for standard output)`)
+	bkpsOut := fs.String("bkps-out", "", "file receiving the true breakpoints (.json or .csv)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if !slices.Contains(datasets.Kinds(), *kind) {
+		return fmt.Errorf("unknown kind %q (available: %s)", *kind, strings.Join(datasets.Kinds(), ", "))
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q (available: csv, json)", *format)
+	}
+	if *format == "csv" && *bkpsOut == "" {
+		return errors.New("-bkps-out is required with -format csv, the CSV signal cannot hold the breakpoints")
+	}
+
+	signal, bkps, err := datasets.Generate(*kind, p)
+	if err != nil {
+		return err
+	}
+
+	w, closeOut, err := createOutput(*out, stdout)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		err = writeJSON(w, dataset{
+			Kind:        *kind,
+			Seed:        p.Seed,
+			NSamples:    len(signal),
+			NFeatures:   len(signal[0]),
+			Breakpoints: bkps,
+			Signal:      signal,
+		})
+	} else {
+		err = writeSignalCSV(w, signal)
+	}
+	if cerr := closeOut(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if *bkpsOut != "" {
+		return writeBreakpointsFile(*bkpsOut, bkps)
+	}
+	return nil
+}
+
+// createOutput opens the named file for writing, or returns stdout when name is "-".
+// The returned function closes the file.
+func createOutput(name string, stdout io.Writer) (io.Writer, func() error, error) {
+	if name == "" || name == "-" {
+		return stdout, func() error { return nil }, nil
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
+
+// writeSignalCSV writes the signal as a CSV table with one column per feature (x0, x1, ...).
+func writeSignalCSV(w io.Writer, signal types.Matrix) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(signal[0]))
+	for j := range header {
+		header[j] = "x" + strconv.Itoa(j)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(header))
+	for _, row := range signal {
+		for j, v := range row {
+			record[j] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeBreakpointsFile writes breakpoints to the named file, as a JSON object
+// {"breakpoints": [...]} when the extension is .json and as a one-column CSV otherwise.
+func writeBreakpointsFile(name string, bkps []int) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		err = writeJSON(f, struct {
+			Breakpoints []int `json:"breakpoints"`
+		}{bkps})
+	} else {
+		cw := csv.NewWriter(f)
+		cw.Write([]string{"breakpoint"})
+		for _, b := range bkps {
+			cw.Write([]string{strconv.Itoa(b)})
+		}
+		cw.Flush()
+		err = cw.Error()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
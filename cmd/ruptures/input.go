@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// inputOptions describes how a delimited file is turned into a signal.
+type inputOptions struct {
+	Delimiter string // Field separator; empty means "by extension" (tab for .tsv, comma otherwise).
+	Columns   string // Comma-separated column indices (0-based) or header names; empty selects all.
+	Header    string // "auto", "yes" or "no".
+}
+
+// register binds the input options to named flags of fs.
+func (o *inputOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.Columns, "columns", "", "comma-separated column indices or header names (default: all)")
+	fs.StringVar(&o.Delimiter, "delimiter", "", `field delimiter (default: tab for .tsv files, "," otherwise)`)
+	fs.StringVar(&o.Header, "header", "auto", "whether the first row is a header (auto, yes, no)")
+}
+
+// loadSignal reads the signal from the named file, or from stdin when name is empty or "-".
+// Files with a .json extension are decoded with readSignalJSON, anything else as a delimited table.
+func loadSignal(name string, stdin io.Reader, opts inputOptions) (types.Matrix, []string, error) {
+	f, err := openInput(name, stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		signal, err := readSignalJSON(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		names := make([]string, len(signal[0]))
+		for j := range names {
+			names[j] = strconv.Itoa(j)
+		}
+		return signal, names, nil
+	}
+	comma, err := delimiterFor(name, opts.Delimiter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return readSignal(f, comma, opts)
+}
+
+// readSignalJSON decodes a signal given as a JSON array of numbers (univariate),
+// an array of arrays (one per sample), or an object with a "signal" field holding
+// either form, such as the documents written by the generate command.
+func readSignalJSON(r io.Reader) (types.Matrix, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("reading JSON input: %w", err)
+	}
+	var doc struct {
+		Signal json.RawMessage `json:"signal"`
+	}
+	if err := json.Unmarshal(raw, &doc); err == nil && doc.Signal != nil {
+		raw = doc.Signal
+	}
+	var signal types.Matrix
+	if err := json.Unmarshal(raw, &signal); err != nil {
+		var values types.Vector
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, errors.New("JSON signal must be an array of numbers or an array of arrays")
+		}
+		signal = make(types.Matrix, len(values))
+		for i, v := range values {
+			signal[i] = []float64{v}
+		}
+	}
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return nil, errors.New("JSON signal is empty")
+	}
+	for i, row := range signal {
+		if len(row) != len(signal[0]) {
+			return nil, fmt.Errorf("JSON signal row %d has %d features, want %d", i, len(row), len(signal[0]))
+		}
+	}
+	return signal, nil
+}
+
+// openInput opens the named file, or returns stdin when name is empty or "-".
+func openInput(name string, stdin io.Reader) (io.ReadCloser, error) {
+	if name == "" || name == "-" {
+		return io.NopCloser(stdin), nil
+	}
+	return os.Open(name)
+}
+
+// delimiterFor resolves the field separator for the given file name.
+func delimiterFor(name, delimiter string) (rune, error) {
+	switch delimiter {
+	case "":
+		if strings.EqualFold(filepath.Ext(name), ".tsv") {
+			return '\t', nil
+		}
+		return ',', nil
+	case `\t`, "tab":
+		return '\t', nil
+	}
+	r := []rune(delimiter)
+	if len(r) != 1 {
+		return 0, fmt.Errorf("delimiter must be a single character, got %q", delimiter)
+	}
+	return r[0], nil
+}
+
+// readSignal parses a delimited table into a signal of shape (n_samples, n_features).
+// It returns the signal together with the names of the selected columns.
+func readSignal(r io.Reader, comma rune, opts inputOptions) (types.Matrix, []string, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading input: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, errors.New("input contains no rows")
+	}
+
+	var header []string
+	switch opts.Header {
+	case "yes":
+		header, records = records[0], records[1:]
+	case "no":
+	case "auto", "":
+		if !isNumericRow(records[0]) {
+			header, records = records[0], records[1:]
+		}
+	default:
+		return nil, nil, fmt.Errorf("header must be auto, yes or no, got %q", opts.Header)
+	}
+	if len(records) == 0 {
+		return nil, nil, errors.New("input contains a header but no samples")
+	}
+
+	indices, err := selectColumns(opts.Columns, header, len(records[0]))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signal := make(types.Matrix, len(records))
+	for i, record := range records {
+		row := make([]float64, len(indices))
+		for j, col := range indices {
+			if col >= len(record) {
+				return nil, nil, fmt.Errorf("row %d has %d columns, column %d requested", i+1, len(record), col)
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(record[col]), 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("row %d, column %d: %w", i+1, col, err)
+			}
+			row[j] = v
+		}
+		signal[i] = row
+	}
+
+	names := make([]string, len(indices))
+	for j, col := range indices {
+		if header != nil && col < len(header) {
+			names[j] = header[col]
+		} else {
+			names[j] = strconv.Itoa(col)
+		}
+	}
+	return signal, names, nil
+}
+
+// isNumericRow reports whether every field of the row parses as a number.
+func isNumericRow(row []string) bool {
+	for _, field := range row {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// selectColumns resolves a column specification into column indices.
+// Each entry is either a 0-based index or, when a header is present, a column name.
+func selectColumns(spec string, header []string, nColumns int) ([]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		indices := make([]int, nColumns)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices, nil
+	}
+
+	var indices []int
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if idx, err := strconv.Atoi(entry); err == nil {
+			if idx < 0 || idx >= nColumns {
+				return nil, fmt.Errorf("column %d out of range [0, %d)", idx, nColumns)
+			}
+			indices = append(indices, idx)
+			continue
+		}
+		found := false
+		for i, name := range header {
+			if strings.TrimSpace(name) == entry {
+				indices = append(indices, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", entry)
+		}
+	}
+	return indices, nil
+}
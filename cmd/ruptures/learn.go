@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"
+	"github.com/theDataFlowClub/ruptures/core/penalty"
+)
+
+// Kinds of penalty models fitted by the learn command.
+var learnModels = []string{"global", "linear"}
+
+// runLearn implements the learn command.
+func runLearn(args []string, stdout, stderr io.Writer) error {
+	fs := cmdutils.NewFlagSet("learn", stderr)
+	cmdutils.SetUsage(fs, "ruptures learn [flags] -dir DIR",
+		`Learn the PELT penalty from annotated signals and print the model as JSON.
+
+Every <case>.truth.{json,csv,txt} file of the directory holds the labeled
+breakpoints of the signal <case>.{csv,tsv,json}. The global model is the single
+penalty with the best mean score over the cases; the linear model predicts the
+log-penalty from the length and the noise variance of every signal. Pass the
+model to detect or evaluate with -penalty-model.`)
+	opts := cmdutils.DefaultOptions()
+	opts.RegisterFlags(fs)
+	var in inputOptions
+	in.register(fs)
+	applyVerbose := registerVerbose(fs, stderr)
+	dir := fs.String("dir", "", "directory of annotated cases")
+	kind := fs.String("model", "global", "penalty model ("+strings.Join(learnModels, ", ")+")")
+	objective := fs.String("objective", penalty.ObjectiveF1, "score to optimize ("+strings.Join(penalty.Objectives(), ", ")+")")
+	margin := fs.Int("margin", 10, "tolerance, in samples, of the F1 score")
+	if err := cmdutils.Parse(fs, args, &opts); err != nil {
+		return err
+	}
+	applyVerbose()
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if *dir == "" {
+		return errors.New("-dir is required")
+	}
+	if !slices.Contains(learnModels, *kind) {
+		return fmt.Errorf("unknown model %q (available: %s)", *kind, strings.Join(learnModels, ", "))
+	}
+	if opts.Detector != "pelt" {
+		return fmt.Errorf("learn requires the pelt detector, not %q", opts.Detector)
+	}
+
+	cases, err := discoverCases(*dir)
+	if err != nil {
+		return err
+	}
+	examples := make([]penalty.Example, 0, len(cases))
+	for _, c := range cases {
+		if c.SignalFile == "" {
+			return fmt.Errorf("case %s: no signal file next to %s", c.Name, c.TruthFile)
+		}
+		bkps, err := readBreakpointsFile(c.TruthFile)
+		if err != nil {
+			return fmt.Errorf("case %s: %w", c.Name, err)
+		}
+		signal, _, err := loadSignal(c.SignalFile, nil, in)
+		if err != nil {
+			return fmt.Errorf("case %s: %w", c.Name, err)
+		}
+		examples = append(examples, penalty.Example{Name: c.Name, Signal: signal, Breakpoints: bkps})
+	}
+
+	learnOpts := penalty.LearnOptions{
+		Objective: *objective,
+		Margin:    *margin,
+		Cost:      opts.Cost,
+		MinSize:   opts.MinSize,
+		Jump:      opts.Jump,
+	}
+	learn := penalty.LearnGlobalContext
+	if *kind == "linear" {
+		learn = penalty.LearnLinearContext
+	}
+	model, err := learn(context.Background(), examples, learnOpts)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, model)
+}
+
+// registerPenaltyModel adds the -penalty-model flag, which loads a model written
+// by the learn command into opts.PenaltyModel.
+func registerPenaltyModel(fs *flag.FlagSet, opts *cmdutils.Options) {
+	fs.Func("penalty-model", "JSON penalty model written by the learn command (instead of -penalty)", func(name string) error {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		var model penalty.Model
+		if err := json.Unmarshal(data, &model); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		opts.PenaltyModel = &model
+		return nil
+	})
+}
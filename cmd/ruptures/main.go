@@ -0,0 +1,108 @@
+// Command ruptures detects change points in delimited text files (CSV/TSV).
+//
+// Usage:
+//
+//	ruptures [detect] [flags] [file]
+//	ruptures generate [flags]
+//	ruptures evaluate [flags] (-truth FILE (-pred FILE | -signal FILE) | -dir DIR)
+//	ruptures crops [flags] [file]
+//	ruptures learn [flags] -dir DIR
+//	ruptures stream [flags] [file]
+//	ruptures serve [flags]
+//
+// The signal is read from file (or standard input when file is omitted or "-"),
+// one sample per row and one feature per selected column. The detector and the
+// cost function are selected by name, the cost through the cost factory
+// (cost.NewCost), and the resulting breakpoints are printed as text, JSON or CSV.
+//
+// The generate command writes synthetic signals from the datasets package
+// (piecewise constant, normal or linear) and their true breakpoints, for a given seed.
+// The evaluate command scores predictions against ground truth with the metrics
+// package, for a single case or a directory of cases, and can fail on quality gates.
+// The crops command lists the optimal PELT segmentations for a whole range of
+// penalties (CROPS), to pick the number of change points from an elbow plot.
+// The learn command fits the penalty to a directory of annotated signals; detect
+// and evaluate apply the learned model with -penalty-model.
+// The stream command runs PELT online on a live CSV stream and prints every change
+// point as soon as it is confirmed.
+// The serve command exposes detection over HTTP: POST /detect takes a signal and
+// the detection options and returns the breakpoints with the cost of every segment.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/theDataFlowClub/ruptures/core/logging"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ruptures: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches to the requested subcommand. When the first argument is not
+// a known subcommand, the arguments are handed to the detect command.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "detect":
+			return runDetect(args[1:], stdin, stdout, stderr)
+		case "generate":
+			return runGenerate(args[1:], stdout, stderr)
+		case "evaluate":
+			return runEvaluate(args[1:], stdout, stderr)
+		case "crops":
+			return runCrops(args[1:], stdin, stdout, stderr)
+		case "learn":
+			return runLearn(args[1:], stdout, stderr)
+		case "stream":
+			return runStream(args[1:], stdin, stdout, stderr)
+		case "serve":
+			return runServe(args[1:], stderr)
+		case "help", "-h", "-help", "--help":
+			printUsage(stderr)
+			return nil
+		}
+	}
+	return runDetect(args, stdin, stdout, stderr)
+}
+
+// registerVerbose adds the -v flag, which routes the structured logs of the library
+// (see core/logging) to standard error; the returned function applies it after parsing.
+func registerVerbose(fs *flag.FlagSet, stderr io.Writer) func() {
+	verbose := fs.Bool("v", false, "write debug logs (detector, cost, n_samples, timing) to standard error")
+	return func() {
+		if *verbose {
+			logging.SetLogger(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+		}
+	}
+}
+
+// printUsage writes the top-level help text.
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `Usage: ruptures <command> [flags] [file]
+
+Commands:
+  detect    detect change points in a CSV/TSV signal (default)
+  generate  write a synthetic signal and its true breakpoints
+  evaluate  score predicted breakpoints against the ground truth
+  crops     list the optimal segmentations for a range of penalties
+  learn     learn the penalty from annotated signals
+  stream    detect change points online in a CSV stream
+  serve     serve detection over HTTP (POST /detect)
+  help      show this help
+
+Run "ruptures <command> -h" for the flags of a command.
+`)
+}
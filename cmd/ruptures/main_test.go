@@ -0,0 +1,572 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/inference"
+)
+
+// stepCSV returns a CSV table with a header and a single step at sample 20.
+func stepCSV() string {
+	var b strings.Builder
+	b.WriteString("time,value\n")
+	for i := 0; i < 40; i++ {
+		v := "0"
+		if i >= 20 {
+			v = "10"
+		}
+		b.WriteString(strconv.Itoa(i) + "," + v + "\n")
+	}
+	return b.String()
+}
+
+func TestReadSignal(t *testing.T) {
+	t.Run("HeaderAutoDetectedAndColumnByName", func(t *testing.T) {
+		signal, names, err := readSignal(strings.NewReader("a,b\n1,2\n3,4\n"), ',', inputOptions{Columns: "b", Header: "auto"})
+		if err != nil {
+			t.Fatalf("readSignal failed: %v", err)
+		}
+		if want := [][]float64{{2}, {4}}; !reflect.DeepEqual(signal, want) {
+			t.Errorf("signal = %v, want %v", signal, want)
+		}
+		if !reflect.DeepEqual(names, []string{"b"}) {
+			t.Errorf("names = %v, want [b]", names)
+		}
+	})
+
+	t.Run("TabSeparatedWithoutHeader", func(t *testing.T) {
+		signal, _, err := readSignal(strings.NewReader("1\t2\n3\t4\n"), '\t', inputOptions{Header: "auto"})
+		if err != nil {
+			t.Fatalf("readSignal failed: %v", err)
+		}
+		if want := [][]float64{{1, 2}, {3, 4}}; !reflect.DeepEqual(signal, want) {
+			t.Errorf("signal = %v, want %v", signal, want)
+		}
+	})
+
+	t.Run("UnknownColumn", func(t *testing.T) {
+		if _, _, err := readSignal(strings.NewReader("a\n1\n"), ',', inputOptions{Columns: "z"}); err == nil {
+			t.Error("expected an error for an unknown column")
+		}
+	})
+}
+
+func TestRunDetectJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"detect", "-cost", "l2", "-columns", "value", "-format", "json"}
+	if err := run(args, strings.NewReader(stepCSV()), &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+	}
+
+	// The library is silent by default: stdout holds only the JSON document.
+	out := stdout.String()
+	var got report
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", out, err)
+	}
+	if want := []int{20, 40}; !reflect.DeepEqual(got.Breakpoints, want) {
+		t.Errorf("breakpoints = %v, want %v", got.Breakpoints, want)
+	}
+	if got.NSamples != 40 {
+		t.Errorf("n_samples = %d, want 40", got.NSamples)
+	}
+}
+
+func TestRunDetectStats(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		args := []string{"detect", "-cost", "l2", "-columns", "value", "-format", "json", "-stats"}
+		if err := run(args, strings.NewReader(stepCSV()), &stdout, &stderr); err != nil {
+			t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+		}
+		var got report
+		if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+		}
+		if len(got.Segments) != 2 {
+			t.Fatalf("segments = %+v, want 2", got.Segments)
+		}
+		for i, want := range []float64{0, 10} {
+			seg := got.Segments[i]
+			if seg.Length != 20 || seg.Mean[0] != want || seg.Median[0] != want || seg.Variance[0] != 0 || seg.Cost != 0 {
+				t.Errorf("segment %d = %+v, want a constant segment of 20 samples at %g", i, seg, want)
+			}
+		}
+		if got.PenalizedCost == nil || got.SegmentsCost == nil || *got.PenalizedCost < *got.SegmentsCost {
+			t.Errorf("penalized cost = %v, segments cost = %v", got.PenalizedCost, got.SegmentsCost)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		args := []string{"detect", "-cost", "l2", "-columns", "value", "-format", "csv", "-stats"}
+		if err := run(args, strings.NewReader(stepCSV()), &stdout, &stderr); err != nil {
+			t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+		}
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		want := []string{
+			"segment,start,end,length,cost,mean_value,median_value,variance_value",
+			"0,0,20,20,0,0,0,0",
+			"1,20,40,20,0,10,10,0",
+		}
+		if !reflect.DeepEqual(lines, want) {
+			t.Errorf("output = %q, want %q", lines, want)
+		}
+	})
+}
+
+func TestRunDetectNBkps(t *testing.T) {
+	// -n-bkps searches the penalty giving the number of change points, which is
+	// reported with the segmentation.
+	rng := rand.New(rand.NewSource(3))
+	var b strings.Builder
+	b.WriteString("value\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&b, "%g\n", float64(i/250)*2+rng.NormFloat64())
+	}
+	for _, args := range [][]string{{"-n-bkps", "1"}, {"-n-bkps", "3"}, {"-n-bkps", "3", "-detector", "wbs"}, {"-n-bkps", "3", "-downsample", "5"}} {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"detect", "-columns", "value", "-format", "json", "-stats"}, args...)
+		if err := run(args, strings.NewReader(b.String()), &stdout, &stderr); err != nil {
+			t.Fatalf("%v: run failed: %v (stderr: %s)", args, err, stderr.String())
+		}
+		var got report
+		if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+		}
+		want, _ := strconv.Atoi(args[7])
+		if len(got.Breakpoints) != want+1 {
+			t.Errorf("%v: breakpoints = %v, want %d change points", args, got.Breakpoints, want)
+		}
+		if got.Penalty <= 0 || math.Abs(*got.PenalizedCost-*got.SegmentsCost-float64(want)*got.Penalty) > 1e-6 {
+			t.Errorf("%v: penalty = %g, penalized cost = %g, segments cost = %g", args, got.Penalty, *got.PenalizedCost, *got.SegmentsCost)
+		}
+	}
+}
+
+func TestRunDetectIntervals(t *testing.T) {
+	// The step of stepCSV is noiseless: both methods locate it exactly.
+	t.Run("JSON", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		args := []string{"detect", "-cost", "l2", "-columns", "value", "-format", "json", "-ci", "bootstrap", "-ci-level", "0.9"}
+		if err := run(args, strings.NewReader(stepCSV()), &stdout, &stderr); err != nil {
+			t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+		}
+		var got report
+		if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+		}
+		want := []inference.Interval{{Breakpoint: 20, Lower: 20, Upper: 20, Level: 0.9}}
+		if !reflect.DeepEqual(got.Intervals, want) {
+			t.Errorf("intervals = %+v, want %+v", got.Intervals, want)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		args := []string{"detect", "-cost", "l2", "-columns", "value", "-format", "csv", "-ci", "profile"}
+		if err := run(args, strings.NewReader(stepCSV()), &stdout, &stderr); err != nil {
+			t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+		}
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		want := []string{"segment,start,end,end_lower,end_upper", "0,0,20,20,20", "1,20,40,,"}
+		if !reflect.DeepEqual(lines, want) {
+			t.Errorf("output = %q, want %q", lines, want)
+		}
+	})
+
+	t.Run("UnknownMethod", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		args := []string{"detect", "-cost", "l2", "-columns", "value", "-ci", "jackknife"}
+		if err := run(args, strings.NewReader(stepCSV()), &stdout, &stderr); err == nil {
+			t.Error("expected an error for an unknown method")
+		}
+	})
+}
+
+func TestRunDetectSignificance(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		args := []string{"detect", "-cost", "l2", "-columns", "value", "-format", "csv", "-significance", "lrt"}
+		if err := run(args, strings.NewReader(stepCSV()), &stdout, &stderr); err != nil {
+			t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+		}
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		want := []string{"segment,start,end,p_value,adjusted_p_value", "0,0,20,0,0", "1,20,40,,"}
+		if !reflect.DeepEqual(lines, want) {
+			t.Errorf("output = %q, want %q", lines, want)
+		}
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		// A small penalty finds many spurious change points around the step at 150.
+		rng := rand.New(rand.NewSource(1))
+		var b strings.Builder
+		b.WriteString("value\n")
+		for i := 0; i < 300; i++ {
+			fmt.Fprintf(&b, "%g\n", float64(2*(i/150))+rng.NormFloat64())
+		}
+		detect := func(extra ...string) report {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"detect", "-cost", "l2", "-penalty", "3", "-columns", "value", "-format", "json"}, extra...)
+			if err := run(args, strings.NewReader(b.String()), &stdout, &stderr); err != nil {
+				t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+			}
+			var got report
+			if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+			}
+			return got
+		}
+		all, pruned := detect(), detect("-prune", "-correction", "bonferroni")
+		if len(pruned.Breakpoints) >= len(all.Breakpoints) || len(pruned.Tests) != len(pruned.Breakpoints)-1 {
+			t.Fatalf("pruned %v with tests %+v from %v", pruned.Breakpoints, pruned.Tests, all.Breakpoints)
+		}
+		found := false
+		for _, bt := range pruned.Tests {
+			if !bt.Significant {
+				t.Errorf("kept change point %+v is not significant", bt)
+			}
+			found = found || (bt.Breakpoint >= 145 && bt.Breakpoint <= 155)
+		}
+		if !found {
+			t.Errorf("pruned breakpoints %v miss the change at 150", pruned.Breakpoints)
+		}
+	})
+}
+
+func TestRunDetectRefine(t *testing.T) {
+	// With -jump 5 the step at 23 is found at 25; the refinement moves it back.
+	var b strings.Builder
+	b.WriteString("value\n")
+	for i := 0; i < 60; i++ {
+		fmt.Fprintf(&b, "%d\n", 10*min(i/23, 1))
+	}
+	detect := func(extra ...string) report {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"detect", "-cost", "l2", "-penalty", "200", "-jump", "5", "-columns", "value", "-format", "json", "-stats"}, extra...)
+		if err := run(args, strings.NewReader(b.String()), &stdout, &stderr); err != nil {
+			t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+		}
+		var got report
+		if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+		}
+		return got
+	}
+	if got := detect(); !reflect.DeepEqual(got.Breakpoints, []int{25, 60}) {
+		t.Fatalf("breakpoints without -refine = %v, want [25 60]", got.Breakpoints)
+	}
+	got := detect("-refine", "5")
+	if !reflect.DeepEqual(got.Breakpoints, []int{23, 60}) {
+		t.Errorf("breakpoints = %v, want [23 60]", got.Breakpoints)
+	}
+	if len(got.Segments) != 2 || got.Segments[0].End != 23 || got.SegmentsCost == nil || *got.SegmentsCost != 0 {
+		t.Errorf("segments = %+v, cost = %v, want the refined segments without cost", got.Segments, got.SegmentsCost)
+	}
+}
+
+func TestRunDetectDownsample(t *testing.T) {
+	// The coarse-to-fine detection finds the breakpoints of the full-resolution one.
+	rng := rand.New(rand.NewSource(2))
+	var b strings.Builder
+	b.WriteString("value\n")
+	for i := 0; i < 3000; i++ {
+		level := 0.0
+		if i >= 1234 && i < 2077 {
+			level = 3
+		}
+		fmt.Fprintf(&b, "%g\n", level+rng.NormFloat64())
+	}
+	detect := func(extra ...string) report {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"detect", "-cost", "l2", "-penalty", "50", "-columns", "value", "-format", "json", "-stats"}, extra...)
+		if err := run(args, strings.NewReader(b.String()), &stdout, &stderr); err != nil {
+			t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+		}
+		var got report
+		if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+		}
+		return got
+	}
+	want := detect()
+	if len(want.Breakpoints) != 3 {
+		t.Fatalf("full-resolution breakpoints = %v, want 3", want.Breakpoints)
+	}
+	for _, levels := range []string{"1", "2"} {
+		got := detect("-downsample", "7", "-levels", levels)
+		if !reflect.DeepEqual(got.Breakpoints, want.Breakpoints) || len(got.Segments) != 3 || math.Abs(*got.PenalizedCost-*want.PenalizedCost) > 1e-6 {
+			t.Errorf("levels %s: breakpoints = %v, penalized cost = %g, want %v and %g",
+				levels, got.Breakpoints, *got.PenalizedCost, want.Breakpoints, *want.PenalizedCost)
+		}
+	}
+	for _, args := range [][]string{{"-downsample", "0"}, {"-downsample", "7", "-cost", "l1"}} {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"detect", "-columns", "value"}, args...)
+		if err := run(args, strings.NewReader(b.String()), &stdout, &stderr); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestRunDetectDownsampleMinSize(t *testing.T) {
+	// The minimum segment length and the jump are divided by the block length for
+	// the coarse detection: -min-size 50 must not hide changes every 300 samples.
+	rng := rand.New(rand.NewSource(3))
+	var b strings.Builder
+	b.WriteString("value\n")
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&b, "%g\n", float64(5*((i/300)%2))+rng.NormFloat64())
+	}
+	detect := func(extra ...string) []int {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"detect", "-cost", "l2", "-penalty", "50", "-columns", "value", "-format", "json"}, extra...)
+		if err := run(args, strings.NewReader(b.String()), &stdout, &stderr); err != nil {
+			t.Fatalf("%v: run failed: %v (stderr: %s)", extra, err, stderr.String())
+		}
+		var got report
+		if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+		}
+		return got.Breakpoints
+	}
+	want := detect("-min-size", "50")
+	if len(want) != 20000/300+1 {
+		t.Fatalf("full-resolution breakpoints = %v, want %d", want, 20000/300+1)
+	}
+	for _, extra := range [][]string{{"-min-size", "50", "-downsample", "10"}, {"-min-size", "50", "-jump", "20", "-downsample", "10"}} {
+		if got := detect(extra...); len(got) != len(want) {
+			t.Errorf("%v: %d breakpoints, want %d", extra, len(got), len(want))
+		}
+	}
+}
+
+func TestRunGenerateThenDetect(t *testing.T) {
+	dir := t.TempDir()
+	signalFile := filepath.Join(dir, "signal.csv")
+	bkpsFile := filepath.Join(dir, "bkps.json")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"generate", "-n-samples", "120", "-n-bkps", "2", "-seed", "5", "-o", signalFile, "-bkps-out", bkpsFile}
+	if err := run(args, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("generate failed: %v (stderr: %s)", err, stderr.String())
+	}
+
+	data, err := os.ReadFile(bkpsFile)
+	if err != nil {
+		t.Fatalf("reading breakpoints: %v", err)
+	}
+	var truth struct {
+		Breakpoints []int `json:"breakpoints"`
+	}
+	if err := json.Unmarshal(data, &truth); err != nil {
+		t.Fatalf("invalid breakpoints file: %v", err)
+	}
+
+	// The generated signal is noiseless, so detection must recover the true breakpoints.
+	stdout.Reset()
+	if err := run([]string{"detect", "-penalty", "1", signalFile}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("detect failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	var want []string
+	for _, b := range truth.Breakpoints {
+		want = append(want, strconv.Itoa(b))
+	}
+	if got := lines[len(lines)-1]; got != strings.Join(want, " ") {
+		t.Errorf("detected %q, want %q", got, strings.Join(want, " "))
+	}
+}
+
+func TestRunEvaluateDirectory(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	for _, seed := range []string{"1", "2"} {
+		args := []string{"generate", "-n-samples", "150", "-n-bkps", "2", "-seed", seed,
+			"-o", filepath.Join(dir, "case"+seed+".csv"), "-bkps-out", filepath.Join(dir, "case"+seed+".truth.json")}
+		if err := run(args, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("generate failed: %v", err)
+		}
+	}
+	// A prediction file takes precedence over inline detection.
+	if err := os.WriteFile(filepath.Join(dir, "case2.pred.txt"), []byte("150\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout.Reset()
+	err := run([]string{"evaluate", "-dir", dir, "-penalty", "1", "-format", "json"}, nil, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("evaluate failed: %v (stderr: %s)", err, stderr.String())
+	}
+	var results []map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d rows, want 2 cases and the mean", len(results))
+	}
+	if results[0]["f1"] != 1.0 || results[1]["f1"] != 0.0 || results[1]["hausdorff"] != nil {
+		t.Errorf("unexpected scores: %v", results)
+	}
+	// The infinite Hausdorff distance of case2 is left out of the mean.
+	if mean := results[2]; mean["hausdorff"] != 0.0 || mean["hausdorff_excluded"] != 1.0 {
+		t.Errorf("mean Hausdorff %v with %v excluded, want 0 with 1 excluded", mean["hausdorff"], mean["hausdorff_excluded"])
+	}
+	if err := run([]string{"evaluate", "-dir", dir, "-penalty", "1", "-max-hausdorff", "1"}, nil, &stdout, &stderr); err != nil {
+		t.Errorf("expected the Hausdorff gate to pass on the finite distances, got %v", err)
+	}
+
+	// The mean F1 is 0.5: a stricter gate must fail.
+	err = run([]string{"evaluate", "-dir", dir, "-penalty", "1", "-min-f1", "0.9"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "quality gate") {
+		t.Errorf("expected a quality gate failure, got %v", err)
+	}
+}
+
+func TestRunCrops(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"crops", "-cost", "l2", "-columns", "value", "-pen-min", "1", "-pen-max", "1e4", "-format", "json"}
+	if err := run(args, strings.NewReader(stepCSV()), &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+	}
+	var rows []cropsRow
+	if err := json.Unmarshal(stdout.Bytes(), &rows); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+	}
+	// The noiseless step is the only segmentation up to the penalty at which
+	// a single segment becomes cheaper.
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 segmentations, got %+v", rows)
+	}
+	if want := []int{20, 40}; !reflect.DeepEqual(rows[0].Breakpoints, want) || rows[0].PenaltyMin != 1 {
+		t.Errorf("first segmentation = %+v, want breakpoints %v from penalty 1", rows[0], want)
+	}
+	if rows[1].NBkps != 0 || rows[1].PenaltyMax != 1e4 {
+		t.Errorf("last segmentation = %+v, want no change point up to penalty 1e4", rows[1])
+	}
+}
+
+func TestRunCropsSelect(t *testing.T) {
+	dir := t.TempDir()
+	signalFile := filepath.Join(dir, "signal.json")
+	var stdout, stderr bytes.Buffer
+	args := []string{"generate", "-n-samples", "400", "-n-bkps", "3", "-noise-std", "1", "-seed", "4", "-format", "json", "-o", signalFile}
+	if err := run(args, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("generate failed: %v (stderr: %s)", err, stderr.String())
+	}
+
+	stdout.Reset()
+	if err := run([]string{"crops", "-select", "mbic", "-format", "json", signalFile}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("crops failed: %v (stderr: %s)", err, stderr.String())
+	}
+	var got selectionReport
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+	}
+	if got.Method != "mbic" || got.NBkps != 3 || len(got.Breakpoints) != 4 {
+		t.Errorf("selected %d change points %v with %q, want 3 with mbic", got.NBkps, got.Breakpoints, got.Method)
+	}
+	if len(got.Scores) != len(got.Candidates) || len(got.Candidates) < 2 {
+		t.Errorf("expected one score per candidate, got %d scores for %d candidates", len(got.Scores), len(got.Candidates))
+	}
+
+	// The penalized likelihood methods assume the costs of the l2 cost.
+	for _, method := range []string{"aic", "bic", "mbic"} {
+		err := run([]string{"crops", "-select", method, "-cost", "l1", signalFile}, nil, &stdout, &stderr)
+		if err == nil || !strings.Contains(err.Error(), "calibrated for the l2 cost") {
+			t.Errorf("-select %s -cost l1: expected a cost error, got %v", method, err)
+		}
+	}
+	stdout.Reset()
+	if err := run([]string{"crops", "-select", "elbow", "-cost", "l1", signalFile}, nil, &stdout, &stderr); err != nil {
+		t.Errorf("-select elbow -cost l1 failed: %v", err)
+	}
+}
+
+func TestRunLearn(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	for _, seed := range []string{"1", "2", "3"} {
+		args := []string{"generate", "-n-samples", "300", "-n-bkps", "3", "-noise-std", "1", "-seed", seed,
+			"-o", filepath.Join(dir, "case"+seed+".csv"), "-bkps-out", filepath.Join(dir, "case"+seed+".truth.json")}
+		if err := run(args, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("generate failed: %v", err)
+		}
+	}
+
+	for _, kind := range []string{"global", "linear"} {
+		t.Run(kind, func(t *testing.T) {
+			stdout.Reset()
+			if err := run([]string{"learn", "-dir", dir, "-model", kind}, nil, &stdout, &stderr); err != nil {
+				t.Fatalf("learn failed: %v (stderr: %s)", err, stderr.String())
+			}
+			var model map[string]any
+			if err := json.Unmarshal(stdout.Bytes(), &model); err != nil {
+				t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+			}
+			if model["cost"] != "l2" || model["train_loss"] != 0.0 {
+				t.Errorf("unexpected model: %v", model)
+			}
+			modelFile := filepath.Join(t.TempDir(), "model.json")
+			if err := os.WriteFile(modelFile, stdout.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			// The learned penalty recovers the labels of the training cases.
+			err := run([]string{"evaluate", "-dir", dir, "-penalty-model", modelFile, "-min-f1", "1"}, nil, &stdout, &stderr)
+			if err != nil {
+				t.Errorf("evaluate with the learned model failed: %v", err)
+			}
+			err = run([]string{"detect", "-penalty-model", modelFile, "-cost", "l1", filepath.Join(dir, "case1.csv")}, nil, &stdout, &stderr)
+			if err == nil || !strings.Contains(err.Error(), "learned for the \"l2\" cost") {
+				t.Errorf("expected a cost mismatch error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("time,value\n")
+	for i := 0; i < 60; i++ {
+		fmt.Fprintf(&b, "%d,%d\n", i, 5*(i/20))
+	}
+	var stdout, stderr bytes.Buffer
+	args := []string{"stream", "-columns", "value", "-penalty", "1", "-format", "json"}
+	if err := run(args, strings.NewReader(b.String()), &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v (stderr: %s)", err, stderr.String())
+	}
+	var events []streamEvent
+	dec := json.NewDecoder(&stdout)
+	for dec.More() {
+		var e streamEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("invalid JSON line: %v", err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 || events[0].Breakpoint != 20 || events[1].Breakpoint != 40 {
+		t.Fatalf("expected change points 20 and 40, got %+v", events)
+	}
+	// The first change point is confirmed while the stream is still being read.
+	if events[0].Final || events[0].At >= 60 {
+		t.Errorf("expected change point 20 to be confirmed before the end, got %+v", events[0])
+	}
+
+	for _, args := range [][]string{{"-cost", "l1"}, {"-jump", "5"}} {
+		if err := run(append([]string{"stream"}, args...), strings.NewReader(b.String()), &stdout, &stderr); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/inference"
+)
+
+// report is the outcome of a detection run, as printed by writeReport.
+type report struct {
+	Detector    string   `json:"detector"`
+	Cost        string   `json:"cost"`
+	Penalty     float64  `json:"penalty"`
+	NSamples    int      `json:"n_samples"`
+	Columns     []string `json:"columns"`
+	Breakpoints []int    `json:"breakpoints"`
+
+	// Segment statistics, only set with -stats.
+	Segments      []base.Segment `json:"segments,omitempty"`
+	SegmentsCost  *float64       `json:"segments_cost,omitempty"`
+	PenalizedCost *float64       `json:"penalized_cost,omitempty"`
+
+	// Confidence intervals of the change points, only set with -ci.
+	Intervals []inference.Interval `json:"intervals,omitempty"`
+	// Tests of the change points, only set with -significance or -prune.
+	Tests []inference.BreakpointTest `json:"tests,omitempty"`
+}
+
+// writeReport prints the report in the requested format: "text", "json" or "csv".
+// With segment statistics, the CSV format has one column per statistic and feature;
+// confidence intervals and tests add columns describing the end of every segment
+// but the last (see breakpointHeader).
+func writeReport(w io.Writer, format string, r report) error {
+	switch format {
+	case "text":
+		fields := make([]string, len(r.Breakpoints))
+		for i, b := range r.Breakpoints {
+			fields[i] = strconv.Itoa(b)
+		}
+		_, err := fmt.Fprintln(w, strings.Join(fields, " "))
+		return err
+	case "json":
+		return writeJSON(w, r)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if r.Segments != nil {
+			return writeSegmentsCSV(cw, r)
+		}
+		if err := cw.Write(append([]string{"segment", "start", "end"}, breakpointHeader(r)...)); err != nil {
+			return err
+		}
+		start := 0
+		for i, end := range r.Breakpoints {
+			row := []string{strconv.Itoa(i), strconv.Itoa(start), strconv.Itoa(end)}
+			if err := cw.Write(append(row, breakpointFields(r, i)...)); err != nil {
+				return err
+			}
+			start = end
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown output format %q (want text, json or csv)", format)
+	}
+}
+
+// writeSegmentsCSV writes one row per segment with its statistics. The columns of
+// the per-feature statistics are named after the signal columns, e.g. mean_x.
+func writeSegmentsCSV(cw *csv.Writer, r report) error {
+	header := []string{"segment", "start", "end", "length", "cost"}
+	for _, stat := range []string{"mean", "median", "variance"} {
+		for j := range r.Segments[0].Mean {
+			name := strconv.Itoa(j)
+			if j < len(r.Columns) {
+				name = r.Columns[j]
+			}
+			header = append(header, stat+"_"+name)
+		}
+	}
+	if err := cw.Write(append(header, breakpointHeader(r)...)); err != nil {
+		return err
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	for i, seg := range r.Segments {
+		row := []string{strconv.Itoa(i), strconv.Itoa(seg.Start), strconv.Itoa(seg.End), strconv.Itoa(seg.Length), format(seg.Cost)}
+		for _, values := range [][]float64{seg.Mean, seg.Median, seg.Variance} {
+			for _, v := range values {
+				row = append(row, format(v))
+			}
+		}
+		if err := cw.Write(append(row, breakpointFields(r, i)...)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// breakpointHeader returns the CSV columns describing the end of every segment:
+// the bounds of its confidence interval and its p-values, if any.
+func breakpointHeader(r report) []string {
+	var header []string
+	if r.Intervals != nil {
+		header = append(header, "end_lower", "end_upper")
+	}
+	if r.Tests != nil {
+		header = append(header, "p_value", "adjusted_p_value")
+	}
+	return header
+}
+
+// breakpointFields returns the fields of breakpointHeader for segment i, empty
+// for the last segment, which ends with the signal.
+func breakpointFields(r report, i int) []string {
+	var fields []string
+	if r.Intervals != nil {
+		if i < len(r.Intervals) {
+			fields = append(fields, strconv.Itoa(r.Intervals[i].Lower), strconv.Itoa(r.Intervals[i].Upper))
+		} else {
+			fields = append(fields, "", "")
+		}
+	}
+	if r.Tests != nil {
+		if i < len(r.Tests) {
+			format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+			fields = append(fields, format(r.Tests[i].PValue), format(r.Tests[i].AdjustedPValue))
+		} else {
+			fields = append(fields, "", "")
+		}
+	}
+	return fields
+}
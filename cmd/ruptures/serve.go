@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"
+	"github.com/theDataFlowClub/ruptures/core/logging"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// serverConfig holds the settings of the HTTP service.
+type serverConfig struct {
+	Defaults cmdutils.Options // Detection options used when a request does not override them.
+	MaxBody  int64            // Maximum request body size, in bytes.
+	Timeout  time.Duration    // Maximum duration of a detection.
+	// MaxSamples is the maximum number of samples of a signal, and
+	// MaxKernelSamples the lower one for the costs in quadraticCosts.
+	MaxSamples       int
+	MaxKernelSamples int
+}
+
+// quadraticCosts are the costs whose memory grows with the square of the number of
+// samples: rbf builds the n×n Gram matrix of the signal when it is fitted, which
+// cannot be interrupted by the timeout.
+var quadraticCosts = map[string]bool{"rbf": true}
+
+// detectResponse is the body returned by POST /detect.
+type detectResponse struct {
+	Detector    string         `json:"detector"`
+	Cost        string         `json:"cost"`
+	Penalty     float64        `json:"penalty,omitempty"`
+	NBkps       int            `json:"n_bkps,omitempty"`
+	NSamples    int            `json:"n_samples"`
+	Breakpoints []int          `json:"breakpoints"`
+	Segments    []base.Segment `json:"segments"`
+	TotalCost   float64        `json:"total_cost"`
+}
+
+// httpError is an error carrying the HTTP status to report.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string { return e.err.Error() }
+func (e *httpError) Unwrap() error { return e.err }
+
+// badRequest wraps err as a 400 error.
+func badRequest(err error) error { return &httpError{http.StatusBadRequest, err} }
+
+// runServe implements the serve command.
+func runServe(args []string, stderr io.Writer) error {
+	fs := cmdutils.NewFlagSet("serve", stderr)
+	cmdutils.SetUsage(fs, "ruptures serve [flags]",
+		`Serve change point detection over HTTP.
+
+POST /detect accepts either a JSON body {"signal": [...], "cost": "l2", "penalty": 5, ...}
+whose option names are the detection flags with "_" instead of "-", or a CSV/TSV body
+(Content-Type text/csv or text/tab-separated-values) with the options as query
+parameters. It returns the breakpoints and the cost of every segment.
+The detection flags below set the defaults of the requests.`)
+	cfg := serverConfig{Defaults: cmdutils.DefaultOptions()}
+	cfg.Defaults.RegisterFlags(fs)
+	applyVerbose := registerVerbose(fs, stderr)
+	addr := fs.String("addr", "127.0.0.1:8080", "listen address")
+	fs.Int64Var(&cfg.MaxBody, "max-body", 10<<20, "maximum request body size in bytes")
+	fs.DurationVar(&cfg.Timeout, "timeout", 30*time.Second, "maximum duration of a detection")
+	fs.IntVar(&cfg.MaxSamples, "max-samples", 1_000_000, "maximum number of samples of a signal")
+	fs.IntVar(&cfg.MaxKernelSamples, "max-kernel-samples", 5000, "maximum number of samples of a signal with the rbf cost, whose memory is quadratic")
+	if err := cmdutils.Parse(fs, args, &cfg.Defaults); err != nil {
+		return err
+	}
+	applyVerbose()
+	if cfg.MaxBody <= 0 || cfg.Timeout <= 0 || cfg.MaxSamples <= 0 || cfg.MaxKernelSamples <= 0 {
+		return errors.New("-max-body, -timeout, -max-samples and -max-kernel-samples must be positive")
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newServeMux(cfg),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       cfg.Timeout,
+		WriteTimeout:      cfg.Timeout + 5*time.Second,
+		IdleTimeout:       time.Minute,
+		ErrorLog:          log.New(stderr, "ruptures serve: ", log.LstdFlags),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	fmt.Fprintf(stderr, "ruptures serve: listening on %s\n", *addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// newServeMux returns the handler of the HTTP service.
+func newServeMux(cfg serverConfig) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+	mux.HandleFunc("POST /detect", func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxBody)
+		resp, err := handleDetect(r, cfg)
+		if err != nil {
+			status := http.StatusUnprocessableEntity
+			var herr *httpError
+			var maxErr *http.MaxBytesError
+			switch {
+			case errors.As(err, &maxErr):
+				status = http.StatusRequestEntityTooLarge
+			case errors.As(err, &herr):
+				status = herr.status
+			}
+			logging.Logger().Info("detect request failed", "status", status, "error", err, logging.KeyDuration, time.Since(start))
+			writeJSONResponse(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		logging.Logger().Info("detect request",
+			"status", http.StatusOK,
+			logging.KeyDetector, resp.Detector,
+			logging.KeyCost, resp.Cost,
+			logging.KeyNSamples, resp.NSamples,
+			logging.KeyNBkps, len(resp.Breakpoints)-1,
+			logging.KeyDuration, time.Since(start))
+		writeJSONResponse(w, http.StatusOK, resp)
+	})
+	return mux
+}
+
+// setRequestFlags applies the options of a request (query parameters or JSON
+// fields, named as the flags with "_") over the server defaults. A request setting
+// the penalty or the number of change points replaces both defaults, which are
+// mutually exclusive.
+func setRequestFlags(fs *flag.FlagSet, opts *cmdutils.Options, params map[string]string, kind string) error {
+	for name := range params {
+		if n := flagName(name); n == "penalty" || n == "n-bkps" {
+			opts.ResetPenalty()
+			break
+		}
+	}
+	for name, value := range params {
+		if err := fs.Set(flagName(name), value); err != nil {
+			return badRequest(fmt.Errorf("%s %q: %w", kind, name, err))
+		}
+	}
+	return nil
+}
+
+// handleDetect decodes the request, runs the detection and builds the response.
+func handleDetect(r *http.Request, cfg serverConfig) (*detectResponse, error) {
+	opts := cfg.Defaults
+	fs := cmdutils.NewFlagSet("request", io.Discard)
+	opts.RegisterFlags(fs)
+	var in inputOptions
+	in.register(fs)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+
+	var signal types.Matrix
+	mediaType := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+	switch mediaType {
+	case "text/csv", "text/tab-separated-values":
+		params := make(map[string]string)
+		for name, values := range r.URL.Query() {
+			params[name] = values[len(values)-1]
+		}
+		if err := setRequestFlags(fs, &opts, params, "parameter"); err != nil {
+			return nil, err
+		}
+		comma := ','
+		if mediaType == "text/tab-separated-values" {
+			comma = '\t'
+		}
+		if in.Delimiter != "" {
+			if comma, err = delimiterFor("", in.Delimiter); err != nil {
+				return nil, badRequest(err)
+			}
+		}
+		if signal, _, err = readSignal(bytes.NewReader(body), comma, in); err != nil {
+			return nil, badRequest(err)
+		}
+	default:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, badRequest(fmt.Errorf("invalid JSON body: %w", err))
+		}
+		raw, ok := fields["signal"]
+		if !ok {
+			return nil, badRequest(errors.New(`missing "signal" field`))
+		}
+		delete(fields, "signal")
+		params := make(map[string]string, len(fields))
+		for name, value := range fields {
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				s = string(value)
+			}
+			params[name] = s
+		}
+		if err := setRequestFlags(fs, &opts, params, "field"); err != nil {
+			return nil, err
+		}
+		if signal, err = readSignalJSON(bytes.NewReader(raw)); err != nil {
+			return nil, badRequest(err)
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, badRequest(err)
+	}
+	if err := checkSamples(cfg, opts.Cost, len(signal)); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), cfg.Timeout)
+	defer cancel()
+	resp, err := detectWithCosts(ctx, &opts, signal)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, &httpError{http.StatusServiceUnavailable, fmt.Errorf("detection did not finish within %s", cfg.Timeout)}
+	}
+	return resp, err
+}
+
+// checkSamples returns a 413 error when the signal has more samples than the
+// server accepts for the cost.
+func checkSamples(cfg serverConfig, model string, nSamples int) error {
+	limit := cfg.MaxSamples
+	if quadraticCosts[model] {
+		limit = min(limit, cfg.MaxKernelSamples)
+	}
+	if nSamples > limit {
+		return &httpError{http.StatusRequestEntityTooLarge,
+			fmt.Errorf("the signal has %d samples, more than the %d accepted with the %s cost", nSamples, limit, model)}
+	}
+	return nil
+}
+
+// detectWithCosts runs the detection and computes the statistics and the cost of
+// every segment.
+func detectWithCosts(ctx context.Context, opts *cmdutils.Options, signal types.Matrix) (*detectResponse, error) {
+	bkps, costFunc, err := detect(ctx, opts, signal)
+	if err != nil {
+		return nil, err
+	}
+	res, err := base.NewResult(signal, costFunc, bkps, opts.Penalty)
+	if err != nil {
+		return nil, err
+	}
+	resp := &detectResponse{
+		Detector:    opts.Detector,
+		Cost:        costFunc.Model(),
+		NSamples:    len(signal),
+		Breakpoints: bkps,
+		Segments:    res.Segments,
+		TotalCost:   res.Cost,
+	}
+	if opts.NBkps > 0 {
+		resp.NBkps = opts.NBkps
+	} else {
+		resp.Penalty = opts.Penalty
+	}
+	return resp, nil
+}
+
+// flagName maps a request parameter name (e.g. "min_size") to its flag name ("min-size").
+func flagName(name string) string {
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+// writeJSONResponse writes v as a JSON response with the given status.
+func writeJSONResponse(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
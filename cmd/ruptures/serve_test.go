@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"
+)
+
+func TestServeDetect(t *testing.T) {
+	srv := httptest.NewServer(newServeMux(serverConfig{
+		Defaults:         cmdutils.DefaultOptions(),
+		MaxBody:          1 << 16,
+		Timeout:          10 * time.Second,
+		MaxSamples:       1000,
+		MaxKernelSamples: 30,
+	}))
+	defer srv.Close()
+
+	step := make([]string, 40)
+	for i := range step {
+		step[i] = "0"
+		if i >= 20 {
+			step[i] = "10"
+		}
+	}
+	jsonBody := `{"signal": [` + strings.Join(step, ",") + `], "cost": "l2", "penalty": 1, "min_size": 2}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		query       string
+		body        string
+		wantStatus  int
+	}{
+		{"JSON", "application/json", "", jsonBody, http.StatusOK},
+		{"CSV", "text/csv", "?cost=l2&columns=value", stepCSV(), http.StatusOK},
+		{"MissingSignal", "application/json", "", `{"cost": "l2"}`, http.StatusBadRequest},
+		{"UnknownOption", "application/json", "", `{"signal": [1, 2], "foo": 1}`, http.StatusBadRequest},
+		{"UnknownCost", "application/json", "", `{"signal": [1, 2], "cost": "nope"}`, http.StatusBadRequest},
+		{"TooLarge", "application/json", "", `{"signal": [` + strings.Repeat("1,", 1<<16) + `1]}`, http.StatusRequestEntityTooLarge},
+		{"TooManySamples", "application/json", "", `{"signal": [` + strings.Repeat("1,", 1000) + `1]}`, http.StatusRequestEntityTooLarge},
+		{"TooManyKernelSamples", "application/json", "", `{"signal": [` + strings.Join(step, ",") + `], "cost": "rbf"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL+"/detect"+tt.query, tt.contentType, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST /detect failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got detectResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			if want := []int{20, 40}; !reflect.DeepEqual(got.Breakpoints, want) {
+				t.Errorf("breakpoints = %v, want %v", got.Breakpoints, want)
+			}
+			if len(got.Segments) != 2 || got.Segments[1].Start != 20 || got.Segments[1].End != 40 {
+				t.Errorf("segments = %+v, want [0, 20) and [20, 40)", got.Segments)
+			}
+			if got.TotalCost != 0 {
+				t.Errorf("total_cost = %v, want 0 for a noiseless step", got.TotalCost)
+			}
+		})
+	}
+}
+
+func TestServeOverridesPenaltyDefaults(t *testing.T) {
+	step := make([]string, 40)
+	for i := range step {
+		step[i] = "0"
+		if i >= 20 {
+			step[i] = "10"
+		}
+	}
+	signal := `"signal": [` + strings.Join(step, ",") + `]`
+
+	// The penalty and the number of change points of a request replace both
+	// defaults of the server, which are mutually exclusive.
+	tests := []struct {
+		name     string
+		defaults []string
+		body     string
+	}{
+		{name: "NBkpsOverPenalty", defaults: []string{"-penalty", "10"}, body: `{` + signal + `, "n_bkps": 1}`},
+		{name: "PenaltyOverNBkps", defaults: []string{"-n-bkps", "3"}, body: `{` + signal + `, "penalty": 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaults := cmdutils.DefaultOptions()
+			fs := cmdutils.NewFlagSet("serve", io.Discard)
+			defaults.RegisterFlags(fs)
+			if err := cmdutils.Parse(fs, tt.defaults, &defaults); err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			srv := httptest.NewServer(newServeMux(serverConfig{Defaults: defaults, MaxBody: 1 << 16, Timeout: 10 * time.Second, MaxSamples: 1000}))
+			defer srv.Close()
+
+			resp, err := http.Post(srv.URL+"/detect", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST /detect failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				t.Fatalf("status = %d (%s), want %d", resp.StatusCode, body, http.StatusOK)
+			}
+			var got detectResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			if want := []int{20, 40}; !reflect.DeepEqual(got.Breakpoints, want) {
+				t.Errorf("breakpoints = %v, want %v", got.Breakpoints, want)
+			}
+		})
+	}
+}
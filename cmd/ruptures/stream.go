@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+)
+
+// streamEvent is a change point reported by the stream command.
+type streamEvent struct {
+	Breakpoint int  `json:"breakpoint"`
+	At         int  `json:"at"`    // Number of samples read when it was reported.
+	Final      bool `json:"final"` // Reported at the end of the input rather than confirmed.
+}
+
+// runStream implements the stream command.
+func runStream(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := cmdutils.NewFlagSet("stream", stderr)
+	cmdutils.SetUsage(fs, "ruptures stream [flags] [file]",
+		`Detect change points online in a CSV/TSV stream, one sample per row, with the
+streaming PELT detector (l2 cost). Every change point is printed as soon as no
+later sample can remove it, with the number of samples read at that time; the
+change points still pending at the end of the input are printed last.`)
+	opts := cmdutils.DefaultOptions()
+	opts.RegisterFlags(fs)
+	var in inputOptions
+	in.register(fs)
+	applyVerbose := registerVerbose(fs, stderr)
+	maxCandidates := fs.Int("max-candidates", 0, "maximum number of PELT candidates kept in memory (0: unlimited)")
+	if err := cmdutils.Parse(fs, args, &opts); err != nil {
+		return err
+	}
+	applyVerbose()
+	switch {
+	case fs.NArg() > 1:
+		return fmt.Errorf("expected at most one input file, got %d", fs.NArg())
+	case opts.Detector != "pelt" || opts.Cost != "l2":
+		return fmt.Errorf("stream supports the pelt detector with the l2 cost, not %s/%s", opts.Detector, opts.Cost)
+	case opts.NBkps > 0 || opts.PenaltyCriterion != "":
+		return errors.New("stream requires a numeric -penalty")
+	case opts.Jump > 1:
+		return errors.New("stream does not support -jump")
+	case *maxCandidates < 0:
+		return fmt.Errorf("-max-candidates must be non-negative, got %d", *maxCandidates)
+	}
+
+	f, err := openInput(fs.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	comma, err := delimiterFor(fs.Arg(0), in.Delimiter)
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(f)
+	reader.Comma = comma
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	detector := pelt.NewOnline(opts.Penalty, opts.MinSize)
+	detector.MaxCandidates = *maxCandidates
+	emit := newStreamWriter(stdout, opts.Format)
+	var indices []int
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading input: %w", err)
+		}
+		if indices == nil {
+			var header []string
+			switch in.Header {
+			case "yes":
+				header = record
+			case "no":
+			case "auto", "":
+				if !isNumericRow(record) {
+					header = record
+				}
+			default:
+				return fmt.Errorf("header must be auto, yes or no, got %q", in.Header)
+			}
+			if indices, err = selectColumns(in.Columns, header, len(record)); err != nil {
+				return err
+			}
+			if header != nil {
+				continue
+			}
+		}
+		sample := make([]float64, len(indices))
+		for j, col := range indices {
+			if col >= len(record) {
+				return fmt.Errorf("row %d has %d columns, column %d requested", row, len(record), col)
+			}
+			if sample[j], err = strconv.ParseFloat(strings.TrimSpace(record[col]), 64); err != nil {
+				return fmt.Errorf("row %d, column %d: %w", row, col, err)
+			}
+		}
+		confirmed, err := detector.Push(sample)
+		if err != nil {
+			return err
+		}
+		for _, c := range confirmed {
+			if err := emit(streamEvent{Breakpoint: c, At: detector.NSamples()}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, c := range detector.Flush() {
+		if err := emit(streamEvent{Breakpoint: c, At: detector.NSamples(), Final: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newStreamWriter returns a function printing one event per line: the change point
+// alone ("text"), a JSON object ("json") or a CSV record with a header ("csv").
+func newStreamWriter(w io.Writer, format string) func(streamEvent) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		return func(e streamEvent) error { return enc.Encode(e) }
+	case "csv":
+		cw := csv.NewWriter(w)
+		headerWritten := false
+		return func(e streamEvent) error {
+			if !headerWritten {
+				cw.Write([]string{"breakpoint", "at", "final"})
+				headerWritten = true
+			}
+			cw.Write([]string{strconv.Itoa(e.Breakpoint), strconv.Itoa(e.At), strconv.FormatBool(e.Final)})
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return func(e streamEvent) error {
+			_, err := fmt.Fprintln(w, e.Breakpoint)
+			return err
+		}
+	}
+}
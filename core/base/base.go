@@ -4,7 +4,11 @@
 // ensuring a consistent API and facilitating modularity and testability.
 package base
 
-import "github.com/theDataFlowClub/ruptures/core/types"
+import (
+	"context"
+
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
 
 // Estimator is the base interface for all change point detection algorithms.
 // Any algorithm implementing this interface must provide methods to:
@@ -32,6 +36,45 @@ type Estimator interface {
 	FitPredict(signal types.Matrix, penalty float64) ([]int, error)
 }
 
+// ContextEstimator is implemented by estimators whose prediction can be aborted.
+// PredictContext behaves like Predict but checks ctx inside its main loops and
+// returns ctx.Err() promptly once the context is cancelled or its deadline expires.
+type ContextEstimator interface {
+	Estimator
+	PredictContext(ctx context.Context, penalty float64) ([]int, error)
+}
+
+// PredictContext predicts the change points of a fitted estimator under ctx.
+// Estimators implementing ContextEstimator are cancelled from within their loops;
+// for the others, ctx is only checked before and after the call to Predict.
+//
+// Parameters:
+//
+//	ctx:     The context bounding the prediction.
+//	est:     A fitted estimator.
+//	penalty: The penalty passed to the estimator.
+//
+// Returns:
+//
+//	[]int: The predicted breakpoints.
+//	error: ctx.Err() if the context ends first, or the error returned by the estimator.
+func PredictContext(ctx context.Context, est Estimator, penalty float64) ([]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if ce, ok := est.(ContextEstimator); ok {
+		return ce.PredictContext(ctx, penalty)
+	}
+	bkps, err := est.Predict(penalty)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return bkps, nil
+}
+
 // CostFunction is the base interface for all segment cost functions.
 // Cost functions quantify the "cost" or "error" within a given segment of a signal.
 // They are crucial for change point detection algorithms to evaluate potential segmentations.
@@ -0,0 +1,64 @@
+package base_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// MockEstimator is a base.Estimator without context support that returns fixed breakpoints.
+type MockEstimator struct {
+	bkps []int
+}
+
+func (m *MockEstimator) Fit(signal types.Matrix) error          { return nil }
+func (m *MockEstimator) Predict(penalty float64) ([]int, error) { return m.bkps, nil }
+func (m *MockEstimator) FitPredict(signal types.Matrix, penalty float64) ([]int, error) {
+	return m.bkps, nil
+}
+
+// MockContextEstimator also implements base.ContextEstimator and records the call.
+type MockContextEstimator struct {
+	MockEstimator
+	calledWithContext bool
+}
+
+func (m *MockContextEstimator) PredictContext(ctx context.Context, penalty float64) ([]int, error) {
+	m.calledWithContext = true
+	return m.bkps, nil
+}
+
+func TestPredictContext(t *testing.T) {
+	t.Run("FallsBackToPredict", func(t *testing.T) {
+		est := &MockEstimator{bkps: []int{5, 10}}
+		bkps, err := base.PredictContext(context.Background(), est, 1.0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(bkps, []int{5, 10}) {
+			t.Errorf("expected [5 10], got %v", bkps)
+		}
+	})
+
+	t.Run("UsesContextEstimator", func(t *testing.T) {
+		est := &MockContextEstimator{MockEstimator: MockEstimator{bkps: []int{10}}}
+		if _, err := base.PredictContext(context.Background(), est, 1.0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !est.calledWithContext {
+			t.Error("expected PredictContext of the estimator to be called")
+		}
+	})
+
+	t.Run("CancelledContext", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := base.PredictContext(ctx, &MockEstimator{bkps: []int{10}}, 1.0); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package base
+
+import "time"
+
+// Progress describes the state of a running detection, as reported to a ProgressFunc.
+type Progress struct {
+	// Processed is the number of samples processed so far.
+	Processed int
+	// Total is the number of samples of the signal.
+	Total int
+	// Candidates is the number of change point candidates still alive
+	// (for PELT, the admissible candidates that survived pruning).
+	Candidates int
+	// Elapsed is the time spent since the prediction started.
+	Elapsed time.Duration
+}
+
+// Fraction returns the fraction of samples processed, in [0, 1].
+func (p Progress) Fraction() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Processed) / float64(p.Total)
+}
+
+// ProgressFunc receives progress updates from a running detection.
+// It is called synchronously from the detection loop, so it should return quickly.
+// Detectors report at most about a hundred updates per prediction, the last one
+// with Processed equal to Total.
+type ProgressFunc func(Progress)
+
+// ProgressReporter is implemented by estimators that can report their progress.
+// Passing a nil ProgressFunc disables reporting.
+type ProgressReporter interface {
+	SetProgressFunc(fn ProgressFunc)
+}
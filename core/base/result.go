@@ -0,0 +1,144 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/stat"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Segment describes one segment [Start, End) of a segmentation, with per-feature
+// statistics of its samples and its cost.
+type Segment struct {
+	Start    int       `json:"start"`
+	End      int       `json:"end"`
+	Length   int       `json:"length"`
+	Mean     []float64 `json:"mean"`     // Mean of every feature.
+	Median   []float64 `json:"median"`   // Median of every feature.
+	Variance []float64 `json:"variance"` // Population variance of every feature.
+	Cost     float64   `json:"cost"`     // Cost of the segment under the cost function.
+}
+
+// Result is a segmentation with the statistics callers usually recompute from the
+// breakpoints: the segments and the costs.
+type Result struct {
+	Breakpoints []int     `json:"breakpoints"`
+	Segments    []Segment `json:"segments"`
+	Penalty     float64   `json:"penalty"`
+	// Cost is the sum of the segment costs.
+	Cost float64 `json:"cost"`
+	// PenalizedCost is Cost plus Penalty for every change point: the objective
+	// minimized by penalized detectors such as PELT.
+	PenalizedCost float64 `json:"penalized_cost"`
+}
+
+// NBkps returns the number of change points of the result.
+func (r Result) NBkps() int {
+	return max(len(r.Breakpoints)-1, 0)
+}
+
+// ResultEstimator is implemented by estimators that can return a Result directly,
+// from the signal and the cost function they were fitted with, so that callers
+// need not pass them. The segment costs are evaluated again from the breakpoints,
+// as NewResult does.
+type ResultEstimator interface {
+	Estimator
+	PredictResultContext(ctx context.Context, penalty float64) (Result, error)
+}
+
+// PredictResult predicts the segmentation of a fitted estimator under ctx and
+// returns it as a Result. Estimators implementing ResultEstimator build it
+// themselves; for the others, the breakpoints of PredictContext are passed to
+// NewResult with the signal and the cost function, which must be fitted on it.
+func PredictResult(ctx context.Context, est Estimator, signal types.Matrix, cost CostFunction, penalty float64) (Result, error) {
+	if re, ok := est.(ResultEstimator); ok {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		return re.PredictResultContext(ctx, penalty)
+	}
+	bkps, err := PredictContext(ctx, est, penalty)
+	if err != nil {
+		return Result{}, err
+	}
+	return NewResult(signal, cost, bkps, penalty)
+}
+
+// CheckBreakpoints checks that bkps segment the signal: the signal is not empty
+// and the breakpoints are positive, strictly increasing and end with its number
+// of samples.
+func CheckBreakpoints(signal types.Matrix, bkps []int) error {
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return exceptions.ErrInvalidSignal
+	}
+	if len(bkps) == 0 || bkps[len(bkps)-1] != len(signal) {
+		return fmt.Errorf("breakpoints %v do not end with the number of samples %d", bkps, len(signal))
+	}
+	prev := 0
+	for _, b := range bkps {
+		if b <= prev {
+			return fmt.Errorf("breakpoints %v are not increasing", bkps)
+		}
+		prev = b
+	}
+	return nil
+}
+
+// NewResult computes the Result of a segmentation of signal.
+//
+// Parameters:
+//
+//	signal:  The segmented signal.
+//	cost:    The cost function, already fitted on signal.
+//	bkps:    The breakpoints, sorted and ending with the number of samples.
+//	penalty: The penalty per change point used to compute PenalizedCost.
+//
+// Returns:
+//
+//	Result: The segments, their statistics and costs. A segment too short for the
+//	        cost model (exceptions.ErrNotEnoughPoints) has a cost of 0.
+//	error:  An error when the breakpoints do not segment the signal or a cost fails.
+func NewResult(signal types.Matrix, cost CostFunction, bkps []int, penalty float64) (Result, error) {
+	if err := CheckBreakpoints(signal, bkps); err != nil {
+		return Result{}, err
+	}
+	res := Result{Breakpoints: bkps, Segments: make([]Segment, 0, len(bkps)), Penalty: penalty}
+	nFeatures := len(signal[0])
+	values := make([]float64, 0, len(signal))
+	start := 0
+	for _, end := range bkps {
+		seg := Segment{
+			Start:    start,
+			End:      end,
+			Length:   end - start,
+			Mean:     make([]float64, nFeatures),
+			Median:   make([]float64, nFeatures),
+			Variance: make([]float64, nFeatures),
+		}
+		for j := 0; j < nFeatures; j++ {
+			values = values[:0]
+			for _, row := range signal[start:end] {
+				values = append(values, row[j])
+			}
+			// The slice is never empty, so the statistics cannot fail.
+			seg.Mean[j], _ = stat.Mean(values)
+			seg.Median[j], _ = stat.Median(values)
+			seg.Variance[j], _ = stat.Variance(values)
+		}
+		c, err := cost.Error(start, end)
+		if errors.Is(err, exceptions.ErrNotEnoughPoints) {
+			c = 0
+		} else if err != nil {
+			return Result{}, fmt.Errorf("cost of segment [%d, %d): %w", start, end, err)
+		}
+		seg.Cost = c
+		res.Cost += c
+		res.Segments = append(res.Segments, seg)
+		start = end
+	}
+	res.PenalizedCost = res.Cost + float64(res.NBkps())*penalty
+	return res, nil
+}
@@ -0,0 +1,102 @@
+package base_test
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+func TestNewResult(t *testing.T) {
+	signal := types.Matrix{{0, 1}, {2, 1}, {1, 1}, {10, 5}, {12, 7}}
+	c := cost.NewCostL2()
+	if err := c.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	res, err := base.NewResult(signal, c, []int{3, 5}, 4)
+	if err != nil {
+		t.Fatalf("NewResult failed: %v", err)
+	}
+	want := []base.Segment{
+		{Start: 0, End: 3, Length: 3, Mean: []float64{1, 1}, Median: []float64{1, 1}, Variance: []float64{2.0 / 3, 0}, Cost: 2},
+		{Start: 3, End: 5, Length: 2, Mean: []float64{11, 6}, Median: []float64{11, 6}, Variance: []float64{1, 1}, Cost: 4},
+	}
+	if len(res.Segments) != len(want) {
+		t.Fatalf("segments = %+v, want %+v", res.Segments, want)
+	}
+	for i, seg := range res.Segments {
+		w := want[i]
+		if seg.Start != w.Start || seg.End != w.End || seg.Length != w.Length || math.Abs(seg.Cost-w.Cost) > 1e-9 ||
+			!near(seg.Mean, w.Mean) || !near(seg.Median, w.Median) || !near(seg.Variance, w.Variance) {
+			t.Errorf("segment %d = %+v, want %+v", i, seg, w)
+		}
+	}
+	if math.Abs(res.Cost-6) > 1e-9 || math.Abs(res.PenalizedCost-10) > 1e-9 || res.NBkps() != 1 {
+		t.Errorf("cost = %g, penalized cost = %g, n_bkps = %d; want 6, 10, 1", res.Cost, res.PenalizedCost, res.NBkps())
+	}
+
+	for _, bkps := range [][]int{{3}, {3, 3, 5}, nil} {
+		if _, err := base.NewResult(signal, c, bkps, 4); err == nil {
+			t.Errorf("NewResult(%v) expected an error", bkps)
+		}
+	}
+}
+
+func TestCheckBreakpoints(t *testing.T) {
+	signal := types.Matrix{{0}, {1}, {2}, {3}}
+	tests := []struct {
+		name        string
+		signal      types.Matrix
+		bkps        []int
+		expectError bool
+	}{
+		{name: "Valid", signal: signal, bkps: []int{1, 3, 4}},
+		{name: "NoChange", signal: signal, bkps: []int{4}},
+		{name: "Empty", signal: signal, bkps: nil, expectError: true},
+		{name: "WrongEnd", signal: signal, bkps: []int{2, 3}, expectError: true},
+		{name: "Repeated", signal: signal, bkps: []int{2, 2, 4}, expectError: true},
+		{name: "Zero", signal: signal, bkps: []int{0, 4}, expectError: true},
+		{name: "Decreasing", signal: signal, bkps: []int{3, 1, 4}, expectError: true},
+		{name: "EmptySignal", signal: types.Matrix{}, bkps: []int{0}, expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := base.CheckBreakpoints(tt.signal, tt.bkps); (err != nil) != tt.expectError {
+				t.Errorf("CheckBreakpoints(%v) error = %v, expectError %v", tt.bkps, err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestPredictResult(t *testing.T) {
+	signal := types.Matrix{{0}, {0}, {4}, {4}}
+	c := cost.NewCostL2()
+	if err := c.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	est := &MockEstimator{bkps: []int{2, 4}}
+	res, err := base.PredictResult(context.Background(), est, signal, c, 1)
+	if err != nil {
+		t.Fatalf("PredictResult failed: %v", err)
+	}
+	if !reflect.DeepEqual(res.Breakpoints, []int{2, 4}) || res.Cost != 0 || res.PenalizedCost != 1 {
+		t.Errorf("result = %+v", res)
+	}
+}
+
+// near reports whether two slices are equal up to rounding.
+func near(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
@@ -1,34 +1,432 @@
+// Package cmdutils provides the command-line argument layer shared by the ruptures
+// commands and examples. It registers named flags for the detection parameters
+// (detector, cost model, penalty, number of breakpoints, segment constraints,
+// kernel options and output format), validates them, and builds the corresponding
+// cost function and detector.
 package cmdutils
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/detection/fpop"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/detection/wbs"
+	"github.com/theDataFlowClub/ruptures/core/penalty"
+	"github.com/theDataFlowClub/ruptures/core/types"
 )
 
-// ParametersFromArgs es una estructura para almacenar los parámetros parseados.
-type ParametersFromArgs struct {
-	CostFuncName string
-	Penalty      float64
+// ErrNBkpsUnreachable is returned when no penalty gives the fixed number of
+// breakpoints: the number of change points of a detector is a step function of
+// the penalty, which may skip some values.
+var ErrNBkpsUnreachable = errors.New("no penalty gives the requested number of change points")
+
+// Options holds the detection parameters that can be set from the command line.
+type Options struct {
+	Detector string  // Name of the detection algorithm (e.g. "pelt").
+	Cost     string  // Name of the cost function model, resolved through cost.NewCost.
+	Penalty  float64 // Penalty value; mutually exclusive with NBkps.
+	NBkps    int     // Fixed number of breakpoints; 0 means "use Penalty".
+	MinSize  int     // Minimum segment length.
+	Jump     int     // Subsample step between admissible breakpoints.
+	Gamma    float64 // Bandwidth of the RBF kernel; 0 selects the median heuristic.
+	Seed     int64   // Seed of the randomized detectors (e.g. "wbs").
+	Format   string  // Output format: "text", "json" or "csv".
+
+	// PenaltyCriterion, when set (e.g. "bic"), derives Penalty from the signal
+	// with the penalty package; see ResolvePenalty.
+	PenaltyCriterion string
+
+	// PenaltyModel, when set, predicts Penalty from the signal; see ResolvePenalty.
+	// Validate also applies the MinSize and Jump the model was learned with,
+	// unless -min-size or -jump was given.
+	PenaltyModel *penalty.Model
+
+	// Progress, when set, is installed on detectors implementing base.ProgressReporter.
+	Progress base.ProgressFunc
+
+	penaltySet bool // Whether -penalty was given explicitly.
+	minSizeSet bool // Whether -min-size was given explicitly.
+	jumpSet    bool // Whether -jump was given explicitly.
+}
+
+// DefaultOptions returns the options used when no flag is given.
+func DefaultOptions() Options {
+	return Options{
+		Detector: "pelt",
+		Cost:     "l2",
+		Penalty:  5.0,
+		MinSize:  2,
+		Jump:     1,
+		Format:   "text",
+	}
+}
+
+// Formats lists the accepted values of Options.Format.
+var Formats = []string{"text", "json", "csv"}
+
+// detectorFactories maps detector names to their constructors.
+var detectorFactories = map[string]func(o *Options, c base.CostFunction) base.Estimator{
+	"pelt": func(o *Options, c base.CostFunction) base.Estimator {
+		return pelt.NewPelt(c, o.MinSize, o.Jump)
+	},
+	"fpop": func(o *Options, c base.CostFunction) base.Estimator {
+		return fpopDetector{FPOP: fpop.NewFPOP(o.MinSize), cost: c}
+	},
+	"wbs": func(o *Options, c base.CostFunction) base.Estimator {
+		return wbs.NewWBS(c, o.MinSize, o.Jump, 0, o.Seed)
+	},
+	"seedbs": func(o *Options, c base.CostFunction) base.Estimator {
+		return wbs.NewSeededBS(c, o.MinSize, o.Jump, 0)
+	},
+}
+
+// jumpless lists the detectors that consider every sample as a change point and
+// have no Jump; Validate rejects -jump for them rather than ignoring it.
+var jumpless = map[string]bool{"fpop": true}
+
+// fpopDetector fits the l2 cost function along with FPOP, which does not use it,
+// so that callers can evaluate the segment costs as with the other detectors.
+type fpopDetector struct {
+	*fpop.FPOP
+	cost base.CostFunction
+}
+
+// Fit fits FPOP and the cost function on the signal.
+func (d fpopDetector) Fit(signal types.Matrix) error {
+	if err := d.FPOP.Fit(signal); err != nil {
+		return err
+	}
+	return d.cost.Fit(signal)
+}
+
+// FitPredict fits FPOP and the cost function on the signal, then predicts the
+// breakpoints with the penalty.
+func (d fpopDetector) FitPredict(signal types.Matrix, penalty float64) ([]int, error) {
+	if err := d.Fit(signal); err != nil {
+		return nil, err
+	}
+	return d.Predict(penalty)
+}
+
+// DetectorNames returns the names of the available detectors, sorted alphabetically.
+func DetectorNames() []string {
+	names := make([]string, 0, len(detectorFactories))
+	for name := range detectorFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterFlags binds the options to named flags of fs, using the current
+// field values as defaults.
+func (o *Options) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Detector, "detector", o.Detector, "detection algorithm ("+strings.Join(DetectorNames(), ", ")+")")
+	fs.StringVar(&o.Cost, "cost", o.Cost, "cost function model ("+strings.Join(cost.Models(), ", ")+")")
+	fs.Func("penalty", fmt.Sprintf("penalty value, or a criterion estimated from the signal (%s); higher values yield fewer change points (default %g)",
+		strings.Join(penalty.Criteria(), ", "), o.Penalty), func(s string) error {
+		if slices.Contains(penalty.Criteria(), strings.ToLower(s)) {
+			o.PenaltyCriterion, o.penaltySet = strings.ToLower(s), true
+			return nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		o.Penalty, o.PenaltyCriterion, o.penaltySet = v, "", true
+		return nil
+	})
+	fs.IntVar(&o.NBkps, "n-bkps", o.NBkps, "fixed number of change points to detect (instead of -penalty)")
+	fs.Func("min-size", fmt.Sprintf("minimum segment length (default %d)", o.MinSize), func(s string) error {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		o.MinSize, o.minSizeSet = v, true
+		return nil
+	})
+	fs.Func("jump", fmt.Sprintf("subsample step between admissible breakpoints (default %d)", o.Jump), func(s string) error {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		o.Jump, o.jumpSet = v, true
+		return nil
+	})
+	fs.Float64Var(&o.Gamma, "gamma", o.Gamma, "RBF kernel bandwidth (0 selects the median heuristic)")
+	fs.Int64Var(&o.Seed, "seed", o.Seed, "random seed of the randomized detectors (wbs) and of the bootstrap (-ci)")
+	fs.StringVar(&o.Format, "format", o.Format, "output format ("+strings.Join(Formats, ", ")+")")
+}
+
+// Validate checks that the options are consistent and refer to known detectors,
+// cost models and formats.
+func (o *Options) Validate() error {
+	if _, ok := detectorFactories[o.Detector]; !ok {
+		return fmt.Errorf("unknown detector %q (available: %s)", o.Detector, strings.Join(DetectorNames(), ", "))
+	}
+	if !slices.Contains(cost.Models(), o.Cost) {
+		return fmt.Errorf("unknown cost %q (available: %s)", o.Cost, strings.Join(cost.Models(), ", "))
+	}
+	if o.Detector == "fpop" && o.Cost != "l2" {
+		return fmt.Errorf("the fpop detector only supports the l2 cost, not %q", o.Cost)
+	}
+	if !slices.Contains(Formats, o.Format) {
+		return fmt.Errorf("unknown format %q (available: %s)", o.Format, strings.Join(Formats, ", "))
+	}
+	if o.NBkps < 0 {
+		return fmt.Errorf("-n-bkps must be non-negative, got %d", o.NBkps)
+	}
+	if o.NBkps > 0 && o.penaltySet {
+		return errors.New("-penalty and -n-bkps are mutually exclusive")
+	}
+	if o.PenaltyModel != nil {
+		if o.penaltySet || o.NBkps > 0 {
+			return errors.New("a penalty model excludes -penalty and -n-bkps")
+		}
+		if o.PenaltyModel.Cost != "" && o.PenaltyModel.Cost != o.Cost {
+			return fmt.Errorf("the penalty model was learned for the %q cost, not %q", o.PenaltyModel.Cost, o.Cost)
+		}
+		// The penalty is only calibrated for the detector settings it was learned with.
+		if m := o.PenaltyModel.MinSize; m > 0 {
+			if o.minSizeSet && o.MinSize != m {
+				return fmt.Errorf("the penalty model was learned with -min-size %d, not %d", m, o.MinSize)
+			}
+			o.MinSize = m
+		}
+		if j := o.PenaltyModel.Jump; j > 0 {
+			if o.jumpSet && o.Jump != j {
+				return fmt.Errorf("the penalty model was learned with -jump %d, not %d", j, o.Jump)
+			}
+			o.Jump = j
+		}
+	} else if o.PenaltyCriterion != "" {
+		if !slices.Contains(penalty.Criteria(), o.PenaltyCriterion) {
+			return fmt.Errorf("unknown penalty criterion %q (available: %s)", o.PenaltyCriterion, strings.Join(penalty.Criteria(), ", "))
+		}
+		if o.Cost != "l2" {
+			return fmt.Errorf("-penalty %s is calibrated for the l2 cost, not %q; give a numeric penalty", o.PenaltyCriterion, o.Cost)
+		}
+	} else if o.NBkps == 0 && o.Penalty <= 0 {
+		return fmt.Errorf("-penalty must be greater than 0, got %g", o.Penalty)
+	}
+	if o.MinSize < 1 {
+		return fmt.Errorf("-min-size must be at least 1, got %d", o.MinSize)
+	}
+	if o.Jump < 1 {
+		return fmt.Errorf("-jump must be at least 1, got %d", o.Jump)
+	}
+	if o.Jump > 1 && jumpless[o.Detector] {
+		return fmt.Errorf("the %s detector does not support -jump", o.Detector)
+	}
+	if o.Gamma < 0 {
+		return fmt.Errorf("-gamma must be non-negative, got %g", o.Gamma)
+	}
+	if o.Gamma > 0 && o.Cost != "rbf" {
+		return fmt.Errorf("-gamma only applies to the rbf cost, not %q", o.Cost)
+	}
+	return nil
+}
+
+// ResetPenalty clears the penalty, its criterion and the number of change points
+// back to their defaults, as if neither -penalty nor -n-bkps had been given, so
+// that they can be set again from another source (e.g. the fields of a request).
+func (o *Options) ResetPenalty() {
+	def := DefaultOptions()
+	o.Penalty, o.PenaltyCriterion, o.NBkps, o.penaltySet = def.Penalty, "", def.NBkps, false
 }
 
-// parseArgs analiza los argumentos de línea de comandos y devuelve los parámetros.
-// Asume que los argumentos son: [nombre_programa] [cost_func_name] [penalty_value]
-func ParseArgs(args []string) ParametersFromArgs {
-	params := ParametersFromArgs{
-		CostFuncName: "rbf", // Valor por defecto
-		Penalty:      5.0,   // Valor por defecto
+// ResolvePenalty replaces Penalty by the value PenaltyModel predicts for the
+// signal, or by the value of PenaltyCriterion computed on it (see
+// penalty.FromSignal). It does nothing when neither is set.
+func (o *Options) ResolvePenalty(signal types.Matrix) error {
+	if o.NBkps > 0 {
+		return nil
+	}
+	if o.PenaltyModel != nil {
+		pen, err := o.PenaltyModel.Penalty(signal)
+		if err != nil {
+			return fmt.Errorf("penalty model: %w", err)
+		}
+		o.Penalty = pen
+		return nil
 	}
+	if o.PenaltyCriterion == "" {
+		return nil
+	}
+	pen, err := penalty.FromSignal(o.PenaltyCriterion, signal)
+	if err != nil {
+		return fmt.Errorf("-penalty %s: %w", o.PenaltyCriterion, err)
+	}
+	o.Penalty = pen
+	return nil
+}
 
-	if len(args) > 1 {
-		params.CostFuncName = args[1]
+// NewCost builds the cost function selected by the options, applying the kernel options.
+func (o *Options) NewCost() (base.CostFunction, error) {
+	c, err := cost.NewCost(o.Cost)
+	if err != nil {
+		return nil, err
+	}
+	if rbf, ok := c.(*cost.CostRbf); ok && o.Gamma > 0 {
+		gamma := o.Gamma
+		rbf.Gamma = &gamma
+	}
+	return c, nil
+}
+
+// NewDetector builds the detector selected by the options on top of the given cost function.
+func (o *Options) NewDetector(c base.CostFunction) (base.Estimator, error) {
+	factory, ok := detectorFactories[o.Detector]
+	if !ok {
+		return nil, fmt.Errorf("unknown detector %q", o.Detector)
+	}
+	est := factory(o, c)
+	if r, ok := est.(base.ProgressReporter); ok && o.Progress != nil {
+		r.SetProgressFunc(o.Progress)
+	}
+	return est, nil
+}
+
+// Predict runs the fitted detector with either the fixed number of breakpoints
+// or the penalty, depending on which one the options specify. With NBkps, the
+// detector is run with the penalties of a bisection until it finds NBkps change
+// points, and Penalty is set to the penalty that gave them.
+func (o *Options) Predict(est base.Estimator) ([]int, error) {
+	return o.PredictContext(context.Background(), est)
+}
+
+// PredictContext is like Predict, but aborts with ctx.Err() once ctx is done
+// (see base.PredictContext).
+func (o *Options) PredictContext(ctx context.Context, est base.Estimator) ([]int, error) {
+	if o.NBkps > 0 {
+		pen, bkps, err := penaltyForNBkps(ctx, est, o.NBkps)
+		if err != nil {
+			return nil, err
+		}
+		o.Penalty = pen
+		return bkps, nil
 	}
-	if len(args) > 2 {
-		p, err := strconv.ParseFloat(args[2], 64)
-		if err == nil {
-			params.Penalty = p
+	return base.PredictContext(ctx, est, o.Penalty)
+}
+
+// PredictResultContext is like PredictContext, but returns the segmentation as a
+// base.Result with the statistics and costs of its segments (see base.PredictResult).
+// The cost function must be fitted on the signal.
+func (o *Options) PredictResultContext(ctx context.Context, est base.Estimator, signal types.Matrix, c base.CostFunction) (base.Result, error) {
+	if o.NBkps > 0 {
+		bkps, err := o.PredictContext(ctx, est)
+		if err != nil {
+			return base.Result{}, err
+		}
+		return base.NewResult(signal, c, bkps, o.Penalty)
+	}
+	return base.PredictResult(ctx, est, signal, c, o.Penalty)
+}
+
+// penaltyForNBkps searches a penalty for which est finds nBkps change points. The
+// number of change points decreases with the penalty: the search brackets nBkps by
+// doubling or halving the penalty from 1, then bisects the bracket geometrically.
+func penaltyForNBkps(ctx context.Context, est base.Estimator, nBkps int) (float64, []int, error) {
+	run := func(pen float64) ([]int, int, error) {
+		bkps, err := base.PredictContext(ctx, est, pen)
+		return bkps, len(bkps) - 1, err
+	}
+	const maxSteps = 200
+	// lo gives more than nBkps change points and hi fewer, once bracketed.
+	lo, hi := 1.0, 1.0
+	bkps, k, err := run(1)
+	if err != nil || k == nBkps {
+		return 1, bkps, err
+	}
+	moreAtLo, fewerAtHi := k, k
+	// Double the penalty while it gives too many change points, or halve it while
+	// it gives too few; either way the last two penalties bracket nBkps.
+	if k > nBkps {
+		for step := 0; k > nBkps; step++ {
+			if step == maxSteps {
+				return 0, nil, fmt.Errorf("%w: %d (at least %d found)", ErrNBkpsUnreachable, nBkps, k)
+			}
+			lo, moreAtLo = hi, k
+			hi *= 2
+			if bkps, k, err = run(hi); err != nil || k == nBkps {
+				return hi, bkps, err
+			}
+			fewerAtHi = k
+		}
+	} else {
+		for step := 0; k < nBkps; step++ {
+			if step == maxSteps {
+				return 0, nil, fmt.Errorf("%w: %d (at most %d found)", ErrNBkpsUnreachable, nBkps, k)
+			}
+			hi, fewerAtHi = lo, k
+			lo /= 2
+			if bkps, k, err = run(lo); err != nil || k == nBkps {
+				return lo, bkps, err
+			}
+			moreAtLo = k
+		}
+	}
+	for step := 0; step < maxSteps && hi/lo > 1+1e-12; step++ {
+		mid := math.Sqrt(lo * hi)
+		if bkps, k, err = run(mid); err != nil || k == nBkps {
+			return mid, bkps, err
+		}
+		if k > nBkps {
+			lo, moreAtLo = mid, k
 		} else {
-			fmt.Printf("Advertencia: No se pudo parsear la penalización '%s', usando valor por defecto: %.2f\n", args[2], params.Penalty)
+			hi, fewerAtHi = mid, k
+		}
+	}
+	return 0, nil, fmt.Errorf("%w: %d (%d change points up to penalty %g, %d above)",
+		ErrNBkpsUnreachable, nBkps, moreAtLo, lo, fewerAtHi)
+}
+
+// SetUsage installs a usage function on fs that prints the synopsis followed by the flag defaults.
+//
+// Example:
+//
+//	cmdutils.SetUsage(fs, "ruptures detect [flags] [file]", "Detect change points in a CSV/TSV signal.")
+func SetUsage(fs *flag.FlagSet, synopsis, description string) {
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "Usage: %s\n\n", synopsis)
+		if description != "" {
+			fmt.Fprintf(w, "%s\n\n", description)
 		}
+		fmt.Fprintln(w, "Flags:")
+		fs.PrintDefaults()
 	}
-	return params
+}
+
+// Parse parses args into fs and validates the options bound to it.
+// On a validation error the usage text is written to the flag set output,
+// so callers only have to report the returned error.
+func Parse(fs *flag.FlagSet, args []string, o *Options) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := o.Validate(); err != nil {
+		fs.Usage()
+		return err
+	}
+	return nil
+}
+
+// NewFlagSet creates a flag set that reports errors instead of exiting and
+// writes usage text to w.
+func NewFlagSet(name string, w io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	return fs
 }
@@ -0,0 +1,246 @@
+package cmdutils_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/datasets"
+	"github.com/theDataFlowClub/ruptures/core/penalty"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+func parse(args ...string) (cmdutils.Options, error) {
+	opts := cmdutils.DefaultOptions()
+	fs := cmdutils.NewFlagSet("test", io.Discard)
+	opts.RegisterFlags(fs)
+	err := cmdutils.Parse(fs, args, &opts)
+	return opts, err
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		expectError bool
+	}{
+		{name: "Defaults", args: nil},
+		{name: "AllFlags", args: []string{"-detector", "pelt", "-cost", "rbf", "-penalty", "2.5", "-min-size", "3", "-jump", "2", "-gamma", "0.5", "-format", "json"}},
+		{name: "NBkpsAlone", args: []string{"-n-bkps", "3"}},
+		{name: "WBSWithSeed", args: []string{"-detector", "wbs", "-seed", "42"}},
+		{name: "SeededBS", args: []string{"-detector", "seedbs"}},
+		{name: "FPOP", args: []string{"-detector", "fpop", "-cost", "l2"}},
+		{name: "FPOPWithL1", args: []string{"-detector", "fpop", "-cost", "l1"}, expectError: true},
+		{name: "FPOPWithJump", args: []string{"-detector", "fpop", "-jump", "5"}, expectError: true},
+		{name: "UnknownDetector", args: []string{"-detector", "nope"}, expectError: true},
+		{name: "UnknownCost", args: []string{"-cost", "nope"}, expectError: true},
+		{name: "UnknownFormat", args: []string{"-format", "xml"}, expectError: true},
+		{name: "NonPositivePenalty", args: []string{"-penalty", "0"}, expectError: true},
+		{name: "MalformedPenalty", args: []string{"-penalty", "abc"}, expectError: true},
+		{name: "PenaltyAndNBkps", args: []string{"-penalty", "2", "-n-bkps", "3"}, expectError: true},
+		{name: "NegativeNBkps", args: []string{"-n-bkps", "-1"}, expectError: true},
+		{name: "ZeroMinSize", args: []string{"-min-size", "0"}, expectError: true},
+		{name: "ZeroJump", args: []string{"-jump", "0"}, expectError: true},
+		{name: "GammaWithoutRbf", args: []string{"-cost", "l2", "-gamma", "1"}, expectError: true},
+		{name: "PenaltyCriterion", args: []string{"-cost", "l2", "-penalty", "BIC"}},
+		{name: "PenaltyCriterionWithRbf", args: []string{"-cost", "rbf", "-penalty", "bic"}, expectError: true},
+		{name: "UndefinedFlag", args: []string{"-bogus"}, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parse(tc.args...)
+			if tc.expectError && err == nil {
+				t.Errorf("Parse(%v) expected an error, got nil", tc.args)
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Parse(%v) got unexpected error: %v", tc.args, err)
+			}
+		})
+	}
+}
+
+func TestOptionsNewCost(t *testing.T) {
+	opts, err := parse("-cost", "rbf", "-gamma", "0.25")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	c, err := opts.NewCost()
+	if err != nil {
+		t.Fatalf("NewCost failed: %v", err)
+	}
+	rbf, ok := c.(*cost.CostRbf)
+	if !ok {
+		t.Fatalf("NewCost returned %T, want *cost.CostRbf", c)
+	}
+	if rbf.Gamma == nil || *rbf.Gamma != 0.25 {
+		t.Errorf("Gamma = %v, want 0.25", rbf.Gamma)
+	}
+}
+
+func TestOptionsResolvePenalty(t *testing.T) {
+	opts, err := parse("-cost", "l2", "-penalty", "mbic")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	signal := types.Matrix{{0}, {1.2}, {-0.3}, {0.8}, {10.1}, {11}, {9.5}, {10.4}}
+	if err := opts.ResolvePenalty(signal); err != nil {
+		t.Fatalf("ResolvePenalty failed: %v", err)
+	}
+	want, _ := penalty.FromSignal(penalty.CriterionMBIC, signal)
+	if opts.Penalty != want {
+		t.Errorf("Penalty = %g, want %g", opts.Penalty, want)
+	}
+}
+
+func TestOptionsPenaltyModel(t *testing.T) {
+	model := &penalty.Model{Cost: "l2", Intercept: math.Log(3)}
+	opts := cmdutils.DefaultOptions()
+	opts.PenaltyModel = model
+	if err := opts.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if err := opts.ResolvePenalty(types.Matrix{{0}, {1}, {0}, {1}}); err != nil {
+		t.Fatalf("ResolvePenalty failed: %v", err)
+	}
+	if math.Abs(opts.Penalty-3) > 1e-12 {
+		t.Errorf("Penalty = %g, want 3", opts.Penalty)
+	}
+
+	opts.Cost = "l1"
+	if err := opts.Validate(); err == nil {
+		t.Error("expected an error for a model learned for another cost")
+	}
+}
+
+func TestOptionsPenaltyModelDetectorSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantMinSize int
+		wantJump    int
+		expectError bool
+	}{
+		{name: "Applied", wantMinSize: 5, wantJump: 3},
+		{name: "SameFlags", args: []string{"-min-size", "5", "-jump", "3"}, wantMinSize: 5, wantJump: 3},
+		{name: "OtherMinSize", args: []string{"-min-size", "2"}, expectError: true},
+		{name: "OtherJump", args: []string{"-jump", "1"}, expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := cmdutils.DefaultOptions()
+			opts.PenaltyModel = &penalty.Model{Cost: "l2", MinSize: 5, Jump: 3, Intercept: math.Log(3)}
+			fs := cmdutils.NewFlagSet("test", io.Discard)
+			opts.RegisterFlags(fs)
+			err := cmdutils.Parse(fs, tt.args, &opts)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got min size %d and jump %d", opts.MinSize, opts.Jump)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if opts.MinSize != tt.wantMinSize || opts.Jump != tt.wantJump {
+				t.Errorf("min size %d and jump %d, want %d and %d", opts.MinSize, opts.Jump, tt.wantMinSize, tt.wantJump)
+			}
+		})
+	}
+}
+
+func TestOptionsPredictNBkps(t *testing.T) {
+	signal := types.Matrix{{0}, {0}, {0}, {0}, {10}, {10}, {10}, {10}, {12}, {12}, {12}, {12}}
+	tests := []struct {
+		nBkps string
+		want  []int
+	}{
+		{nBkps: "1", want: []int{4, 12}},
+		{nBkps: "2", want: []int{4, 8, 12}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nBkps, func(t *testing.T) {
+			opts, err := parse("-n-bkps", tt.nBkps)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			c, _ := opts.NewCost()
+			det, err := opts.NewDetector(c)
+			if err != nil {
+				t.Fatalf("NewDetector failed: %v", err)
+			}
+			if err := det.Fit(signal); err != nil {
+				t.Fatalf("Fit failed: %v", err)
+			}
+			got, err := opts.Predict(det)
+			if err != nil {
+				t.Fatalf("Predict failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Predict = %v, want %v", got, tt.want)
+			}
+			// The penalty found gives the same breakpoints.
+			if bkps, err := base.PredictContext(context.Background(), det, opts.Penalty); err != nil || !reflect.DeepEqual(bkps, tt.want) {
+				t.Errorf("penalty %g gives %v (%v), want %v", opts.Penalty, bkps, err, tt.want)
+			}
+			res, err := opts.PredictResultContext(context.Background(), det, signal, c)
+			if err != nil {
+				t.Fatalf("PredictResultContext failed: %v", err)
+			}
+			if !reflect.DeepEqual(res.Breakpoints, tt.want) {
+				t.Errorf("PredictResultContext breakpoints = %v, want %v", res.Breakpoints, tt.want)
+			}
+		})
+	}
+
+	// Penalty 1 gives too many change points and doubling it jumps from 3 to 0
+	// (at 128 and 256): the bisection must stay between those two penalties,
+	// where 2 change points are optimal from about 193 to 209.
+	t.Run("Overshoot", func(t *testing.T) {
+		params := datasets.DefaultParams()
+		params.NoiseStd, params.Seed = 1, 1
+		signal, _, err := datasets.PwConstant(params)
+		if err != nil {
+			t.Fatalf("PwConstant failed: %v", err)
+		}
+		opts, err := parse("-n-bkps", "2")
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		c, _ := opts.NewCost()
+		det, _ := opts.NewDetector(c)
+		if err := det.Fit(signal); err != nil {
+			t.Fatalf("Fit failed: %v", err)
+		}
+		got, err := opts.Predict(det)
+		if err != nil {
+			t.Fatalf("Predict failed: %v", err)
+		}
+		if len(got) != 3 || opts.Penalty <= 128 || opts.Penalty >= 256 {
+			t.Errorf("Predict = %v at penalty %g, want 2 change points between 128 and 256", got, opts.Penalty)
+		}
+	})
+
+	// A noiseless constant segment is never split, whatever the penalty.
+	opts, err := parse("-n-bkps", "5")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	c, _ := opts.NewCost()
+	det, err := opts.NewDetector(c)
+	if err != nil {
+		t.Fatalf("NewDetector failed: %v", err)
+	}
+	if err := det.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	if _, err := opts.Predict(det); !errors.Is(err, cmdutils.ErrNBkpsUnreachable) {
+		t.Errorf("Predict error = %v, want %v", err, cmdutils.ErrNBkpsUnreachable)
+	}
+}
@@ -6,6 +6,7 @@ package cost
 import (
 	"errors"
 	"fmt" // Agregado para fmt.Errorf
+	"math"
 
 	"github.com/theDataFlowClub/ruptures/core/base"
 	"github.com/theDataFlowClub/ruptures/core/exceptions" // Import custom error types
@@ -117,6 +118,48 @@ func (c *CostL2) Model() string {
 	return "l2"
 }
 
+// L2Sums holds the cumulative sums of a signal, from which the L2 cost of any
+// segment is computed in O(n_features), without fitting a CostL2 on it. The
+// samples are centred on the mean of the signal, which keeps the differences of
+// the sums of squares accurate.
+type L2Sums struct {
+	nFeatures int
+	sums      []float64 // sums[t*nFeatures+j] = Σ_{i < t} x_ij, centred.
+	squares   []float64 // squares[t] = Σ_{i < t} ||x_i||², centred.
+}
+
+// NewL2Sums computes the cumulative sums of a non-empty signal in O(n_samples·n_features).
+func NewL2Sums(signal types.Matrix) *L2Sums {
+	n, nFeatures := len(signal), len(signal[0])
+	means := make([]float64, nFeatures)
+	for _, row := range signal {
+		for j, v := range row {
+			means[j] += v / float64(n)
+		}
+	}
+	c := &L2Sums{nFeatures: nFeatures, sums: make([]float64, (n+1)*nFeatures), squares: make([]float64, n+1)}
+	for i, row := range signal {
+		c.squares[i+1] = c.squares[i]
+		for j, v := range row {
+			v -= means[j]
+			c.sums[(i+1)*nFeatures+j] = c.sums[i*nFeatures+j] + v
+			c.squares[i+1] += v * v
+		}
+	}
+	return c
+}
+
+// Cost returns the L2 cost of the segment [start, end), with 0 <= start < end <= n_samples:
+// the sum of the squared deviations of its samples from their mean, as CostL2.Error.
+func (c *L2Sums) Cost(start, end int) float64 {
+	norm := 0.0
+	for j := 0; j < c.nFeatures; j++ {
+		d := c.sums[end*c.nFeatures+j] - c.sums[start*c.nFeatures+j]
+		norm += d * d
+	}
+	return math.Max(c.squares[end]-c.squares[start]-norm/float64(end-start), 0)
+}
+
 // init function is called automatically when the package is initialized.
 // It registers the CostL2 constructor with the cost factory.
 func init() {
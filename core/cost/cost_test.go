@@ -245,6 +245,28 @@ func TestCostL2_Error(t *testing.T) {
 	}
 }
 
+func TestL2Sums(t *testing.T) {
+	// The cumulative sums give the cost of CostL2 on every segment, even with a
+	// large offset that would ruin uncentred sums of squares.
+	signal := createMatrix([][]float64{{1e6 + 1, 2}, {1e6 + 3, -1}, {1e6, 0}, {1e6 + 2, 5}, {1e6 - 4, 1}})
+	l2 := cost.NewCostL2()
+	if err := l2.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	sums := cost.NewL2Sums(signal)
+	for start := 0; start < len(signal); start++ {
+		for end := start + 1; end <= len(signal); end++ {
+			want, err := l2.Error(start, end)
+			if err != nil {
+				t.Fatalf("Error(%d, %d) failed: %v", start, end, err)
+			}
+			if got := sums.Cost(start, end); math.Abs(got-want) > 1e-6 {
+				t.Errorf("Cost(%d, %d) = %g; want %g", start, end, got, want)
+			}
+		}
+	}
+}
+
 func TestCostL2_Model(t *testing.T) {
 	l2Cost := cost.NewCostL2()
 	expectedModel := "l2"
@@ -4,9 +4,11 @@ package cost
 
 import (
 	"fmt"
+	"sort"
 	"sync" // For thread-safe map access
 
 	"github.com/theDataFlowClub/ruptures/core/base" // For the CostFunction interface
+	"github.com/theDataFlowClub/ruptures/core/logging"
 )
 
 // costFactoryRegistry holds a map of model names to functions that construct CostFunction instances.
@@ -27,7 +29,7 @@ func RegisterCostFunction(model string, constructor func() base.CostFunction) {
 		panic(fmt.Sprintf("cost function model '%s' already registered", model))
 	}
 	costFactoryRegistry[model] = constructor
-	fmt.Printf("DEBUG: Cost function '%s' registered.\n", model) // <-- Añade esta línea
+	logging.Logger().Debug("cost function registered", logging.KeyCost, model)
 }
 
 // NewCost creates and returns a new instance of a CostFunction based on its model name.
@@ -50,3 +52,16 @@ func NewCost(model string) (base.CostFunction, error) {
 	}
 	return constructor(), nil
 }
+
+// Models returns the names of all registered cost function models, sorted alphabetically.
+// It is safe for concurrent use.
+func Models() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	models := make([]string, 0, len(costFactoryRegistry))
+	for model := range costFactoryRegistry {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
@@ -69,6 +69,37 @@ func (c *CostRbf) GetKernel() (kernels.Kernel, error) {
 	return c._kernel, nil
 }
 
+// Kernel returns the entry (i, j) of the Gram matrix of the fitted signal:
+// exp(-clip(gamma * ||x_i - x_j||², 1e-2, 1e2)) off the diagonal and 1 on it.
+// It reads the cached Gram matrix when there is one and computes the entry
+// otherwise, so detectors accumulating kernel sums sample by sample (Pelt) agree
+// with Error without building the n×n matrix.
+func (c *CostRbf) Kernel(i, j int) (float64, error) {
+	if c.Signal == nil {
+		return 0, errors.New("CostRbf: signal not fitted, call Fit() first")
+	}
+	if i < 0 || j < 0 || i >= len(c.Signal) || j >= len(c.Signal) {
+		return 0, fmt.Errorf("CostRbf: kernel indices (%d, %d) out of bounds for %d samples", i, j, len(c.Signal))
+	}
+	if c._gram != nil {
+		return c._gram[i][j], nil
+	}
+	if i == j {
+		return 1, nil
+	}
+	if c.Gamma == nil {
+		if _, err := c.GetGram(); err != nil {
+			return 0, err
+		}
+		return c._gram[i][j], nil
+	}
+	distSq, err := linalg.SquaredEuclideanDistance(c.Signal[i], c.Signal[j])
+	if err != nil {
+		return 0, fmt.Errorf("CostRbf: failed to compute the squared distance: %w", err)
+	}
+	return math.Exp(-math.Max(1e-2, math.Min(*c.Gamma*distSq, 1e2))), nil
+}
+
 // GetGram calculates and returns the Gram matrix (lazy loading).
 // This method is the Go equivalent of the Python @property def gram.
 func (c *CostRbf) GetGram() (types.Matrix, error) {
@@ -129,11 +160,14 @@ func (c *CostRbf) GetGram() (types.Matrix, error) {
 }
 
 // Fit sets parameters of the instance and optionally calculates default gamma.
+// Refitting discards the Gram matrix of the previous signal but keeps gamma, so a
+// gamma found by the heuristic on the first signal is reused on the next ones.
 func (c *CostRbf) Fit(signal types.Matrix) error {
 	if signal == nil || len(signal) == 0 || (len(signal) > 0 && len(signal[0]) == 0) {
 		return exceptions.ErrNotEnoughPoints
 	}
 	c.Signal = signal
+	c._gram = nil
 
 	if c.Gamma == nil {
 		_, err := c.GetGram()
@@ -199,6 +233,6 @@ func (c *CostRbf) SetCachedGramForTest(gram types.Matrix) {
 // init function is called automatically when the package is initialized.
 func init() {
 	RegisterCostFunction("rbf", func() base.CostFunction {
-		return NewCostEntropy()
+		return NewCostRbf(nil)
 	})
 }
@@ -134,6 +134,28 @@ func TestCostRbfFit(t *testing.T) {
 	}
 }
 
+func TestCostRbfRefit(t *testing.T) {
+	// Refitting on a longer signal must rebuild the Gram matrix with the same gamma.
+	rbfCost := cost.NewCostRbf(nil)
+	if err := rbfCost.Fit(types.Matrix{{0.0}, {1.0}, {2.0}}); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	gamma := *rbfCost.Gamma
+	if err := rbfCost.Fit(types.Matrix{{0.0}, {1.0}, {2.0}, {3.0}, {4.0}}); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	gram, err := rbfCost.GetGram()
+	if err != nil || len(gram) != 5 {
+		t.Errorf("Gram matrix has %d rows after refit (err %v), want 5", len(gram), err)
+	}
+	if *rbfCost.Gamma != gamma {
+		t.Errorf("Gamma changed from %g to %g on refit", gamma, *rbfCost.Gamma)
+	}
+	if _, err := rbfCost.Error(0, 5); err != nil {
+		t.Errorf("Error on the refitted signal failed: %v", err)
+	}
+}
+
 // ### TestCostRbfGetGram
 
 func TestCostRbfGetGram(t *testing.T) {
@@ -403,3 +425,38 @@ func TestCostRbfError(t *testing.T) {
 		})
 	}
 }
+
+func TestCostRbfKernel(t *testing.T) {
+	// Kernel coincide con la matriz de Gram, calculada o no.
+	signal := types.Matrix{{0}, {0}, {1}, {3}, {40}}
+	gamma := 0.5
+	withGram := cost.NewCostRbf(&gamma)
+	if err := withGram.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	gram, err := withGram.GetGram()
+	if err != nil {
+		t.Fatalf("GetGram failed: %v", err)
+	}
+	lazy := cost.NewCostRbf(&gamma)
+	if err := lazy.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	for i := range signal {
+		for j := range signal {
+			got, err := lazy.Kernel(i, j)
+			if err != nil {
+				t.Fatalf("Kernel(%d, %d) failed: %v", i, j, err)
+			}
+			if math.Abs(got-gram[i][j]) > floatTolerance {
+				t.Errorf("Kernel(%d, %d) = %g, Gram %g", i, j, got, gram[i][j])
+			}
+		}
+	}
+	if lazy.GetCachedGramForTest() != nil {
+		t.Error("Kernel built the Gram matrix")
+	}
+	if _, err := lazy.Kernel(0, len(signal)); err == nil {
+		t.Error("expected an error for an index out of bounds")
+	}
+}
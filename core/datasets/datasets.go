@@ -0,0 +1,159 @@
+// Package datasets provides synthetic signal generators with known change points.
+// They mirror the generators of the Python ruptures library (pw_constant, pw_normal,
+// pw_linear) and are used to build test fixtures and benchmarks. Every generator is
+// driven by an explicit seed, so the same parameters always yield the same signal.
+package datasets
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Params holds the parameters shared by the generators.
+type Params struct {
+	NSamples  int     // Number of samples of the signal.
+	NFeatures int     // Number of features (dimensions) of the signal.
+	NBkps     int     // Number of change points, excluding the final index n_samples.
+	NoiseStd  float64 // Standard deviation of the additive Gaussian noise; 0 disables noise.
+	DeltaMin  float64 // Minimum absolute jump of the mean at each change point (PwConstant).
+	DeltaMax  float64 // Maximum absolute jump of the mean at each change point (PwConstant).
+	Seed      int64   // Seed of the random number generator.
+}
+
+// DefaultParams returns the defaults of the Python ruptures generators:
+// 200 samples, 1 feature, 3 change points, no noise and jumps in [1, 10].
+func DefaultParams() Params {
+	return Params{
+		NSamples:  200,
+		NFeatures: 1,
+		NBkps:     3,
+		DeltaMin:  1,
+		DeltaMax:  10,
+	}
+}
+
+// validate checks that the parameters describe a feasible signal.
+func (p Params) validate() error {
+	if p.NSamples < 1 || p.NFeatures < 1 {
+		return fmt.Errorf("datasets: n_samples and n_features must be positive (got %d, %d): %w",
+			p.NSamples, p.NFeatures, exceptions.ErrBadSegmentationParameters)
+	}
+	if p.NBkps < 0 || p.NBkps >= p.NSamples {
+		return fmt.Errorf("datasets: cannot place %d change points in %d samples: %w",
+			p.NBkps, p.NSamples, exceptions.ErrBadSegmentationParameters)
+	}
+	if p.NoiseStd < 0 {
+		return fmt.Errorf("datasets: noise_std must be non-negative, got %g", p.NoiseStd)
+	}
+	if p.DeltaMin < 0 || p.DeltaMax < p.DeltaMin {
+		return fmt.Errorf("datasets: invalid jump range [%g, %g]", p.DeltaMin, p.DeltaMax)
+	}
+	return nil
+}
+
+// DrawBkps draws nBkps change points for a signal of nSamples samples.
+// Segment lengths follow a concentrated Dirichlet distribution, as in ruptures,
+// so segments have roughly equal lengths. The returned slice is sorted, has
+// distinct entries and ends with nSamples.
+//
+// Parameters:
+//
+//	nSamples: The length of the signal.
+//	nBkps:    The number of change points, excluding nSamples. Must be < nSamples.
+//	rng:      The random number generator.
+//
+// Returns:
+//
+//	[]int: The breakpoints [b1, ..., b_nBkps, nSamples].
+func DrawBkps(nSamples, nBkps int, rng *rand.Rand) []int {
+	nSegments := nBkps + 1
+	alpha := 2000.0 / float64(nSegments)
+	weights := make([]float64, nSegments)
+	total := 0.0
+	for i := range weights {
+		weights[i] = gammaVariate(alpha, rng)
+		total += weights[i]
+	}
+
+	bkps := make([]int, 0, nSegments)
+	cumulative := 0.0
+	for i := 0; i < nBkps; i++ {
+		cumulative += weights[i]
+		bkps = append(bkps, int(math.Round(float64(nSamples)*cumulative/total)))
+	}
+	sort.Ints(bkps)
+
+	// Rounding can collapse neighbouring breakpoints on short signals:
+	// force strictly increasing values inside [1, nSamples-1].
+	for i := range bkps {
+		low := i + 1
+		if i > 0 && bkps[i-1]+1 > low {
+			low = bkps[i-1] + 1
+		}
+		high := nSamples - (nBkps - i)
+		bkps[i] = min(max(bkps[i], low), high)
+	}
+	return append(bkps, nSamples)
+}
+
+// gammaVariate draws from a Gamma(alpha, 1) distribution with alpha >= 1
+// using the Marsaglia-Tsang method.
+func gammaVariate(alpha float64, rng *rand.Rand) float64 {
+	d := alpha - 1.0/3.0
+	c := 1.0 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+// addNoise adds independent Gaussian noise of standard deviation std to every entry of signal.
+func addNoise(signal [][]float64, std float64, rng *rand.Rand) {
+	if std == 0 {
+		return
+	}
+	for _, row := range signal {
+		for j := range row {
+			row[j] += std * rng.NormFloat64()
+		}
+	}
+}
+
+// generators maps generator names to their implementations.
+var generators = map[string]func(Params) (types.Matrix, []int, error){
+	"constant": PwConstant,
+	"normal":   PwNormal,
+	"linear":   PwLinear,
+}
+
+// Kinds returns the names accepted by Generate, sorted alphabetically.
+func Kinds() []string {
+	kinds := make([]string, 0, len(generators))
+	for kind := range generators {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Generate runs the generator registered under kind ("constant", "normal" or "linear").
+func Generate(kind string, p Params) (types.Matrix, []int, error) {
+	generate, ok := generators[kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("datasets: unknown kind %q", kind)
+	}
+	return generate(p)
+}
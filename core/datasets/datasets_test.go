@@ -0,0 +1,109 @@
+package datasets_test
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/datasets"
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+)
+
+func TestDrawBkps(t *testing.T) {
+	testCases := []struct {
+		name     string
+		nSamples int
+		nBkps    int
+	}{
+		{name: "NoBreakpoints", nSamples: 100, nBkps: 0},
+		{name: "Default", nSamples: 200, nBkps: 3},
+		{name: "Crowded", nSamples: 6, nBkps: 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bkps := datasets.DrawBkps(tc.nSamples, tc.nBkps, rand.New(rand.NewSource(1)))
+			if len(bkps) != tc.nBkps+1 {
+				t.Fatalf("len(bkps) = %d, want %d (%v)", len(bkps), tc.nBkps+1, bkps)
+			}
+			if bkps[len(bkps)-1] != tc.nSamples {
+				t.Errorf("last breakpoint = %d, want %d", bkps[len(bkps)-1], tc.nSamples)
+			}
+			prev := 0
+			for _, b := range bkps {
+				if b <= prev {
+					t.Fatalf("breakpoints not strictly increasing from 0: %v", bkps)
+				}
+				prev = b
+			}
+		})
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	p := datasets.DefaultParams()
+	p.NFeatures = 3
+	p.NoiseStd = 1
+	p.Seed = 42
+
+	wantFeatures := map[string]int{"constant": 3, "normal": 2, "linear": 4}
+	for _, kind := range datasets.Kinds() {
+		t.Run(kind, func(t *testing.T) {
+			signal, bkps, err := datasets.Generate(kind, p)
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if len(signal) != p.NSamples || len(signal[0]) != wantFeatures[kind] {
+				t.Errorf("signal shape = (%d, %d), want (%d, %d)", len(signal), len(signal[0]), p.NSamples, wantFeatures[kind])
+			}
+			if len(bkps) != p.NBkps+1 {
+				t.Errorf("len(bkps) = %d, want %d", len(bkps), p.NBkps+1)
+			}
+
+			// Same seed, same output.
+			again, againBkps, _ := datasets.Generate(kind, p)
+			if !reflect.DeepEqual(signal, again) || !reflect.DeepEqual(bkps, againBkps) {
+				t.Error("Generate is not reproducible for a fixed seed")
+			}
+		})
+	}
+}
+
+func TestPwConstantNoiseless(t *testing.T) {
+	p := datasets.DefaultParams()
+	p.Seed = 7
+	signal, bkps, err := datasets.PwConstant(p)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	start := 0
+	for _, end := range bkps {
+		for i := start; i < end; i++ {
+			if signal[i][0] != signal[start][0] {
+				t.Fatalf("segment [%d, %d) is not constant at %d", start, end, i)
+			}
+		}
+		if start > 0 {
+			jump := signal[start][0] - signal[start-1][0]
+			if jump < 0 {
+				jump = -jump
+			}
+			if jump < p.DeltaMin || jump > p.DeltaMax {
+				t.Errorf("jump at %d = %g, want within [%g, %g]", start, jump, p.DeltaMin, p.DeltaMax)
+			}
+		}
+		start = end
+	}
+}
+
+func TestInvalidParams(t *testing.T) {
+	p := datasets.DefaultParams()
+	p.NBkps = p.NSamples
+	if _, _, err := datasets.PwConstant(p); !errors.Is(err, exceptions.ErrBadSegmentationParameters) {
+		t.Errorf("PwConstant error = %v, want %v", err, exceptions.ErrBadSegmentationParameters)
+	}
+	if _, _, err := datasets.Generate("wavy", datasets.DefaultParams()); err == nil {
+		t.Error("Generate should fail for an unknown kind")
+	}
+}
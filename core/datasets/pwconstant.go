@@ -0,0 +1,54 @@
+package datasets
+
+import (
+	"math/rand"
+
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// PwConstant generates a piecewise constant signal with Gaussian noise.
+// At each segment the mean of every feature jumps by a random amount drawn
+// uniformly in [DeltaMin, DeltaMax], with a random sign.
+//
+// Equivalent to ruptures.pw_constant.
+//
+// Parameters:
+//
+//	p: The generator parameters. NSamples, NFeatures, NBkps, NoiseStd, DeltaMin,
+//	   DeltaMax and Seed are used.
+//
+// Returns:
+//
+//	types.Matrix: The signal, of shape (NSamples, NFeatures).
+//	[]int:        The true breakpoints, ending with NSamples.
+//	error:        An error if the parameters are invalid.
+func PwConstant(p Params) (types.Matrix, []int, error) {
+	if err := p.validate(); err != nil {
+		return nil, nil, err
+	}
+	rng := rand.New(rand.NewSource(p.Seed))
+	bkps := DrawBkps(p.NSamples, p.NBkps, rng)
+	return pwConstant(p, bkps, rng), bkps, nil
+}
+
+// pwConstant fills a piecewise constant signal for the given breakpoints.
+func pwConstant(p Params, bkps []int, rng *rand.Rand) types.Matrix {
+	signal := make(types.Matrix, p.NSamples)
+	center := make([]float64, p.NFeatures)
+	start := 0
+	for _, end := range bkps {
+		for j := range center {
+			jump := p.DeltaMin + (p.DeltaMax-p.DeltaMin)*rng.Float64()
+			if rng.Intn(2) == 0 {
+				jump = -jump
+			}
+			center[j] += jump
+		}
+		for i := start; i < end; i++ {
+			signal[i] = append([]float64(nil), center...)
+		}
+		start = end
+	}
+	addNoise(signal, p.NoiseStd, rng)
+	return signal
+}
@@ -0,0 +1,46 @@
+package datasets
+
+import (
+	"math/rand"
+
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// PwLinear generates a piecewise linear regression signal. NFeatures Gaussian
+// covariates are drawn at random, and the response is their linear combination
+// with coefficients that are piecewise constant (as generated by PwConstant),
+// plus Gaussian noise.
+//
+// Equivalent to ruptures.pw_linear.
+//
+// Returns:
+//
+//	types.Matrix: The signal, of shape (NSamples, NFeatures+1). Column 0 is the
+//	              response and columns 1..NFeatures are the covariates.
+//	[]int:        The true breakpoints, ending with NSamples.
+//	error:        An error if the parameters are invalid.
+func PwLinear(p Params) (types.Matrix, []int, error) {
+	if err := p.validate(); err != nil {
+		return nil, nil, err
+	}
+	rng := rand.New(rand.NewSource(p.Seed))
+	bkps := DrawBkps(p.NSamples, p.NBkps, rng)
+
+	coefficients := p
+	coefficients.NoiseStd = 0
+	coeff := pwConstant(coefficients, bkps, rng)
+
+	signal := make(types.Matrix, p.NSamples)
+	for i := range signal {
+		row := make([]float64, p.NFeatures+1)
+		for j := 0; j < p.NFeatures; j++ {
+			row[j+1] = rng.NormFloat64()
+			row[0] += coeff[i][j] * row[j+1]
+		}
+		if p.NoiseStd > 0 {
+			row[0] += p.NoiseStd * rng.NormFloat64()
+		}
+		signal[i] = row
+	}
+	return signal, bkps, nil
+}
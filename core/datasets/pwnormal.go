@@ -0,0 +1,46 @@
+package datasets
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// pwNormalCorrelations are the correlations of the two features, alternated from one segment to the next.
+var pwNormalCorrelations = [2]float64{0.9, -0.9}
+
+// PwNormal generates a bivariate, zero-mean Gaussian signal whose covariance changes
+// at each breakpoint: the correlation between the two features alternates between
+// 0.9 and -0.9 while the variances stay at 1.
+//
+// Equivalent to ruptures.pw_normal. The signal always has two features;
+// NFeatures, NoiseStd, DeltaMin and DeltaMax are ignored.
+//
+// Returns:
+//
+//	types.Matrix: The signal, of shape (NSamples, 2).
+//	[]int:        The true breakpoints, ending with NSamples.
+//	error:        An error if the parameters are invalid.
+func PwNormal(p Params) (types.Matrix, []int, error) {
+	p.NFeatures, p.NoiseStd = 2, 0
+	if err := p.validate(); err != nil {
+		return nil, nil, err
+	}
+	rng := rand.New(rand.NewSource(p.Seed))
+	bkps := DrawBkps(p.NSamples, p.NBkps, rng)
+
+	signal := make(types.Matrix, p.NSamples)
+	start := 0
+	for k, end := range bkps {
+		// Cholesky factor of [[1, rho], [rho, 1]].
+		rho := pwNormalCorrelations[k%2]
+		scale := math.Sqrt(1 - rho*rho)
+		for i := start; i < end; i++ {
+			z1, z2 := rng.NormFloat64(), rng.NormFloat64()
+			signal[i] = []float64{z1, rho*z1 + scale*z2}
+		}
+		start = end
+	}
+	return signal, bkps, nil
+}
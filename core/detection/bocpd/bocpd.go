@@ -0,0 +1,259 @@
+// Package bocpd implements Bayesian Online Changepoint Detection (Adams and MacKay,
+// 2007). Observations are processed one at a time and, after each of them, the
+// detector returns the posterior distribution of the run length — the number of
+// observations since the last change point — instead of a hard segmentation. Change
+// probabilities derived from it are calibrated and can drive alerting directly.
+//
+// The detector is generic over a conjugate observation Model (NormalGamma,
+// PoissonGamma, BetaBernoulli, or any type implementing the interface) and a Hazard
+// function giving the prior probability of a change at every run length.
+//
+// Example:
+//
+//	d := bocpd.NewDetector(bocpd.NormalGamma{Mu: 0, Kappa: 1, Alpha: 1, Beta: 1}, bocpd.ConstantHazard(250))
+//	for _, x := range stream {
+//		step, err := d.Update(x)
+//		if err != nil {
+//			return err
+//		}
+//		if step.ChangeProbability(10) > 0.9 {
+//			alert(step)
+//		}
+//	}
+package bocpd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Hazard returns the prior probability, in [0, 1], that a change point occurs right
+// after a run of runLength observations.
+type Hazard func(runLength int) float64
+
+// ConstantHazard returns the memoryless hazard 1/lambda: segment lengths follow a
+// geometric distribution with mean lambda. lambda must be at least 1.
+func ConstantHazard(lambda float64) Hazard {
+	return func(int) float64 { return 1 / lambda }
+}
+
+// Model is a conjugate observation model: a value holding the parameters of the
+// posterior predictive distribution given the observations of a run.
+// Implementations must be immutable, since hypotheses share their history.
+type Model interface {
+	// LogPredictive returns the log-density (or log-probability) of x under the
+	// posterior predictive distribution; -Inf when x is impossible.
+	LogPredictive(x float64) float64
+	// Update returns the model conditioned on one more observation x.
+	Update(x float64) Model
+}
+
+// Step is the state of the detector after an observation.
+type Step struct {
+	// T is the number of observations processed, including this one.
+	T int
+	// RunLength is the posterior distribution of the run length: RunLength[r] is the
+	// probability that the current segment holds the last r observations, i.e. that
+	// the most recent change point is the breakpoint T - r. RunLength[0] is the
+	// probability of a change right after this observation.
+	RunLength []float64
+	// LogPredictive is log p(x_T | x_1..x_T-1), the log-probability of the observation
+	// before it was seen: very negative values flag surprising observations.
+	LogPredictive float64
+}
+
+// MAP returns the most probable run length.
+func (s Step) MAP() int {
+	best := 0
+	for r, p := range s.RunLength {
+		if p > s.RunLength[best] {
+			best = r
+		}
+	}
+	return best
+}
+
+// ChangeProbability returns the probability that a change point occurred within
+// the last window observations: P(run length < window), i.e. that the most recent
+// breakpoint lies in (T - window, T].
+func (s Step) ChangeProbability(window int) float64 {
+	total := 0.0
+	for r := 0; r < window && r < len(s.RunLength); r++ {
+		total += s.RunLength[r]
+	}
+	return math.Min(total, 1)
+}
+
+// Detector runs BOCPD on a stream of scalar observations. It is not safe for
+// concurrent use.
+type Detector struct {
+	Prior  Model  // Model of a run before any observation.
+	Hazard Hazard // Prior probability of a change at every run length.
+	// MaxRunLength, when positive, truncates the run-length distribution to
+	// 0..MaxRunLength, bounding the cost of each update (otherwise linear in the
+	// number of observations). The mass of longer runs is dropped.
+	MaxRunLength int
+
+	t        int
+	logProbs []float64 // log P(r_t = r | x_1..x_t)
+	models   []Model   // Posterior of the run of length r
+}
+
+// NewDetector creates a detector with the given prior model and hazard.
+func NewDetector(prior Model, hazard Hazard) *Detector {
+	return &Detector{Prior: prior, Hazard: hazard}
+}
+
+// Reset forgets every observation.
+func (d *Detector) Reset() {
+	d.t, d.logProbs, d.models = 0, nil, nil
+}
+
+// Update processes the next observation and returns the new state.
+//
+// Parameters:
+//
+//	x: The observation; its domain depends on the model (e.g. counts for PoissonGamma).
+//
+// Returns:
+//
+//	Step:  The run-length posterior after x.
+//	error: An error when the detector is misconfigured or x is impossible under
+//	       every hypothesis (e.g. a negative count); the state is then unchanged.
+func (d *Detector) Update(x float64) (Step, error) {
+	if d.Prior == nil || d.Hazard == nil {
+		return Step{}, errors.New("bocpd: detector needs a prior model and a hazard function")
+	}
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return Step{}, fmt.Errorf("bocpd: observation %d is not finite", d.t+1)
+	}
+	if d.models == nil {
+		d.logProbs, d.models = []float64{0}, []Model{d.Prior}
+	}
+
+	// Message passing: every run either grows by one observation or is cut by a
+	// change point right after x, with the probabilities of the hazard.
+	n := len(d.models)
+	logProbs := make([]float64, n+1)
+	logCP := make([]float64, n)
+	logPred := make([]float64, n)
+	for r, m := range d.models {
+		h := d.Hazard(r)
+		if !(h >= 0 && h <= 1) {
+			return Step{}, fmt.Errorf("bocpd: hazard(%d) = %g is not a probability", r, h)
+		}
+		lp := m.LogPredictive(x)
+		if math.IsNaN(lp) {
+			return Step{}, fmt.Errorf("bocpd: invalid model parameters %+v", m)
+		}
+		logPred[r] = d.logProbs[r] + lp
+		logProbs[r+1] = logPred[r] + math.Log1p(-h)
+		logCP[r] = logPred[r] + math.Log(h)
+	}
+	logProbs[0] = logSumExp(logCP)
+	evidence := logSumExp(logProbs)
+	if math.IsInf(evidence, -1) {
+		return Step{}, fmt.Errorf("bocpd: observation %g has zero probability under the model", x)
+	}
+	for r := range logProbs {
+		logProbs[r] -= evidence
+	}
+
+	models := make([]Model, n+1)
+	models[0] = d.Prior
+	for r, m := range d.models {
+		models[r+1] = m.Update(x)
+	}
+	if d.MaxRunLength > 0 && len(models) > d.MaxRunLength+1 {
+		models, logProbs = models[:d.MaxRunLength+1], logProbs[:d.MaxRunLength+1]
+		norm := logSumExp(logProbs)
+		for r := range logProbs {
+			logProbs[r] -= norm
+		}
+	}
+	d.t++
+	d.logProbs, d.models = logProbs, models
+
+	step := Step{T: d.t, RunLength: make([]float64, len(logProbs)), LogPredictive: logSumExp(logPred)}
+	for r, lp := range logProbs {
+		step.RunLength[r] = math.Exp(lp)
+	}
+	return step, nil
+}
+
+// Run resets the detector and processes all observations, returning one Step per observation.
+func (d *Detector) Run(xs []float64) ([]Step, error) {
+	d.Reset()
+	steps := make([]Step, 0, len(xs))
+	for _, x := range xs {
+		step, err := d.Update(x)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// Breakpoints turns the steps of a run into hard breakpoints for comparison with
+// the other detectors. A change point is reported whenever the probability of a
+// change within the last window observations reaches threshold; it is placed at the
+// most probable run length below window, and reports less than window apart are
+// merged. The result is sorted and ends with the number of observations.
+func Breakpoints(steps []Step, window int, threshold float64) []int {
+	var bkps []int
+	lastProb := 0.0
+	for _, s := range steps {
+		prob := s.ChangeProbability(window)
+		if prob < threshold {
+			continue
+		}
+		best := 0
+		for r := 1; r < window && r < len(s.RunLength); r++ {
+			if s.RunLength[r] > s.RunLength[best] {
+				best = r
+			}
+		}
+		bkp := s.T - best
+		if bkp <= 0 || bkp >= len(steps) {
+			continue
+		}
+		// Successive alerts about the same change keep the most confident location.
+		if k := len(bkps); k > 0 && abs(bkp-bkps[k-1]) < window {
+			if prob > lastProb {
+				bkps[k-1], lastProb = bkp, prob
+			}
+			continue
+		}
+		bkps = append(bkps, bkp)
+		lastProb = prob
+	}
+	sort.Ints(bkps)
+	return append(bkps, len(steps))
+}
+
+// abs returns the absolute value of an integer.
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// logSumExp returns log(Σ exp(v)) without overflow.
+func logSumExp(values []float64) float64 {
+	maxValue := math.Inf(-1)
+	for _, v := range values {
+		maxValue = math.Max(maxValue, v)
+	}
+	if math.IsInf(maxValue, 0) {
+		return maxValue
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += math.Exp(v - maxValue)
+	}
+	return maxValue + math.Log(sum)
+}
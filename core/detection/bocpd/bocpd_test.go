@@ -0,0 +1,136 @@
+package bocpd_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/detection/bocpd"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+)
+
+const floatTolerance = 1e-9
+
+// piecewise draws len(values) segments of the given length, each from draw(value).
+func piecewise(values []float64, length int, draw func(v float64) float64) ([]float64, []int) {
+	var xs []float64
+	var bkps []int
+	for _, v := range values {
+		for i := 0; i < length; i++ {
+			xs = append(xs, draw(v))
+		}
+		bkps = append(bkps, len(xs))
+	}
+	return xs, bkps
+}
+
+func TestDetectorModels(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	poisson := func(rate float64) float64 {
+		// Knuth's algorithm, enough for small rates.
+		l, k, p := math.Exp(-rate), 0.0, 1.0
+		for p *= rng.Float64(); p > l; p *= rng.Float64() {
+			k++
+		}
+		return k
+	}
+	tests := []struct {
+		name   string
+		prior  bocpd.Model
+		values []float64
+		draw   func(v float64) float64
+	}{
+		{"NormalGamma", bocpd.NormalGamma{Mu: 0, Kappa: 0.1, Alpha: 1, Beta: 1},
+			[]float64{0, 5, -2, 3}, func(v float64) float64 { return v + rng.NormFloat64() }},
+		{"PoissonGamma", bocpd.PoissonGamma{Alpha: 1, Beta: 0.1},
+			[]float64{2, 12, 4}, poisson},
+		{"BetaBernoulli", bocpd.BetaBernoulli{Alpha: 1, Beta: 1},
+			[]float64{0.05, 0.8, 0.1}, func(v float64) float64 {
+				if rng.Float64() < v {
+					return 1
+				}
+				return 0
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xs, trueBkps := piecewise(tt.values, 200, tt.draw)
+			steps, err := bocpd.NewDetector(tt.prior, bocpd.ConstantHazard(200)).Run(xs)
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			for _, s := range steps {
+				total := 0.0
+				for _, p := range s.RunLength {
+					total += p
+				}
+				if math.Abs(total-1) > 1e-6 {
+					t.Fatalf("step %d: run-length posterior sums to %g", s.T, total)
+				}
+			}
+			// Long after a change, the run length is the time since that change.
+			if got := steps[len(steps)-1].MAP(); math.Abs(float64(got-200)) > 15 {
+				t.Errorf("expected a final MAP run length close to 200, got %d", got)
+			}
+			bkps := bocpd.Breakpoints(steps, 20, 0.8)
+			f1, err := metrics.F1Score(trueBkps, bkps, 15)
+			if err != nil {
+				t.Fatalf("F1Score failed: %v", err)
+			}
+			if f1 < 1 {
+				t.Errorf("expected F1 = 1, got %g (true %v, detected %v)", f1, trueBkps, bkps)
+			}
+		})
+	}
+}
+
+func TestDetectorFirstStep(t *testing.T) {
+	// After one observation, the posterior is the hazard itself.
+	step, err := bocpd.NewDetector(bocpd.NormalGamma{Mu: 0, Kappa: 1, Alpha: 1, Beta: 1}, bocpd.ConstantHazard(10)).Update(0.3)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(step.RunLength) != 2 || math.Abs(step.RunLength[0]-0.1) > floatTolerance || math.Abs(step.RunLength[1]-0.9) > floatTolerance {
+		t.Errorf("expected run-length posterior [0.1 0.9], got %v", step.RunLength)
+	}
+	// Student t with 2 degrees of freedom and scale² 2 at 0.3.
+	want := math.Log(0.25) - 1.5*math.Log1p(0.09/4)
+	if math.Abs(step.LogPredictive-want) > floatTolerance {
+		t.Errorf("expected log predictive %g, got %g", want, step.LogPredictive)
+	}
+}
+
+func TestDetectorMaxRunLength(t *testing.T) {
+	d := bocpd.NewDetector(bocpd.BetaBernoulli{Alpha: 1, Beta: 1}, bocpd.ConstantHazard(100))
+	d.MaxRunLength = 50
+	for i := 0; i < 200; i++ {
+		step, err := d.Update(float64(i % 2))
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if len(step.RunLength) > 51 {
+			t.Fatalf("step %d: %d run lengths, limit 51", i, len(step.RunLength))
+		}
+	}
+}
+
+func TestDetectorErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		prior bocpd.Model
+		x     float64
+	}{
+		{"NoPrior", nil, 1},
+		{"NotFinite", bocpd.NormalGamma{Kappa: 1, Alpha: 1, Beta: 1}, math.Inf(1)},
+		{"InvalidParameters", bocpd.NormalGamma{Kappa: 0, Alpha: 1, Beta: 1}, 1},
+		{"NegativeCount", bocpd.PoissonGamma{Alpha: 1, Beta: 1}, -1},
+		{"NotBinary", bocpd.BetaBernoulli{Alpha: 1, Beta: 1}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := bocpd.NewDetector(tt.prior, bocpd.ConstantHazard(10)).Update(tt.x); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package bocpd
+
+import "math"
+
+// NormalGamma is the conjugate model of Gaussian observations with unknown mean and
+// variance: the precision follows Gamma(Alpha, Beta) and the mean, given the
+// precision τ, Normal(Mu, 1/(Kappa·τ)). The predictive distribution is a Student t
+// with 2·Alpha degrees of freedom. Kappa, Alpha and Beta must be positive; Beta/Alpha
+// is the prior guess of the noise variance.
+type NormalGamma struct {
+	Mu    float64 // Prior mean.
+	Kappa float64 // Number of pseudo-observations behind Mu.
+	Alpha float64 // Shape of the precision prior (half the pseudo-observations behind Beta).
+	Beta  float64 // Rate of the precision prior.
+}
+
+// LogPredictive implements Model.
+func (m NormalGamma) LogPredictive(x float64) float64 {
+	if !(m.Kappa > 0 && m.Alpha > 0 && m.Beta > 0) {
+		return math.NaN()
+	}
+	nu := 2 * m.Alpha
+	scale2 := m.Beta * (m.Kappa + 1) / (m.Alpha * m.Kappa)
+	z := (x - m.Mu) * (x - m.Mu) / (nu * scale2)
+	lgHalf, _ := math.Lgamma((nu + 1) / 2)
+	lg, _ := math.Lgamma(nu / 2)
+	return lgHalf - lg - 0.5*math.Log(nu*math.Pi*scale2) - (nu+1)/2*math.Log1p(z)
+}
+
+// Update implements Model.
+func (m NormalGamma) Update(x float64) Model {
+	return NormalGamma{
+		Mu:    (m.Kappa*m.Mu + x) / (m.Kappa + 1),
+		Kappa: m.Kappa + 1,
+		Alpha: m.Alpha + 0.5,
+		Beta:  m.Beta + m.Kappa*(x-m.Mu)*(x-m.Mu)/(2*(m.Kappa+1)),
+	}
+}
+
+// PoissonGamma is the conjugate model of count observations: Poisson counts whose
+// rate follows Gamma(Alpha, Beta), with mean Alpha/Beta. The predictive distribution
+// is negative binomial. Alpha and Beta must be positive; observations must be
+// non-negative integers.
+type PoissonGamma struct {
+	Alpha float64 // Shape of the rate prior (pseudo-count of events).
+	Beta  float64 // Rate of the rate prior (pseudo-number of observations).
+}
+
+// LogPredictive implements Model.
+func (m PoissonGamma) LogPredictive(x float64) float64 {
+	if !(m.Alpha > 0 && m.Beta > 0) {
+		return math.NaN()
+	}
+	if x < 0 || x != math.Trunc(x) {
+		return math.Inf(-1)
+	}
+	lgAX, _ := math.Lgamma(m.Alpha + x)
+	lgA, _ := math.Lgamma(m.Alpha)
+	lgX, _ := math.Lgamma(x + 1)
+	return lgAX - lgA - lgX + m.Alpha*math.Log(m.Beta/(m.Beta+1)) - x*math.Log(m.Beta+1)
+}
+
+// Update implements Model.
+func (m PoissonGamma) Update(x float64) Model {
+	return PoissonGamma{Alpha: m.Alpha + x, Beta: m.Beta + 1}
+}
+
+// BetaBernoulli is the conjugate model of binary observations (0 or 1), such as
+// success/failure events: the probability of a 1 follows Beta(Alpha, Beta). Alpha
+// and Beta must be positive.
+type BetaBernoulli struct {
+	Alpha float64 // Pseudo-count of ones.
+	Beta  float64 // Pseudo-count of zeros.
+}
+
+// LogPredictive implements Model.
+func (m BetaBernoulli) LogPredictive(x float64) float64 {
+	if !(m.Alpha > 0 && m.Beta > 0) {
+		return math.NaN()
+	}
+	switch x {
+	case 1:
+		return math.Log(m.Alpha / (m.Alpha + m.Beta))
+	case 0:
+		return math.Log(m.Beta / (m.Alpha + m.Beta))
+	default:
+		return math.Inf(-1)
+	}
+}
+
+// Update implements Model.
+func (m BetaBernoulli) Update(x float64) Model {
+	return BetaBernoulli{Alpha: m.Alpha + x, Beta: m.Beta + 1 - x}
+}
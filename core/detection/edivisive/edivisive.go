@@ -0,0 +1,259 @@
+// Package edivisive implements E-Divisive (Matteson and James, 2014), a
+// nonparametric hierarchical divisive detector based on the energy distance.
+//
+// The energy distance between the samples X and Y of two segments,
+//
+//	E(X, Y) = 2·mean |x - y|^α - mean |x - x'|^α - mean |y - y'|^α,
+//
+// is zero if and only if both samples come from the same distribution (for
+// 0 < α < 2), whatever the distribution and the dimension. E-Divisive splits
+// the signal where the scaled distance Q = m·n/(m+n) · E(X, Y) between the two
+// sides is largest, then keeps splitting the segment with the largest Q. Each
+// split is tested by permutation: the samples are shuffled within the current
+// segments, which preserves the change points already found and destroys any
+// other, and the p-value is the proportion of shuffles reaching a Q at least as
+// large. Detection stops at the first split that is not significant.
+//
+// No distributional assumption and no kernel bandwidth are needed, and every
+// change point comes with a p-value. Distances between all pairs of samples are
+// precomputed, so memory and time grow as n²: the detector suits signals of up to
+// a few thousand samples.
+package edivisive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Defaults of the E-Divisive parameters, following the ecp R package.
+const (
+	DefaultExponent     = 1.0
+	DefaultPermutations = 199
+	DefaultSigLevel     = 0.05
+)
+
+// ChangePoint is a change point found by E-Divisive.
+type ChangePoint struct {
+	Index     int     `json:"index"`     // Breakpoint: first sample after the change.
+	Statistic float64 `json:"statistic"` // Scaled energy distance Q between both sides.
+	PValue    float64 `json:"p_value"`   // Permutation p-value of the split.
+	Rank      int     `json:"rank"`      // Order of discovery, from 1.
+}
+
+// Result is the outcome of Detect.
+type Result struct {
+	// Breakpoints are the change points, sorted, ending with the number of samples.
+	Breakpoints []int `json:"breakpoints"`
+	// ChangePoints describe the change points, in the order of Breakpoints.
+	ChangePoints []ChangePoint `json:"change_points"`
+	// RejectedPValue is the p-value of the best split that was not significant, or
+	// 0 when the search stopped for another reason (MaxBkps, no admissible split).
+	RejectedPValue float64 `json:"rejected_p_value"`
+}
+
+// EDivisive is the E-Divisive detector.
+type EDivisive struct {
+	MinSize      int     // Minimum segment length; at least 2.
+	Exponent     float64 // Exponent α of the distances, in (0, 2].
+	Permutations int     // Number of permutations of each test.
+	SigLevel     float64 // Significance level of each split, in (0, 1).
+	MaxBkps      int     // Maximum number of change points; 0 means no limit.
+	Seed         int64   // Seed of the random generator drawing the permutations.
+}
+
+// NewEDivisive creates an E-Divisive detector with the default exponent.
+//
+// Parameters:
+//
+//	minSize:      The minimum segment length, at least 2.
+//	permutations: The number of permutations of each test; 0 selects DefaultPermutations.
+//	sigLevel:     The significance level of each split; 0 selects DefaultSigLevel.
+//	seed:         The seed of the random generator, for reproducible p-values.
+func NewEDivisive(minSize, permutations int, sigLevel float64, seed int64) *EDivisive {
+	if permutations == 0 {
+		permutations = DefaultPermutations
+	}
+	if sigLevel == 0 {
+		sigLevel = DefaultSigLevel
+	}
+	return &EDivisive{MinSize: minSize, Exponent: DefaultExponent, Permutations: permutations, SigLevel: sigLevel, Seed: seed}
+}
+
+// split is the best split of a segment [start, end).
+type split struct {
+	start, end int
+	at         int // -1 when the segment cannot be split.
+	q          float64
+}
+
+// Detect runs E-Divisive on the signal.
+func (e *EDivisive) Detect(signal types.Matrix) (Result, error) {
+	return e.DetectContext(context.Background(), signal)
+}
+
+// DetectContext is like Detect, but aborts with ctx.Err() once ctx is done.
+func (e *EDivisive) DetectContext(ctx context.Context, signal types.Matrix) (Result, error) {
+	if err := e.check(); err != nil {
+		return Result{}, err
+	}
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return Result{}, exceptions.ErrInvalidSignal
+	}
+	dist, err := e.distances(signal)
+	if err != nil {
+		return Result{}, err
+	}
+	n := len(signal)
+	rng := rand.New(rand.NewSource(e.Seed))
+	identity := make([]int, n)
+	for i := range identity {
+		identity[i] = i
+	}
+
+	var res Result
+	segments := []split{bestSplit(dist, identity, 0, n, e.MinSize)}
+	for e.MaxBkps == 0 || len(res.ChangePoints) < e.MaxBkps {
+		best := 0
+		for i, s := range segments {
+			if s.q > segments[best].q {
+				best = i
+			}
+		}
+		cand := segments[best]
+		if cand.at < 0 {
+			break
+		}
+
+		// Permutation test: shuffle within every current segment and count the
+		// shuffles whose best split is at least as good as the observed one.
+		exceed := 0
+		perm := make([]int, n)
+		for r := 0; r < e.Permutations; r++ {
+			if err := ctx.Err(); err != nil {
+				return Result{}, err
+			}
+			copy(perm, identity)
+			permQ := math.Inf(-1)
+			for _, s := range segments {
+				rng.Shuffle(s.end-s.start, func(i, j int) {
+					perm[s.start+i], perm[s.start+j] = perm[s.start+j], perm[s.start+i]
+				})
+				if ps := bestSplit(dist, perm, s.start, s.end, e.MinSize); ps.at >= 0 {
+					permQ = math.Max(permQ, ps.q)
+				}
+			}
+			if permQ >= cand.q {
+				exceed++
+			}
+		}
+		pValue := float64(exceed+1) / float64(e.Permutations+1)
+		if pValue > e.SigLevel {
+			res.RejectedPValue = pValue
+			break
+		}
+
+		res.ChangePoints = append(res.ChangePoints, ChangePoint{Index: cand.at, Statistic: cand.q, PValue: pValue, Rank: len(res.ChangePoints) + 1})
+		segments = append(segments[:best], segments[best+1:]...)
+		segments = append(segments,
+			bestSplit(dist, identity, cand.start, cand.at, e.MinSize),
+			bestSplit(dist, identity, cand.at, cand.end, e.MinSize))
+	}
+
+	sort.Slice(res.ChangePoints, func(i, j int) bool { return res.ChangePoints[i].Index < res.ChangePoints[j].Index })
+	res.Breakpoints = make([]int, 0, len(res.ChangePoints)+1)
+	for _, cp := range res.ChangePoints {
+		res.Breakpoints = append(res.Breakpoints, cp.Index)
+	}
+	res.Breakpoints = append(res.Breakpoints, n)
+	return res, nil
+}
+
+// check validates the parameters.
+func (e *EDivisive) check() error {
+	switch {
+	case e.MinSize < 2:
+		return fmt.Errorf("edivisive: min_size must be at least 2, got %d", e.MinSize)
+	case !(e.Exponent > 0 && e.Exponent <= 2):
+		return fmt.Errorf("edivisive: exponent must be in (0, 2], got %g", e.Exponent)
+	case e.Permutations < 1:
+		return fmt.Errorf("edivisive: permutations must be at least 1, got %d", e.Permutations)
+	case !(e.SigLevel > 0 && e.SigLevel < 1):
+		return fmt.Errorf("edivisive: significance level must be in (0, 1), got %g", e.SigLevel)
+	case e.MaxBkps < 0:
+		return errors.New("edivisive: max_bkps must be non-negative")
+	}
+	return nil
+}
+
+// distances returns the matrix of |x_i - x_j|^Exponent for all pairs of samples.
+func (e *EDivisive) distances(signal types.Matrix) ([][]float64, error) {
+	n, nFeatures := len(signal), len(signal[0])
+	dist := make([][]float64, n)
+	for i := range dist {
+		if len(signal[i]) != nFeatures {
+			return nil, fmt.Errorf("edivisive: row %d has %d features, want %d: %w", i, len(signal[i]), nFeatures, exceptions.ErrInvalidSignal)
+		}
+		dist[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			sum := 0.0
+			for k := 0; k < nFeatures; k++ {
+				d := signal[i][k] - signal[j][k]
+				sum += d * d
+			}
+			d := math.Pow(sum, e.Exponent/2)
+			if math.IsNaN(d) || math.IsInf(d, 0) {
+				return nil, fmt.Errorf("edivisive: samples %d and %d are not finite", i, j)
+			}
+			dist[i][j], dist[j][i] = d, d
+		}
+	}
+	return dist, nil
+}
+
+// bestSplit returns the split of [start, end) maximizing Q, for the samples
+// order[start:end]. It runs in O((end - start)²) with running sums: within(a, b)
+// is the sum of the distances between the samples of [a, b).
+func bestSplit(dist [][]float64, order []int, start, end, minSize int) split {
+	best := split{start: start, end: end, at: -1, q: math.Inf(-1)}
+	if end-start < 2*minSize {
+		return best
+	}
+	total := 0.0 // within(start, end)
+	for i := start; i < end; i++ {
+		row := dist[order[i]]
+		for j := i + 1; j < end; j++ {
+			total += row[order[j]]
+		}
+	}
+	// Grow the left side one sample at a time, from within(start, start) = 0 and
+	// within(start, end) for the right side.
+	left, right := 0.0, total
+	for t := start + 1; t <= end-minSize; t++ {
+		row := dist[order[t-1]]
+		for i := start; i < t-1; i++ {
+			left += row[order[i]]
+		}
+		for j := t; j < end; j++ {
+			right -= row[order[j]]
+		}
+		if t-start < minSize {
+			continue
+		}
+		m, n := float64(t-start), float64(end-t)
+		between := total - left - right
+		energy := 2*between/(m*n) - left/(m*(m-1)/2) - right/(n*(n-1)/2)
+		if q := m * n / (m + n) * energy; q > best.q {
+			best.at, best.q = t, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,148 @@
+package edivisive_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/detection/edivisive"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// logSignal returns a 2-feature signal whose mean never changes: the scale triples
+// at 100, and the Gaussian noise turns into ±3 with equal probability, of the same
+// variance, at 200.
+func logSignal(seed int64) (types.Matrix, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	signal := make(types.Matrix, 300)
+	for i := range signal {
+		signal[i] = make([]float64, 2)
+		for j := range signal[i] {
+			switch {
+			case i < 100:
+				signal[i][j] = rng.NormFloat64()
+			case i < 200:
+				signal[i][j] = 3 * rng.NormFloat64()
+			default:
+				signal[i][j] = float64(6*rng.Intn(2) - 3)
+			}
+		}
+	}
+	return signal, []int{100, 200, 300}
+}
+
+func TestDetect(t *testing.T) {
+	signal, truth := logSignal(1)
+	d := edivisive.NewEDivisive(10, 99, 0.05, 1)
+	res, err := d.Detect(signal)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	f1, err := metrics.F1Score(truth, res.Breakpoints, 10)
+	if err != nil {
+		t.Fatalf("F1Score failed: %v", err)
+	}
+	if f1 < 1 {
+		t.Errorf("breakpoints = %v, want within 10 of %v (F1 = %g)", res.Breakpoints, truth, f1)
+	}
+	ranks := map[int]bool{}
+	for _, cp := range res.ChangePoints {
+		if cp.PValue > d.SigLevel || cp.Statistic <= 0 {
+			t.Errorf("change point %+v is not significant", cp)
+		}
+		ranks[cp.Rank] = true
+	}
+	if len(ranks) != len(res.ChangePoints) {
+		t.Errorf("ranks are not distinct: %+v", res.ChangePoints)
+	}
+	if res.RejectedPValue <= d.SigLevel {
+		t.Errorf("RejectedPValue = %g, want above %g", res.RejectedPValue, d.SigLevel)
+	}
+}
+
+func TestDetectNoChange(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	signal := make(types.Matrix, 150)
+	for i := range signal {
+		signal[i] = []float64{rng.NormFloat64()}
+	}
+	res, err := edivisive.NewEDivisive(10, 99, 0.05, 3).Detect(signal)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if !reflect.DeepEqual(res.Breakpoints, []int{150}) {
+		t.Errorf("breakpoints = %v on a signal without change", res.Breakpoints)
+	}
+}
+
+func TestDetectOptions(t *testing.T) {
+	signal, truth := logSignal(4)
+	tests := []struct {
+		name string
+		det  *edivisive.EDivisive
+		want int // Number of change points.
+	}{
+		{name: "MaxBkps", det: &edivisive.EDivisive{MinSize: 10, Exponent: 1, Permutations: 49, SigLevel: 0.05, MaxBkps: 1}, want: 1},
+		{name: "Exponent", det: &edivisive.EDivisive{MinSize: 10, Exponent: 0.5, Permutations: 49, SigLevel: 0.05}, want: len(truth) - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.det.Detect(signal)
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+			if len(res.ChangePoints) != tt.want {
+				t.Errorf("%d change points (%v), want %d", len(res.ChangePoints), res.Breakpoints, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectSeedReproducible(t *testing.T) {
+	signal, _ := logSignal(5)
+	run := func() edivisive.Result {
+		res, err := edivisive.NewEDivisive(10, 19, 0.1, 7).Detect(signal)
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		return res
+	}
+	if a, b := run(), run(); !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed gave %+v and %+v", a, b)
+	}
+}
+
+func TestDetectErrors(t *testing.T) {
+	signal, _ := logSignal(6)
+	tests := []struct {
+		name   string
+		det    *edivisive.EDivisive
+		signal types.Matrix
+	}{
+		{name: "MinSize", det: edivisive.NewEDivisive(1, 0, 0, 0), signal: signal},
+		{name: "Exponent", det: &edivisive.EDivisive{MinSize: 5, Exponent: 2.5, Permutations: 9, SigLevel: 0.05}, signal: signal},
+		{name: "SigLevel", det: edivisive.NewEDivisive(5, 0, 1.5, 0), signal: signal},
+		{name: "Empty", det: edivisive.NewEDivisive(5, 0, 0, 0), signal: types.Matrix{}},
+		{name: "NotFinite", det: edivisive.NewEDivisive(5, 0, 0, 0), signal: types.Matrix{{0}, {math.Inf(1)}, {1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.det.Detect(tt.signal); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestDetectContextCanceled(t *testing.T) {
+	signal, _ := logSignal(8)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := edivisive.NewEDivisive(10, 0, 0, 0).DetectContext(ctx, signal); !errors.Is(err, context.Canceled) {
+		t.Errorf("DetectContext error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,248 @@
+// Package fpop implements FPOP, functional pruning optimal partitioning (Maidstone,
+// Hocking, Rigaill and Fearnhead, 2017), for changes in the mean of a univariate
+// signal under the L2 cost.
+//
+// FPOP solves the same penalized problem as pelt.Pelt with the L2 cost, exactly:
+// it returns the optimal segmentation with segments of at least MinSize samples.
+// The two detectors prune differently. PELT drops a candidate change point τ once
+// its best cost F(τ) + C(τ, t) exceeds F(t): with few change points that rarely
+// happens, and the number of candidates, hence the run time, grows quadratically.
+// That rule is exact without a minimum segment length, so both detectors agree
+// with MinSize 1; with a larger MinSize, PELT may drop a candidate that a
+// segmentation ending less than MinSize samples later still needs, and its
+// segmentation is then an approximation of FPOP's. FPOP instead keeps, as a
+// function of the mean μ of the last segment,
+//
+//	q_τ(μ) = F(τ) + β + Σ_{τ ≤ i < t} (x_i - μ)²,
+//
+// and the set of values of μ for which τ is the best candidate. Every new
+// candidate shrinks these sets; a candidate is dropped as soon as its set is
+// empty, which happens quickly whatever the number of change points. In practice
+// the number of candidates stays logarithmic in the length of the signal.
+package fpop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// span is a closed interval [lo, hi] of means.
+type span struct {
+	lo, hi float64
+}
+
+// candidate is a candidate last change point τ with the sums of the signal up to
+// τ and the set of means for which it is optimal.
+type candidate struct {
+	tau     int
+	cost    float64 // F(τ) + β
+	sum     float64 // Σ_{i < τ} x_i
+	squares float64 // Σ_{i < τ} x_i²
+	set     []span  // Sorted, disjoint intervals.
+}
+
+// quadratic returns the coefficients of q_τ(μ) = a·μ² - 2·b·μ + c at the time
+// whose cumulative sums are sum and squares.
+func (c *candidate) quadratic(t int, sum, squares float64) (a, b, cst float64) {
+	return float64(t - c.tau), sum - c.sum, c.cost + squares - c.squares
+}
+
+// FPOP is the functional pruning detector. It implements base.Estimator.
+type FPOP struct {
+	MinSize int // Minimum segment length.
+
+	signal   []float64
+	maxCands int
+}
+
+// NewFPOP creates an FPOP detector with the given minimum segment length.
+func NewFPOP(minSize int) *FPOP {
+	return &FPOP{MinSize: minSize}
+}
+
+// Fit stores the signal, which must be univariate.
+func (f *FPOP) Fit(signal types.Matrix) error {
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return exceptions.ErrInvalidSignal
+	}
+	xs := make([]float64, len(signal))
+	for i, row := range signal {
+		if len(row) != 1 {
+			return fmt.Errorf("fpop: row %d has %d features; FPOP requires a univariate signal", i, len(row))
+		}
+		if math.IsNaN(row[0]) || math.IsInf(row[0], 0) {
+			return fmt.Errorf("fpop: sample %d is not finite", i)
+		}
+		xs[i] = row[0]
+	}
+	f.signal = xs
+	return nil
+}
+
+// Predict returns the breakpoints of the optimal segmentation for the penalty.
+func (f *FPOP) Predict(penalty float64) ([]int, error) {
+	return f.PredictContext(context.Background(), penalty)
+}
+
+// PredictContext is like Predict, but aborts with ctx.Err() once ctx is done.
+func (f *FPOP) PredictContext(ctx context.Context, penalty float64) ([]int, error) {
+	if f.signal == nil {
+		return nil, errors.New("fpop: detector not fitted, call Fit() first")
+	}
+	if penalty <= 0 {
+		return nil, errors.New("fpop: penalty must be greater than 0")
+	}
+	if f.MinSize < 1 {
+		return nil, fmt.Errorf("fpop: min_size must be at least 1, got %d", f.MinSize)
+	}
+	n := len(f.signal)
+	if n < f.MinSize {
+		return nil, exceptions.ErrBadSegmentationParameters
+	}
+
+	// The mean of every segment lies within the range of the signal.
+	domain := span{lo: math.Inf(1), hi: math.Inf(-1)}
+	for _, x := range f.signal {
+		domain.lo, domain.hi = math.Min(domain.lo, x), math.Max(domain.hi, x)
+	}
+
+	prev := make([]int, n+1)
+	// A candidate τ only competes from t = τ + MinSize on; until then it waits in
+	// pending, in increasing order of τ. The origin has cost F(0) + β = 0.
+	pending := []candidate{{tau: 0}}
+	var active []candidate
+	var sum, squares float64
+	f.maxCands = 0
+	for t := 1; t <= n; t++ {
+		if t%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		x := f.signal[t-1]
+		sum += x
+		squares += x * x
+
+		if len(pending) > 0 && pending[0].tau == t-f.MinSize {
+			active = activate(active, pending[0], domain)
+			pending = pending[1:]
+		}
+		if len(active) == 0 {
+			continue // t < MinSize: no admissible segmentation ends at t.
+		}
+		f.maxCands = max(f.maxCands, len(active))
+
+		best, bestCost := 0, math.Inf(1)
+		for i := range active {
+			if c := minOver(&active[i], t, sum, squares); c < bestCost {
+				best, bestCost = i, c
+			}
+		}
+		prev[t] = active[best].tau
+		if t < n {
+			pending = append(pending, candidate{tau: t, cost: bestCost + penalty, sum: sum, squares: squares})
+		}
+	}
+
+	bkps := []int{n}
+	for t := prev[n]; t > 0; t = prev[t] {
+		bkps = append(bkps, t)
+	}
+	sort.Ints(bkps)
+	return bkps, nil
+}
+
+// FitPredict fits the detector on the signal and predicts its breakpoints.
+func (f *FPOP) FitPredict(signal types.Matrix, penalty float64) ([]int, error) {
+	if err := f.Fit(signal); err != nil {
+		return nil, err
+	}
+	return f.Predict(penalty)
+}
+
+// MaxCandidates returns the largest number of candidates kept at once by the last
+// Predict, which bounds the cost of each step.
+func (f *FPOP) MaxCandidates() int {
+	return f.maxCands
+}
+
+// minOver returns the minimum of q_τ at time t over the set of the candidate.
+func minOver(c *candidate, t int, sum, squares float64) float64 {
+	a, b, cst := c.quadratic(t, sum, squares)
+	vertex := b / a
+	best := math.Inf(1)
+	for _, s := range c.set {
+		mu := math.Max(s.lo, math.Min(s.hi, vertex))
+		best = math.Min(best, a*mu*mu-2*b*mu+cst)
+	}
+	return best
+}
+
+// activate makes the candidate nu compete: every active candidate τ keeps only
+// the means where q_τ ≤ F(ν) + β at time ν (beyond that, ν is better at every
+// later time, since both accumulate the same residuals), and ν takes the rest of
+// the domain. Candidates left with an empty set are dropped, as is ν.
+func activate(active []candidate, nu candidate, domain span) []candidate {
+	kept := active[:0]
+	for _, c := range active {
+		a, b, cst := c.quadratic(nu.tau, nu.sum, nu.squares)
+		// q_τ(μ) ≤ cost ⇔ a·μ² - 2·b·μ + (cst - cost) ≤ 0.
+		disc := b*b - a*(cst-nu.cost)
+		if disc < 0 {
+			continue
+		}
+		root := math.Sqrt(disc) / a
+		c.set = intersect(c.set, span{lo: b/a - root, hi: b/a + root})
+		if len(c.set) > 0 {
+			kept = append(kept, c)
+		}
+	}
+	nu.set = complement(kept, domain)
+	if len(nu.set) > 0 {
+		kept = append(kept, nu)
+	}
+	return kept
+}
+
+// intersect returns the parts of the sorted spans within s.
+func intersect(spans []span, s span) []span {
+	out := spans[:0]
+	for _, sp := range spans {
+		lo, hi := math.Max(sp.lo, s.lo), math.Min(sp.hi, s.hi)
+		if lo <= hi {
+			out = append(out, span{lo, hi})
+		}
+	}
+	return out
+}
+
+// complement returns the parts of domain covered by no set of the candidates,
+// ignoring empty gaps.
+func complement(cands []candidate, domain span) []span {
+	var covered []span
+	for _, c := range cands {
+		covered = append(covered, c.set...)
+	}
+	sort.Slice(covered, func(i, j int) bool { return covered[i].lo < covered[j].lo })
+	var out []span
+	lo := domain.lo
+	for _, s := range covered {
+		if s.lo > lo {
+			out = append(out, span{lo, math.Min(s.lo, domain.hi)})
+		}
+		lo = math.Max(lo, s.hi)
+		if lo >= domain.hi {
+			return out
+		}
+	}
+	if lo < domain.hi || len(covered) == 0 {
+		out = append(out, span{lo, domain.hi})
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package fpop_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/detection/fpop"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// stepSignal returns a univariate signal of n samples with unit Gaussian noise and
+// a new random mean every segmentLength samples.
+func stepSignal(seed int64, n, segmentLength int) types.Matrix {
+	rng := rand.New(rand.NewSource(seed))
+	signal := make(types.Matrix, n)
+	level := 0.0
+	for i := range signal {
+		if i%segmentLength == 0 {
+			level = 4 * rng.NormFloat64()
+		}
+		signal[i] = []float64{level + rng.NormFloat64()}
+	}
+	return signal
+}
+
+func TestFPOPMatchesPelt(t *testing.T) {
+	// PELT's pruning is exact with MinSize 1 (see the package documentation).
+	tests := []struct {
+		name    string
+		signal  types.Matrix
+		minSize int
+		penalty float64
+	}{
+		{name: "ManyChanges", signal: stepSignal(1, 500, 25), minSize: 1, penalty: 10},
+		{name: "FewChanges", signal: stepSignal(2, 2000, 1000), minSize: 1, penalty: 15},
+		{name: "SmallPenalty", signal: stepSignal(4, 300, 30), minSize: 1, penalty: 1},
+		{name: "Constant", signal: types.Matrix{{1}, {1}, {1}, {1}, {1}}, minSize: 1, penalty: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := pelt.NewPelt(cost.NewCostL2(), tt.minSize, 1).FitPredict(tt.signal, tt.penalty)
+			if err != nil {
+				t.Fatalf("Pelt failed: %v", err)
+			}
+			got, err := fpop.NewFPOP(tt.minSize).FitPredict(tt.signal, tt.penalty)
+			if err != nil {
+				t.Fatalf("FPOP failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("FPOP = %v, Pelt = %v", got, want)
+			}
+		})
+	}
+}
+
+// optimalPartition returns the optimal segmentation of the signal with the L2
+// cost, segments of at least minSize samples and the penalty, by the O(n²)
+// dynamic program without pruning.
+func optimalPartition(signal types.Matrix, minSize int, penalty float64) []int {
+	n := len(signal)
+	sums, squares := make([]float64, n+1), make([]float64, n+1)
+	for i, row := range signal {
+		sums[i+1], squares[i+1] = sums[i]+row[0], squares[i]+row[0]*row[0]
+	}
+	best, prev := make([]float64, n+1), make([]int, n+1)
+	best[0] = -penalty
+	for t := 1; t <= n; t++ {
+		best[t] = math.Inf(1)
+		for s := 0; s <= t-minSize; s++ {
+			sum := sums[t] - sums[s]
+			v := best[s] + squares[t] - squares[s] - sum*sum/float64(t-s) + penalty
+			if v < best[t] {
+				best[t], prev[t] = v, s
+			}
+		}
+	}
+	bkps := []int{n}
+	for t := prev[n]; t > 0; t = prev[t] {
+		bkps = append([]int{t}, bkps...)
+	}
+	return bkps
+}
+
+func TestFPOPMatchesOptimalPartition(t *testing.T) {
+	// With MinSize > 1, FPOP stays exact where PELT's pruning is not.
+	tests := []struct {
+		name    string
+		signal  types.Matrix
+		minSize int
+		penalty float64
+	}{
+		{name: "MinSize", signal: stepSignal(3, 400, 40), minSize: 5, penalty: 8},
+		{name: "SmallPenalty", signal: stepSignal(4, 300, 30), minSize: 2, penalty: 1},
+		{name: "ShortSegments", signal: stepSignal(6, 300, 7), minSize: 4, penalty: 3},
+		{name: "LargeMinSize", signal: stepSignal(7, 300, 20), minSize: 30, penalty: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := optimalPartition(tt.signal, tt.minSize, tt.penalty)
+			got, err := fpop.NewFPOP(tt.minSize).FitPredict(tt.signal, tt.penalty)
+			if err != nil {
+				t.Fatalf("FPOP failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("FPOP = %v, optimal partition = %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFPOPPrunesWithFewChanges(t *testing.T) {
+	// A single change in a long signal: PELT keeps about n candidates, FPOP a handful.
+	signal := stepSignal(5, 20000, 10000)
+	f := fpop.NewFPOP(1)
+	bkps, err := f.FitPredict(signal, 20)
+	if err != nil {
+		t.Fatalf("FitPredict failed: %v", err)
+	}
+	if !reflect.DeepEqual(bkps, []int{10000, 20000}) {
+		t.Errorf("breakpoints = %v, want [10000 20000]", bkps)
+	}
+	if f.MaxCandidates() > 100 {
+		t.Errorf("MaxCandidates = %d, want a few", f.MaxCandidates())
+	}
+}
+
+func TestFPOPErrors(t *testing.T) {
+	signal := stepSignal(6, 50, 10)
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "NotFitted", run: func() error {
+			_, err := fpop.NewFPOP(1).Predict(1)
+			return err
+		}},
+		{name: "ZeroPenalty", run: func() error {
+			_, err := fpop.NewFPOP(1).FitPredict(signal, 0)
+			return err
+		}},
+		{name: "ZeroMinSize", run: func() error {
+			_, err := fpop.NewFPOP(0).FitPredict(signal, 1)
+			return err
+		}},
+		{name: "Multivariate", run: func() error {
+			return fpop.NewFPOP(1).Fit(types.Matrix{{0, 1}, {1, 0}})
+		}},
+		{name: "Empty", run: func() error {
+			return fpop.NewFPOP(1).Fit(types.Matrix{})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestFPOPPredictContextCanceled(t *testing.T) {
+	f := fpop.NewFPOP(1)
+	if err := f.Fit(stepSignal(7, 5000, 100)); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.PredictContext(ctx, 5); !errors.Is(err, context.Canceled) {
+		t.Errorf("PredictContext error = %v, want %v", err, context.Canceled)
+	}
+}
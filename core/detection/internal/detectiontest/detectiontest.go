@@ -0,0 +1,34 @@
+// Package detectiontest provides the signals shared by the tests of the detection
+// packages.
+package detectiontest
+
+import (
+	"math/rand"
+
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// BurstSignal returns a signal with a short burst of level 4 between two long
+// quiet segments, with Gaussian noise of standard deviation 0.5: the case where
+// plain binary segmentation fails.
+//
+// Parameters:
+//
+//	seed: Seed of the noise.
+//
+// Returns:
+//
+//	types.Matrix: The 300-sample univariate signal.
+//	[]int:        Its breakpoints, [140 152 300].
+func BurstSignal(seed int64) (types.Matrix, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	signal := make(types.Matrix, 300)
+	for i := range signal {
+		level := 0.0
+		if i >= 140 && i < 152 {
+			level = 4
+		}
+		signal[i] = []float64{level + 0.5*rng.NormFloat64()}
+	}
+	return signal, []int{140, 152, 300}
+}
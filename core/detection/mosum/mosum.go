@@ -0,0 +1,302 @@
+// Package mosum implements the moving sum (MOSUM) procedure for changes of the mean
+// of a univariate signal (Eichinger and Kirch, 2018), with multiscale bottom-up
+// merging over several bandwidths (Messer et al., 2014; Cho and Kirch, 2022).
+//
+// For a bandwidth G, the MOSUM statistic at k compares the means of the G samples
+// before and after k:
+//
+//	T_G(k) = √(G/2) · |mean(x[k:k+G]) - mean(x[k-G:k])| / σ̂_k,  G ≤ k ≤ n - G,
+//
+// where σ̂_k estimates the noise standard deviation, globally or from both
+// windows, and is floored so that the statistic stays finite on noiseless
+// signals. Under the hypothesis of no change, max_k T_G(k) has a Gumbel-type
+// limit, which gives the critical value of a level-α test and a p-value for every
+// location (see CriticalValue and PValue). Change points are the local maxima of
+// the statistic over the critical value. The whole procedure runs in O(n) per
+// bandwidth.
+//
+// Small bandwidths localize close change points, large ones detect small changes;
+// Detect runs several bandwidths and merges their change points bottom-up: the
+// change points of smaller bandwidths are kept first, and a larger bandwidth only
+// adds change points far enough from those already kept.
+//
+// Example:
+//
+//	res, err := mosum.NewMOSUM(0.05).Detect(xs)
+//	if err != nil {
+//		return err
+//	}
+//	for _, cp := range res.ChangePoints {
+//		fmt.Printf("change at %d (G=%d, T=%.2f, p=%.3g)\n", cp.Index, cp.Bandwidth, cp.Statistic, cp.PValue)
+//	}
+package mosum
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/theDataFlowClub/ruptures/core/penalty"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Defaults of the MOSUM parameters, following the mosum R package.
+const (
+	DefaultAlpha = 0.1 // Significance level of each bandwidth.
+	DefaultEta   = 0.4 // Neighbourhood of a change point, relative to G.
+)
+
+// minRelativeVariance is the floor of the noise variance estimates, relative to
+// the variance of the signal values: without it, the statistic of a noiseless
+// piecewise-constant signal is infinite at every change.
+const minRelativeVariance = 1e-6
+
+// ChangePoint is a change point detected by the MOSUM procedure.
+type ChangePoint struct {
+	Index         int     `json:"index"`          // Breakpoint: first sample after the change.
+	Bandwidth     int     `json:"bandwidth"`      // Bandwidth G that detected it.
+	Statistic     float64 `json:"statistic"`      // T_G at the change point.
+	CriticalValue float64 `json:"critical_value"` // Critical value of the bandwidth.
+	PValue        float64 `json:"p_value"`        // Asymptotic p-value of the statistic.
+}
+
+// Scale holds the statistic curve of one bandwidth.
+type Scale struct {
+	Bandwidth     int       `json:"bandwidth"`
+	CriticalValue float64   `json:"critical_value"`
+	Statistic     []float64 `json:"statistic"` // T_G(k) for k = 0..n-1; 0 outside [G, n-G].
+	// ChangePoints are the change points of this bandwidth alone, before merging.
+	ChangePoints []ChangePoint `json:"change_points"`
+}
+
+// Result is the outcome of Detect.
+type Result struct {
+	// Breakpoints are the merged change points, sorted, ending with the number of samples.
+	Breakpoints []int `json:"breakpoints"`
+	// ChangePoints describe the merged change points, in the order of Breakpoints.
+	ChangePoints []ChangePoint `json:"change_points"`
+	// Scales hold the statistic of every bandwidth, in increasing bandwidth order.
+	Scales []Scale `json:"scales"`
+}
+
+// MOSUM is the multiscale MOSUM detector.
+type MOSUM struct {
+	// Bandwidths are the window sizes G; nil selects DefaultBandwidths.
+	Bandwidths []int
+	Alpha      float64 // Significance level of each bandwidth, in (0, 1).
+	// Eta sets the neighbourhood of a change point, Eta·G locations on either side:
+	// a change point is the maximum of the statistic over its neighbourhood, and a
+	// larger bandwidth only adds change points outside the neighbourhoods of those
+	// already kept.
+	Eta float64
+	// Sigma is the noise standard deviation. When 0, it is estimated from the MAD
+	// of the first differences (see penalty.EstimateSigma), with the same floor as
+	// the local estimates for noiseless signals; when negative, the
+	// pooled standard deviation of the two windows is used at every location, which
+	// suits heteroscedastic signals but gives more false alarms at small bandwidths.
+	Sigma float64
+}
+
+// NewMOSUM creates a MOSUM detector at the given significance level, with the
+// given bandwidths or the default ones.
+func NewMOSUM(alpha float64, bandwidths ...int) *MOSUM {
+	return &MOSUM{Bandwidths: bandwidths, Alpha: alpha, Eta: DefaultEta}
+}
+
+// DefaultBandwidths returns a Fibonacci-like grid of bandwidths, 10, 20, 30, 50,
+// 80, ..., up to min(n/2, n^(2/3)), or nil when n is shorter than 32 samples.
+func DefaultBandwidths(n int) []int {
+	maxG := int(math.Min(float64(n)/2, math.Pow(float64(n), 2.0/3)))
+	var gs []int
+	for prev, g := 10, 10; g <= maxG; prev, g = g, g+prev {
+		if len(gs) == 0 || g != gs[len(gs)-1] {
+			gs = append(gs, g)
+		}
+	}
+	return gs
+}
+
+// Statistic returns the MOSUM statistic T_G(k) for k = 0..len(xs)-1, 0 where the
+// windows do not fit. sigma is the noise standard deviation, or 0 for the
+// pooled standard deviation of the windows, whose variance is floored at
+// minRelativeVariance times that of xs. The statistic of a constant signal is 0.
+func Statistic(xs []float64, g int, sigma float64) ([]float64, error) {
+	n := len(xs)
+	if g < 1 || 2*g > n {
+		return nil, fmt.Errorf("mosum: bandwidth %d does not fit in %d samples", g, n)
+	}
+	sums := make([]float64, n+1)
+	squares := make([]float64, n+1)
+	for i, x := range xs {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil, fmt.Errorf("mosum: sample %d is not finite", i)
+		}
+		sums[i+1] = sums[i] + x
+		squares[i+1] = squares[i] + x*x
+	}
+	fg := float64(g)
+	stat := make([]float64, n)
+	mean := sums[n] / float64(n)
+	spread := squares[n]/float64(n) - mean*mean
+	if !(spread > 0) {
+		return stat, nil
+	}
+	minVariance := minRelativeVariance * spread
+	for k := range stat {
+		if k < g || k > n-g {
+			continue
+		}
+		left, right := sums[k]-sums[k-g], sums[k+g]-sums[k]
+		diff := math.Abs(right-left) / fg
+		s := sigma
+		if s == 0 {
+			ss := squares[k+g] - squares[k-g] - (left*left+right*right)/fg
+			s = math.Sqrt(math.Max(ss/(2*fg), minVariance))
+		}
+		stat[k] = math.Sqrt(fg/2) * diff / s
+	}
+	return stat, nil
+}
+
+// scaling returns the normalizing constants a and b of the limit of max_k T_G(k):
+// P(a·max T - b ≤ x) → exp(-2·e^(-x)).
+func scaling(n, g int) (a, b float64) {
+	r := math.Log(float64(n) / float64(g))
+	a = math.Sqrt(2 * r)
+	b = 2*r + 0.5*math.Log(r) + math.Log(1.5) - 0.5*math.Log(math.Pi)
+	return a, b
+}
+
+// CriticalValue returns the asymptotic critical value of the level-alpha MOSUM
+// test with bandwidth g on n samples.
+func CriticalValue(n, g int, alpha float64) float64 {
+	a, b := scaling(n, g)
+	return (b - math.Log(-0.5*math.Log(1-alpha))) / a
+}
+
+// PValue returns the asymptotic p-value of a maximum statistic stat for bandwidth
+// g on n samples.
+func PValue(n, g int, stat float64) float64 {
+	a, b := scaling(n, g)
+	return -math.Expm1(-2 * math.Exp(b-a*stat))
+}
+
+// Detect runs every bandwidth on xs and merges their change points bottom-up.
+func (m *MOSUM) Detect(xs []float64) (Result, error) {
+	n := len(xs)
+	if n == 0 {
+		return Result{}, errors.New("mosum: empty signal")
+	}
+	if !(m.Alpha > 0 && m.Alpha < 1) {
+		return Result{}, fmt.Errorf("mosum: alpha must be in (0, 1), got %g", m.Alpha)
+	}
+	if m.Eta < 0 {
+		return Result{}, fmt.Errorf("mosum: eta must be non-negative, got %g", m.Eta)
+	}
+	bandwidths := append([]int(nil), m.Bandwidths...)
+	if len(bandwidths) == 0 {
+		if bandwidths = DefaultBandwidths(n); len(bandwidths) == 0 {
+			return Result{}, fmt.Errorf("mosum: signal of %d samples too short for the default bandwidths", n)
+		}
+	}
+	sort.Ints(bandwidths)
+	sigma := m.Sigma
+	switch {
+	case sigma < 0:
+		sigma = 0
+	case sigma == 0:
+		column := make(types.Matrix, n)
+		for i, x := range xs {
+			column[i] = []float64{x}
+		}
+		sigmas, err := penalty.EstimateSigmas(column)
+		if err != nil {
+			return Result{}, fmt.Errorf("mosum: %w", err)
+		}
+		sigma = sigmas[0]
+		if sigma == 0 {
+			// A noiseless signal: the floor of the local estimates, or the local
+			// estimates themselves when the signal is constant.
+			sigma = math.Sqrt(minRelativeVariance * variance(xs))
+		}
+	}
+
+	res := Result{Scales: make([]Scale, 0, len(bandwidths))}
+	for _, g := range bandwidths {
+		scale, err := m.scale(xs, g, sigma)
+		if err != nil {
+			return Result{}, err
+		}
+		res.Scales = append(res.Scales, scale)
+	}
+
+	// Bottom-up merge: smaller bandwidths first, each change point only kept far
+	// enough from those already kept.
+	for _, scale := range res.Scales {
+		minDist := m.Eta * float64(scale.Bandwidth)
+		for _, cp := range scale.ChangePoints {
+			far := true
+			for _, kept := range res.ChangePoints {
+				if math.Abs(float64(cp.Index-kept.Index)) < minDist {
+					far = false
+					break
+				}
+			}
+			if far {
+				res.ChangePoints = append(res.ChangePoints, cp)
+			}
+		}
+	}
+	sort.Slice(res.ChangePoints, func(i, j int) bool { return res.ChangePoints[i].Index < res.ChangePoints[j].Index })
+	res.Breakpoints = make([]int, 0, len(res.ChangePoints)+1)
+	for _, cp := range res.ChangePoints {
+		res.Breakpoints = append(res.Breakpoints, cp.Index)
+	}
+	res.Breakpoints = append(res.Breakpoints, n)
+	return res, nil
+}
+
+// variance returns the variance of the values of xs.
+func variance(xs []float64) float64 {
+	var sum, sumSquares float64
+	for _, x := range xs {
+		sum += x
+		sumSquares += x * x
+	}
+	mean := sum / float64(len(xs))
+	return math.Max(sumSquares/float64(len(xs))-mean*mean, 0)
+}
+
+// scale computes the statistic of one bandwidth and its change points: the
+// locations over the critical value where the statistic is maximal within Eta·G
+// locations on either side.
+func (m *MOSUM) scale(xs []float64, g int, sigma float64) (Scale, error) {
+	stat, err := Statistic(xs, g, sigma)
+	if err != nil {
+		return Scale{}, err
+	}
+	n := len(xs)
+	scale := Scale{Bandwidth: g, CriticalValue: CriticalValue(n, g, m.Alpha), Statistic: stat}
+	radius := int(m.Eta * float64(g))
+	for k := g; k <= n-g; k++ {
+		if !(stat[k] > scale.CriticalValue) {
+			continue
+		}
+		local := true
+		for i := max(g, k-radius); i <= min(n-g, k+radius) && local; i++ {
+			// Ties go to the first location, so that a plateau yields one change point.
+			local = stat[i] < stat[k] || (stat[i] == stat[k] && i >= k)
+		}
+		if local {
+			scale.ChangePoints = append(scale.ChangePoints, ChangePoint{
+				Index:         k,
+				Bandwidth:     g,
+				Statistic:     stat[k],
+				CriticalValue: scale.CriticalValue,
+				PValue:        PValue(n, g, stat[k]),
+			})
+		}
+	}
+	return scale, nil
+}
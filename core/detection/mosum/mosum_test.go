@@ -0,0 +1,183 @@
+package mosum_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/detection/mosum"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+)
+
+// stepSignal returns a signal with unit Gaussian noise and the given mean levels
+// between the breakpoints.
+func stepSignal(seed int64, bkps []int, levels []float64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	xs := make([]float64, bkps[len(bkps)-1])
+	seg := 0
+	for i := range xs {
+		if i >= bkps[seg] {
+			seg++
+		}
+		xs[i] = levels[seg] + rng.NormFloat64()
+	}
+	return xs
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		bkps   []int
+		levels []float64
+		det    *mosum.MOSUM
+		margin int
+	}{
+		{name: "SingleBandwidth", bkps: []int{200, 400, 600}, levels: []float64{0, 2, 0.5}, det: mosum.NewMOSUM(0.05, 50), margin: 5},
+		// A close pair of changes needs a small bandwidth, a small change a large one.
+		{name: "Multiscale", bkps: []int{300, 360, 700, 1000}, levels: []float64{0, 3, 0, 0.9}, det: mosum.NewMOSUM(0.05), margin: 15},
+		{name: "LocalVariance", bkps: []int{200, 400, 600}, levels: []float64{0, 2, 0.5}, det: &mosum.MOSUM{Bandwidths: []int{50}, Alpha: 0.05, Eta: mosum.DefaultEta, Sigma: -1}, margin: 5},
+		{name: "KnownSigma", bkps: []int{150, 300}, levels: []float64{0, 1.5}, det: &mosum.MOSUM{Bandwidths: []int{40}, Alpha: 0.05, Eta: mosum.DefaultEta, Sigma: 1}, margin: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xs := stepSignal(1, tt.bkps, tt.levels)
+			res, err := tt.det.Detect(xs)
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+			f1, err := metrics.F1Score(tt.bkps, res.Breakpoints, tt.margin)
+			if err != nil {
+				t.Fatalf("F1Score failed: %v", err)
+			}
+			if f1 < 1 {
+				t.Errorf("breakpoints = %v, want within %d of %v (F1 = %g)", res.Breakpoints, tt.margin, tt.bkps, f1)
+			}
+			if len(res.ChangePoints) != len(res.Breakpoints)-1 {
+				t.Fatalf("%d change points for breakpoints %v", len(res.ChangePoints), res.Breakpoints)
+			}
+			for _, cp := range res.ChangePoints {
+				if cp.Statistic <= cp.CriticalValue || cp.PValue >= tt.det.Alpha {
+					t.Errorf("change point %+v is not significant", cp)
+				}
+			}
+			for _, s := range res.Scales {
+				if len(s.Statistic) != len(xs) {
+					t.Errorf("bandwidth %d: curve of %d values, want %d", s.Bandwidth, len(s.Statistic), len(xs))
+				}
+			}
+			if _, err := json.Marshal(res); err != nil {
+				t.Errorf("result does not marshal to JSON: %v", err)
+			}
+		})
+	}
+}
+
+func TestDetectNoChange(t *testing.T) {
+	xs := stepSignal(2, []int{1000}, []float64{0})
+	res, err := mosum.NewMOSUM(0.05).Detect(xs)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if !reflect.DeepEqual(res.Breakpoints, []int{1000}) {
+		t.Errorf("breakpoints = %v on a signal without change", res.Breakpoints)
+	}
+}
+
+func TestCriticalValue(t *testing.T) {
+	for _, alpha := range []float64{0.01, 0.05, 0.1} {
+		cv := mosum.CriticalValue(1000, 50, alpha)
+		if p := mosum.PValue(1000, 50, cv); math.Abs(p-alpha) > 1e-12 {
+			t.Errorf("PValue(CriticalValue(%g)) = %g", alpha, p)
+		}
+	}
+	if mosum.CriticalValue(1000, 50, 0.01) <= mosum.CriticalValue(1000, 50, 0.1) {
+		t.Error("critical value should grow as alpha decreases")
+	}
+	if mosum.CriticalValue(10000, 50, 0.05) <= mosum.CriticalValue(1000, 50, 0.05) {
+		t.Error("critical value should grow with n/G")
+	}
+}
+
+func TestStatistic(t *testing.T) {
+	xs := []float64{0, 0, 0, 2, 2, 2}
+	stat, err := mosum.Statistic(xs, 3, 1)
+	if err != nil {
+		t.Fatalf("Statistic failed: %v", err)
+	}
+	want := []float64{0, 0, 0, math.Sqrt(1.5) * 2, 0, 0}
+	for k := range want {
+		if math.Abs(stat[k]-want[k]) > 1e-12 {
+			t.Errorf("T(%d) = %g, want %g", k, stat[k], want[k])
+		}
+	}
+	if _, err := mosum.Statistic(xs, 4, 0); err == nil {
+		t.Error("expected an error for a bandwidth larger than half the signal")
+	}
+}
+
+func TestDefaultBandwidths(t *testing.T) {
+	if got, want := mosum.DefaultBandwidths(1000), []int{10, 20, 30, 50, 80}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultBandwidths(1000) = %v, want %v", got, want)
+	}
+	if got := mosum.DefaultBandwidths(15); got != nil {
+		t.Errorf("DefaultBandwidths(15) = %v, want nil", got)
+	}
+}
+
+func TestDetectErrors(t *testing.T) {
+	xs := stepSignal(3, []int{100}, []float64{0})
+	tests := []struct {
+		name string
+		det  *mosum.MOSUM
+		xs   []float64
+	}{
+		{name: "Empty", det: mosum.NewMOSUM(0.05), xs: nil},
+		{name: "BadAlpha", det: mosum.NewMOSUM(1.5), xs: xs},
+		{name: "BandwidthTooLarge", det: mosum.NewMOSUM(0.05, 60), xs: xs},
+		{name: "TooShort", det: mosum.NewMOSUM(0.05), xs: xs[:15]},
+		{name: "NotFinite", det: mosum.NewMOSUM(0.05, 10), xs: append([]float64{math.NaN()}, xs...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.det.Detect(tt.xs); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestDetectNoiseless(t *testing.T) {
+	// Without noise the variance estimates are zero; their floor keeps the
+	// statistic finite, so that the result still encodes as JSON.
+	bkps := []int{100, 200, 300}
+	xs := make([]float64, 300)
+	for i := range xs {
+		xs[i] = float64(i / 100 % 2)
+	}
+	for _, sigma := range []float64{0, -1} {
+		det := &mosum.MOSUM{Bandwidths: []int{30}, Alpha: 0.05, Eta: mosum.DefaultEta, Sigma: sigma}
+		res, err := det.Detect(xs)
+		if err != nil {
+			t.Fatalf("sigma %g: Detect failed: %v", sigma, err)
+		}
+		if !reflect.DeepEqual(res.Breakpoints, bkps) {
+			t.Errorf("sigma %g: breakpoints = %v, want %v", sigma, res.Breakpoints, bkps)
+		}
+		for _, v := range res.Scales[0].Statistic {
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				t.Fatalf("sigma %g: statistic %g is not finite", sigma, v)
+			}
+		}
+		if _, err := json.Marshal(res); err != nil {
+			t.Errorf("sigma %g: result does not marshal to JSON: %v", sigma, err)
+		}
+	}
+
+	// A constant signal has no change point.
+	res, err := mosum.NewMOSUM(0.05, 30).Detect(make([]float64, 300))
+	if err != nil || len(res.Breakpoints) != 1 {
+		t.Errorf("constant signal: breakpoints = %v (%v), want [300]", res.Breakpoints, err)
+	}
+}
@@ -0,0 +1,287 @@
+// Package not implements the Narrowest-Over-Threshold detector (Baranowski, Chen
+// and Fryzlewicz, 2019).
+//
+// NOT draws many random sub-intervals of the signal and computes, on each of them,
+// the largest contrast between the data and a model with one change point. Among
+// the intervals whose contrast exceeds the threshold, it keeps the narrowest one:
+// being narrow, it most likely contains a single change point, which its contrast
+// then locates accurately. The search recurses on both sides of that change point.
+// This selection gives NOT near-optimal localization rates, including for changes
+// of slope, where binary segmentation style methods are poorly localized.
+//
+// Two contrasts are available:
+//
+//   - PiecewiseConstant detects changes of the mean: the contrast is the CUSUM
+//     statistic √(l·r/n)·|mean(right) - mean(left)|.
+//   - PiecewiseLinear detects changes of slope in a continuous piecewise-linear
+//     signal (trend changes): the contrast is the projection of the data on the
+//     kink (t - b)₊ orthogonalized against the linear trend on the interval.
+//
+// Contrasts are computed in O(1) per split point from cumulative sums, and in units
+// of the noise level, so that the threshold hardly depends on the data:
+// DefaultThreshold gives the usual choice. Multivariate signals use the Euclidean
+// norm of the per-feature contrasts.
+package not
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/penalty"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Contrast selects the signal model whose changes NOT detects.
+type Contrast string
+
+const (
+	PiecewiseConstant Contrast = "constant" // Changes of the mean.
+	PiecewiseLinear   Contrast = "linear"   // Changes of slope of a continuous trend.
+)
+
+// DefaultIntervals is the number of random intervals drawn when NIntervals is 0.
+const DefaultIntervals = 5000
+
+// DefaultThreshold returns the threshold recommended by Baranowski et al. for a
+// signal of nSamples samples, 1.3·√(2·log n) in units of the noise level, raised to
+// 1.3·√(2·log n + d - 1) for d features to account for the norm of d contrasts.
+func DefaultThreshold(nSamples, nFeatures int) float64 {
+	return 1.3 * math.Sqrt(2*math.Log(float64(nSamples))+float64(nFeatures-1))
+}
+
+// interval is a sub-interval [start, end) of the signal with its best split.
+type interval struct {
+	start, end int
+	split      int
+	contrast   float64
+}
+
+// NOT is the Narrowest-Over-Threshold detector. It implements base.Estimator,
+// where the penalty passed to Predict is the threshold on the contrast.
+type NOT struct {
+	Contrast   Contrast // Signal model.
+	MinSize    int      // Minimum segment length.
+	NIntervals int      // Number of random intervals; 0 selects DefaultIntervals.
+	Seed       int64    // Seed of the random generator drawing the intervals.
+	// Sigma is the noise standard deviation, shared by all features. When 0, it is
+	// estimated for every feature from the MAD of the first differences (see
+	// penalty.EstimateSigmas).
+	Sigma float64
+
+	nSamples  int
+	sums      [][]float64 // Cumulative sums of x per feature, standardized.
+	moments   [][]float64 // Cumulative sums of t·x per feature, standardized.
+	intervals []interval  // Random intervals with a valid split, computed lazily.
+}
+
+// NewNOT creates a NOT detector.
+//
+// Parameters:
+//
+//	contrast:   PiecewiseConstant or PiecewiseLinear.
+//	minSize:    The minimum segment length.
+//	nIntervals: The number of random intervals; 0 selects DefaultIntervals.
+//	seed:       The seed of the random generator, for reproducible results.
+func NewNOT(contrast Contrast, minSize, nIntervals int, seed int64) *NOT {
+	return &NOT{Contrast: contrast, MinSize: minSize, NIntervals: nIntervals, Seed: seed}
+}
+
+// Fit standardizes the signal by its noise level and computes its cumulative sums.
+func (d *NOT) Fit(signal types.Matrix) error {
+	if d.Contrast != PiecewiseConstant && d.Contrast != PiecewiseLinear {
+		return fmt.Errorf("not: unknown contrast %q (available: %s, %s)", d.Contrast, PiecewiseConstant, PiecewiseLinear)
+	}
+	if d.MinSize < 1 {
+		return fmt.Errorf("not: min_size must be at least 1, got %d", d.MinSize)
+	}
+	if d.Sigma < 0 {
+		return fmt.Errorf("not: sigma must be non-negative, got %g", d.Sigma)
+	}
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return exceptions.ErrInvalidSignal
+	}
+	nFeatures := len(signal[0])
+	sigmas := make([]float64, nFeatures)
+	if d.Sigma > 0 {
+		for j := range sigmas {
+			sigmas[j] = d.Sigma
+		}
+	} else {
+		var err error
+		if sigmas, err = penalty.EstimateSigmas(signal); err != nil {
+			return err
+		}
+		for j, s := range sigmas {
+			if s == 0 {
+				return fmt.Errorf("not: estimated noise level of feature %d is zero; set Sigma explicitly", j)
+			}
+		}
+	}
+
+	n := len(signal)
+	d.sums = make([][]float64, nFeatures)
+	d.moments = make([][]float64, nFeatures)
+	for j := range d.sums {
+		d.sums[j] = make([]float64, n+1)
+		d.moments[j] = make([]float64, n+1)
+	}
+	for i, row := range signal {
+		if len(row) != nFeatures {
+			return fmt.Errorf("not: row %d has %d features, want %d: %w", i, len(row), nFeatures, exceptions.ErrInvalidSignal)
+		}
+		for j, v := range row {
+			x := v / sigmas[j]
+			d.sums[j][i+1] = d.sums[j][i] + x
+			d.moments[j][i+1] = d.moments[j][i] + float64(i)*x
+		}
+	}
+	d.nSamples, d.intervals = n, nil
+	return nil
+}
+
+// Predict returns the breakpoints selected with the given threshold on the contrast.
+func (d *NOT) Predict(threshold float64) ([]int, error) {
+	return d.PredictContext(context.Background(), threshold)
+}
+
+// PredictContext is like Predict, but aborts with ctx.Err() once ctx is done.
+func (d *NOT) PredictContext(ctx context.Context, threshold float64) ([]int, error) {
+	if d.sums == nil {
+		return nil, errors.New("not: detector not fitted, call Fit() first")
+	}
+	if threshold <= 0 {
+		return nil, errors.New("not: threshold must be greater than 0")
+	}
+	if d.intervals == nil {
+		if err := d.computeIntervals(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Narrowest first: the first interval over the threshold inside a segment wins.
+	bkps := []int{d.nSamples}
+	pending := [][2]int{{0, d.nSamples}}
+	for len(pending) > 0 {
+		seg := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, iv := range d.intervals {
+			if iv.start >= seg[0] && iv.end <= seg[1] && iv.contrast > threshold {
+				bkps = append(bkps, iv.split)
+				pending = append(pending, [2]int{seg[0], iv.split}, [2]int{iv.split, seg[1]})
+				break
+			}
+		}
+	}
+	sort.Ints(bkps)
+	return bkps, nil
+}
+
+// FitPredict fits the detector on the signal and predicts its breakpoints.
+func (d *NOT) FitPredict(signal types.Matrix, threshold float64) ([]int, error) {
+	if err := d.Fit(signal); err != nil {
+		return nil, err
+	}
+	return d.Predict(threshold)
+}
+
+// computeIntervals draws the random intervals, plus the whole signal, finds the
+// best split of each and sorts them by increasing width.
+func (d *NOT) computeIntervals(ctx context.Context) error {
+	count := d.NIntervals
+	if count <= 0 {
+		count = DefaultIntervals
+	}
+	n, minLength := d.nSamples, 2*d.MinSize
+	if n < minLength {
+		d.intervals = []interval{}
+		return nil
+	}
+	rng := rand.New(rand.NewSource(d.Seed))
+	bounds := [][2]int{{0, n}}
+	for len(bounds) < count+1 {
+		s, e := rng.Intn(n+1), rng.Intn(n+1)
+		if s > e {
+			s, e = e, s
+		}
+		if e-s >= minLength {
+			bounds = append(bounds, [2]int{s, e})
+		}
+	}
+	d.intervals = make([]interval, 0, len(bounds))
+	for _, b := range bounds {
+		if err := ctx.Err(); err != nil {
+			d.intervals = nil
+			return err
+		}
+		if split, c := d.bestSplit(b[0], b[1]); split >= 0 {
+			d.intervals = append(d.intervals, interval{b[0], b[1], split, c})
+		}
+	}
+	sort.SliceStable(d.intervals, func(a, b int) bool {
+		return d.intervals[a].end-d.intervals[a].start < d.intervals[b].end-d.intervals[b].start
+	})
+	return nil
+}
+
+// bestSplit returns the split of [start, end) with the largest contrast, or -1
+// when none is admissible.
+func (d *NOT) bestSplit(start, end int) (int, float64) {
+	best, bestContrast := -1, 0.0
+	for b := start + d.MinSize; b <= end-d.MinSize; b++ {
+		total := 0.0
+		for j := range d.sums {
+			var c float64
+			if d.Contrast == PiecewiseLinear {
+				c = d.linearContrast(j, start, b, end)
+			} else {
+				c = d.constantContrast(j, start, b, end)
+			}
+			total += c * c
+		}
+		if c := math.Sqrt(total); c > bestContrast {
+			best, bestContrast = b, c
+		}
+	}
+	return best, bestContrast
+}
+
+// constantContrast returns the CUSUM statistic of feature j on [start, end) at b.
+func (d *NOT) constantContrast(j, start, b, end int) float64 {
+	s := d.sums[j]
+	l, r := float64(b-start), float64(end-b)
+	meanLeft := (s[b] - s[start]) / l
+	meanRight := (s[end] - s[b]) / r
+	return math.Sqrt(l*r/(l+r)) * (meanRight - meanLeft)
+}
+
+// linearContrast returns the normalized inner product of feature j on [start, end)
+// with the kink g(t) = (t - b)₊ made orthogonal to the constant and linear trends
+// of the interval. Positions are counted from start to keep the sums small.
+func (d *NOT) linearContrast(j, start, b, end int) float64 {
+	n := float64(end - start)
+	m := float64(end - b) // g takes the values 0, 1, ..., m-1 on [b, end).
+	sumG := m * (m - 1) / 2
+	sumG2 := (m - 1) * m * (2*m - 1) / 6
+	offset := float64(b - start)
+	meanT := (n - 1) / 2
+	sumU2 := n * (n*n - 1) / 12                      // Σ (t - mean t)²
+	sumGU := offset*sumG + sumG2 - meanT*sumG        // Σ g·(t - mean t)
+	normG := sumG2 - sumG*sumG/n - sumGU*sumGU/sumU2 // Squared norm of the residual of g
+	if normG <= 1e-12 {
+		return 0
+	}
+
+	s, mom := d.sums[j], d.moments[j]
+	sumX := s[end] - s[start]
+	sumTX := mom[end] - mom[start] - float64(start)*sumX // Σ (t - start)·x
+	sumXU := sumTX - meanT*sumX
+	// Σ g·x = Σ_{t ≥ b} (t - b)·x
+	sumGX := mom[end] - mom[b] - float64(b)*(s[end]-s[b])
+	inner := sumGX - sumG*sumX/n - sumGU/sumU2*sumXU
+	return inner / math.Sqrt(normG)
+}
@@ -0,0 +1,159 @@
+package not_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/detection/internal/detectiontest"
+	"github.com/theDataFlowClub/ruptures/core/detection/not"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// trendSignal returns a continuous piecewise-linear signal whose slope changes at
+// 150 and 300, with Gaussian noise of standard deviation 0.5.
+func trendSignal(seed int64, nFeatures int) (types.Matrix, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	signal := make(types.Matrix, 400)
+	level := 0.0
+	for i := range signal {
+		slope := 0.05
+		switch {
+		case i >= 300:
+			slope = 0.03
+		case i >= 150:
+			slope = -0.06
+		}
+		level += slope
+		signal[i] = make([]float64, nFeatures)
+		for j := range signal[i] {
+			signal[i][j] = level + 0.5*rng.NormFloat64()
+		}
+	}
+	return signal, []int{150, 300, 400}
+}
+
+func TestNOT(t *testing.T) {
+	trend, trendBkps := trendSignal(1, 1)
+	trend3, _ := trendSignal(2, 3)
+	burst, burstBkps := detectiontest.BurstSignal(3)
+	tests := []struct {
+		name   string
+		est    *not.NOT
+		signal types.Matrix
+		want   []int
+		margin int
+	}{
+		{name: "Linear", est: not.NewNOT(not.PiecewiseLinear, 2, 1000, 1), signal: trend, want: trendBkps, margin: 10},
+		{name: "LinearMultivariate", est: not.NewNOT(not.PiecewiseLinear, 2, 1000, 1), signal: trend3, want: trendBkps, margin: 10},
+		{name: "Constant", est: not.NewNOT(not.PiecewiseConstant, 1, 1000, 1), signal: burst, want: burstBkps, margin: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bkps, err := tt.est.FitPredict(tt.signal, not.DefaultThreshold(len(tt.signal), len(tt.signal[0])))
+			if err != nil {
+				t.Fatalf("FitPredict failed: %v", err)
+			}
+			f1, err := metrics.F1Score(tt.want, bkps, tt.margin)
+			if err != nil {
+				t.Fatalf("F1Score failed: %v", err)
+			}
+			if f1 < 1 {
+				t.Errorf("breakpoints = %v, want within %d of %v (F1 = %g)", bkps, tt.margin, tt.want, f1)
+			}
+		})
+	}
+}
+
+func TestNOTConstantContrastMissesSlopeChange(t *testing.T) {
+	// A pure change of slope, with no jump, is what the linear contrast is for: the
+	// constant contrast chops the trend into many steps instead.
+	signal, _ := trendSignal(4, 1)
+	d := not.NewNOT(not.PiecewiseConstant, 2, 1000, 1)
+	bkps, err := d.FitPredict(signal, not.DefaultThreshold(len(signal), 1))
+	if err != nil {
+		t.Fatalf("FitPredict failed: %v", err)
+	}
+	if len(bkps) <= 3 {
+		t.Errorf("constant contrast found %v, expected a staircase of many breakpoints", bkps)
+	}
+}
+
+func TestNOTThreshold(t *testing.T) {
+	signal, _ := trendSignal(5, 1)
+	d := not.NewNOT(not.PiecewiseLinear, 2, 500, 9)
+	d.Sigma = 0.5
+	if err := d.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	bkps, err := d.Predict(1e6)
+	if err != nil {
+		t.Fatalf("Predict failed: %v", err)
+	}
+	if !reflect.DeepEqual(bkps, []int{400}) {
+		t.Errorf("breakpoints = %v, want [400] with a huge threshold", bkps)
+	}
+}
+
+func TestNOTSeedReproducible(t *testing.T) {
+	signal, _ := trendSignal(6, 1)
+	run := func() []int {
+		bkps, err := not.NewNOT(not.PiecewiseLinear, 2, 50, 9).FitPredict(signal, 2)
+		if err != nil {
+			t.Fatalf("FitPredict failed: %v", err)
+		}
+		return bkps
+	}
+	if a, b := run(), run(); !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed gave %v and %v", a, b)
+	}
+}
+
+func TestNOTErrors(t *testing.T) {
+	signal, _ := detectiontest.BurstSignal(6)
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "NotFitted", run: func() error {
+			_, err := not.NewNOT(not.PiecewiseConstant, 1, 0, 0).Predict(1)
+			return err
+		}},
+		{name: "UnknownContrast", run: func() error {
+			return not.NewNOT("quadratic", 1, 0, 0).Fit(signal)
+		}},
+		{name: "ZeroThreshold", run: func() error {
+			_, err := not.NewNOT(not.PiecewiseConstant, 1, 10, 0).FitPredict(signal, 0)
+			return err
+		}},
+		{name: "NoiselessSignal", run: func() error {
+			return not.NewNOT(not.PiecewiseConstant, 1, 0, 0).Fit(types.Matrix{{1}, {1}, {1}, {2}, {2}})
+		}},
+		{name: "EmptySignal", run: func() error {
+			return not.NewNOT(not.PiecewiseConstant, 1, 0, 0).Fit(types.Matrix{})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestNOTPredictContextCanceled(t *testing.T) {
+	signal, _ := detectiontest.BurstSignal(7)
+	d := not.NewNOT(not.PiecewiseConstant, 1, 0, 0)
+	if err := d.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := d.PredictContext(ctx, 3); !errors.Is(err, context.Canceled) {
+		t.Errorf("PredictContext error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,125 @@
+package pelt
+
+import (
+	"context"
+	"fmt"
+)
+
+// CropsSegmentation es una segmentación óptima devuelta por Crops, junto con el
+// intervalo de penalizaciones para el que es óptima.
+type CropsSegmentation struct {
+	Breakpoints []int   // Puntos de cambio (el último es n_samples)
+	NBkps       int     // Número de puntos de cambio (len(Breakpoints) - 1)
+	Cost        float64 // Suma de los costos de los segmentos, sin penalización
+	PenaltyMin  float64 // Menor penalización del rango para la que la segmentación es óptima
+	PenaltyMax  float64 // Mayor penalización del rango para la que la segmentación es óptima
+}
+
+// PenalizedCost devuelve el costo penalizado de la segmentación para la penalización dada.
+func (s CropsSegmentation) PenalizedCost(penalty float64) float64 {
+	return s.Cost + float64(s.NBkps)*penalty
+}
+
+// Crops implementa CROPS (Changepoints for a Range Of PenaltieS, Haynes, Eckley y
+// Fearnhead, 2017): devuelve todas las segmentaciones óptimas distintas para las
+// penalizaciones de [penMin, penMax], ordenadas por penalización creciente (es decir,
+// por número de puntos de cambio decreciente).
+//
+// Cada segmentación es óptima en su intervalo [PenaltyMin, PenaltyMax]; los intervalos
+// de segmentaciones consecutivas se tocan en los valores donde ambas tienen el mismo
+// costo penalizado. CROPS ejecuta PELT a lo sumo 2·K veces, siendo K el número de
+// segmentaciones distintas, reutilizando la función de costo ya ajustada.
+func (p *Pelt) Crops(penMin, penMax float64) ([]CropsSegmentation, error) {
+	return p.CropsContext(context.Background(), penMin, penMax)
+}
+
+// CropsContext es como Crops, pero aborta con ctx.Err() en cuanto el contexto se
+// cancela o expira.
+func (p *Pelt) CropsContext(ctx context.Context, penMin, penMax float64) ([]CropsSegmentation, error) {
+	if penMin <= 0 || penMax < penMin {
+		return nil, fmt.Errorf("Pelt: CROPS requires 0 < penMin <= penMax, got [%g, %g].", penMin, penMax)
+	}
+
+	// Segmentaciones ya calculadas, indexadas por número de puntos de cambio.
+	byNBkps := make(map[int]CropsSegmentation)
+	run := func(penalty float64) (CropsSegmentation, error) {
+		bkps, totalCost, err := p.predictWithCost(ctx, penalty)
+		if err != nil {
+			return CropsSegmentation{}, err
+		}
+		seg := CropsSegmentation{Breakpoints: bkps, NBkps: len(bkps) - 1}
+		seg.Cost = totalCost - float64(seg.NBkps)*penalty
+		if _, ok := byNBkps[seg.NBkps]; !ok {
+			byNBkps[seg.NBkps] = seg
+		}
+		return seg, nil
+	}
+
+	low, err := run(penMin)
+	if err != nil {
+		return nil, err
+	}
+	high, err := run(penMax)
+	if err != nil {
+		return nil, err
+	}
+
+	// Intervalos de penalización pendientes, cada uno con las segmentaciones de sus extremos.
+	type interval struct {
+		low, high       CropsSegmentation
+		penLow, penHigh float64
+	}
+	pending := []interval{{low, high, penMin, penMax}}
+	for len(pending) > 0 {
+		iv := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if iv.low.NBkps <= iv.high.NBkps+1 {
+			continue // No puede haber otra segmentación óptima entre ambas.
+		}
+		// Penalización en la que las dos segmentaciones tienen el mismo costo penalizado.
+		// Fuera del intervalo solo puede deberse a errores de redondeo: no hay nada entre ambas.
+		crossing := crossingPenalty(iv.low, iv.high)
+		if crossing <= iv.penLow || crossing >= iv.penHigh {
+			continue
+		}
+		mid, err := run(crossing)
+		if err != nil {
+			return nil, err
+		}
+		if mid.NBkps == iv.high.NBkps || mid.NBkps == iv.low.NBkps {
+			continue // Las segmentaciones de los extremos son vecinas en el camino de soluciones.
+		}
+		pending = append(pending, interval{iv.low, mid, iv.penLow, crossing}, interval{mid, iv.high, crossing, iv.penHigh})
+	}
+
+	// Recorre las segmentaciones por número de puntos de cambio decreciente y conserva
+	// solo la envolvente inferior de las rectas Cost + NBkps·penalty: una segmentación
+	// óptima en un único punto (empate) no aporta ningún intervalo.
+	segs := make([]CropsSegmentation, 0, len(byNBkps))
+	for nBkps := low.NBkps; nBkps >= high.NBkps; nBkps-- {
+		seg, ok := byNBkps[nBkps]
+		if !ok {
+			continue
+		}
+		for len(segs) >= 2 && crossingPenalty(segs[len(segs)-2], segs[len(segs)-1]) >= crossingPenalty(segs[len(segs)-1], seg) {
+			segs = segs[:len(segs)-1]
+		}
+		segs = append(segs, seg)
+	}
+	for i := range segs {
+		segs[i].PenaltyMin, segs[i].PenaltyMax = penMin, penMax
+		if i > 0 {
+			segs[i].PenaltyMin = crossingPenalty(segs[i-1], segs[i])
+		}
+		if i < len(segs)-1 {
+			segs[i].PenaltyMax = crossingPenalty(segs[i], segs[i+1])
+		}
+	}
+	return segs, nil
+}
+
+// crossingPenalty devuelve la penalización en la que las segmentaciones more (con más
+// puntos de cambio) y fewer tienen el mismo costo penalizado.
+func crossingPenalty(more, fewer CropsSegmentation) float64 {
+	return (fewer.Cost - more.Cost) / float64(more.NBkps-fewer.NBkps)
+}
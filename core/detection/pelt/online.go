@@ -0,0 +1,282 @@
+package pelt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Online es una versión en línea (streaming) de PELT con costo L2: recibe las muestras
+// una a una con Push y devuelve los puntos de cambio en cuanto quedan confirmados, sin
+// volver a recorrer la historia. Usa la misma recursión que predictL2Optimized,
+//
+//	F(t) = min_{τ ∈ R, τ <= t-MinSize} F(τ) + C(τ, t) + penalty,
+//
+// con sumas acumuladas para calcular C(τ, t) en O(d), y la regla de poda de PELT: un
+// candidato τ se descarta cuando F(τ) + C(τ, t) >= F(t). La segmentación obtenida es
+// aproximadamente la de Pelt con costo L2, no exactamente: Pelt solo poda el prefijo de
+// candidatos, mientras que Online poda cada candidato por separado para que la memoria no
+// crezca con el flujo, y con MinSize > 1 la regla de poda deja de ser exacta (un candidato
+// descartado en t aún podría ser óptimo antes de t + MinSize). Las dos segmentaciones
+// coinciden casi siempre; cuando difieren, la de Online tiene casi los mismos puntos de
+// cambio y un costo penalizado apenas mayor (ver TestOnlineApproximatesPelt).
+//
+// Un punto de cambio está confirmado cuando forma parte de la segmentación óptima de
+// todos los candidatos supervivientes: ninguna muestra futura puede eliminarlo. La
+// historia anterior al último punto confirmado se descarta, de modo que la memoria solo
+// depende del número de candidatos (acotable con MaxCandidates) y de los puntos de
+// cambio pendientes, no de la longitud del flujo.
+//
+// Online admite señales multivariadas (el costo es la suma de los costos L2 de cada
+// dimensión). No es seguro para uso concurrente.
+type Online struct {
+	Penalty float64 // Penalización por punto de cambio; debe ser mayor que 0
+	MinSize int     // Tamaño mínimo de un segmento
+	// MaxCandidates, si es mayor que 0, limita el número de candidatos conservados: al
+	// superarlo se descartan los menos prometedores (mayor F(τ) + C(τ, t)). La memoria
+	// queda acotada a costa de que la segmentación se aleje más de la de Pelt.
+	MaxCandidates int
+
+	nSamples   int               // Número de muestras recibidas
+	nFeatures  int               // Dimensión de las muestras (fijada por la primera)
+	sums       []float64         // Suma acumulada de cada dimensión
+	squares    []float64         // Suma acumulada de los cuadrados de cada dimensión
+	root       *onlineNode       // Último punto confirmado (o el origen del flujo)
+	last       *onlineNode       // Final de la segmentación óptima de las muestras recibidas
+	candidates []onlineCandidate // Candidatos a último punto de cambio supervivientes a la poda
+}
+
+// onlineNode es un punto de cambio de una segmentación óptima. Los nodos forman un árbol:
+// prev apunta al punto de cambio anterior de la segmentación óptima que termina en pos.
+type onlineNode struct {
+	pos   int
+	depth int
+	prev  *onlineNode
+}
+
+// onlineCandidate es un candidato τ a último punto de cambio: F(τ), las sumas
+// acumuladas hasta τ y el nodo de la segmentación óptima de las muestras [0, τ).
+type onlineCandidate struct {
+	node    *onlineNode
+	cost    float64
+	sums    []float64
+	squares []float64
+	value   float64 // F(τ) + C(τ, t) en la última muestra, para la poda
+}
+
+// NewOnline crea un detector en línea con la penalización y el tamaño mínimo de segmento dados.
+func NewOnline(penalty float64, minSize int) *Online {
+	return &Online{Penalty: penalty, MinSize: minSize}
+}
+
+// NSamples devuelve el número de muestras recibidas.
+func (o *Online) NSamples() int {
+	return o.nSamples
+}
+
+// Candidates devuelve el número de candidatos conservados, que determina la memoria
+// y el tiempo de cada Push.
+func (o *Online) Candidates() int {
+	return len(o.candidates)
+}
+
+// Push añade una muestra y devuelve los puntos de cambio confirmados por ella, en orden
+// creciente y como índices absolutos en el flujo (la muestra i es la i-ésima recibida,
+// empezando en 0). Casi siempre la lista está vacía.
+func (o *Online) Push(sample []float64) ([]int, error) {
+	if err := o.check(sample); err != nil {
+		return nil, err
+	}
+	if o.root == nil {
+		o.reset()
+	}
+
+	o.nSamples++
+	for j, v := range sample {
+		o.sums[j] += v
+		o.squares[j] += v * v
+	}
+	t := o.nSamples
+
+	// Recursión de PELT sobre los candidatos admisibles (segmento de al menos MinSize muestras).
+	best, bestCost := -1, math.Inf(1)
+	for i := range o.candidates {
+		c := &o.candidates[i]
+		if c.node.pos > t-o.MinSize {
+			continue
+		}
+		c.value = c.cost + o.segmentCost(c, t)
+		if c.value+o.Penalty < bestCost {
+			best, bestCost = i, c.value+o.Penalty
+		}
+	}
+	if best < 0 {
+		// Aún no hay un segmento admisible que termine en t: t no puede ser punto de cambio.
+		return nil, nil
+	}
+
+	// Poda: se descartan los candidatos que ya no pueden ser óptimos en el futuro.
+	kept := o.candidates[:0]
+	bestNode := o.candidates[best].node
+	for _, c := range o.candidates {
+		if c.node.pos <= t-o.MinSize && c.value >= bestCost {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	o.candidates = kept
+	o.last = &onlineNode{pos: t, depth: bestNode.depth + 1, prev: bestNode}
+	o.candidates = append(o.candidates, onlineCandidate{
+		node:    o.last,
+		cost:    bestCost,
+		sums:    append([]float64(nil), o.sums...),
+		squares: append([]float64(nil), o.squares...),
+	})
+	o.limitCandidates(t)
+	return o.confirm(), nil
+}
+
+// Pending devuelve los puntos de cambio de la segmentación óptima de las muestras
+// recibidas que aún no están confirmados: pueden cambiar con las próximas muestras.
+func (o *Online) Pending() []int {
+	if o.last == nil {
+		return nil
+	}
+	var pending []int
+	for n := o.last.prev; n != nil && n != o.root; n = n.prev {
+		pending = append(pending, n.pos)
+	}
+	sort.Ints(pending)
+	return pending
+}
+
+// Flush da por terminado el tramo actual del flujo: devuelve los puntos de cambio
+// pendientes como definitivos y reinicia la detección a partir de la siguiente muestra,
+// como si el flujo empezara de nuevo (el índice NSamples() actúa como frontera de
+// segmento, aunque no se informa como punto de cambio).
+func (o *Online) Flush() []int {
+	pending := o.Pending()
+	o.reset()
+	return pending
+}
+
+// check valida los parámetros y la muestra antes de incorporarla.
+func (o *Online) check(sample []float64) error {
+	if o.Penalty <= 0 {
+		return errors.New("Pelt: penalty must be greater than 0.")
+	}
+	if o.MinSize < 1 {
+		return errors.New("Pelt: min_size must be at least 1.")
+	}
+	if len(sample) == 0 {
+		return errors.New("Pelt: empty sample.")
+	}
+	if o.nFeatures != 0 && len(sample) != o.nFeatures {
+		return fmt.Errorf("Pelt: sample %d has %d features, want %d.", o.nSamples, len(sample), o.nFeatures)
+	}
+	for _, v := range sample {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("Pelt: sample %d is not finite.", o.nSamples)
+		}
+	}
+	o.nFeatures = len(sample)
+	return nil
+}
+
+// reset reinicia la detección en la posición actual del flujo: el único candidato es
+// el origen, con costo -penalty como en predictL2Optimized.
+func (o *Online) reset() {
+	if o.sums == nil {
+		o.sums = make([]float64, o.nFeatures)
+		o.squares = make([]float64, o.nFeatures)
+	}
+	depth := 0
+	if o.root != nil {
+		depth = o.root.depth
+	}
+	o.root = &onlineNode{pos: o.nSamples, depth: depth}
+	o.last = o.root
+	o.candidates = []onlineCandidate{{
+		node:    o.root,
+		cost:    -o.Penalty,
+		sums:    append([]float64(nil), o.sums...),
+		squares: append([]float64(nil), o.squares...),
+	}}
+}
+
+// segmentCost calcula el costo L2 del segmento [τ, t) del candidato con las sumas acumuladas.
+func (o *Online) segmentCost(c *onlineCandidate, t int) float64 {
+	length := float64(t - c.node.pos)
+	total := 0.0
+	for j := range o.sums {
+		total += l2CostFromSums(o.sums[j]-c.sums[j], o.squares[j]-c.squares[j], length)
+	}
+	return total
+}
+
+// limitCandidates descarta los candidatos admisibles menos prometedores cuando hay más
+// de MaxCandidates. Los candidatos aún no admisibles (demasiado recientes) se conservan.
+func (o *Online) limitCandidates(t int) {
+	if o.MaxCandidates <= 0 || len(o.candidates) <= o.MaxCandidates {
+		return
+	}
+	excess := len(o.candidates) - o.MaxCandidates
+	order := make([]int, 0, len(o.candidates))
+	for i, c := range o.candidates {
+		if c.node.pos <= t-o.MinSize {
+			order = append(order, i)
+		}
+	}
+	// Nunca se descarta el mejor candidato: es el último nodo de la segmentación óptima.
+	sort.Slice(order, func(a, b int) bool { return o.candidates[order[a]].value > o.candidates[order[b]].value })
+	if excess > len(order)-1 {
+		excess = max(0, len(order)-1)
+	}
+	drop := make(map[int]bool, excess)
+	for _, i := range order[:excess] {
+		drop[i] = true
+	}
+	kept := o.candidates[:0]
+	for i, c := range o.candidates {
+		if !drop[i] {
+			kept = append(kept, c)
+		}
+	}
+	o.candidates = kept
+}
+
+// confirm busca el ancestro común de las segmentaciones de todos los candidatos: los
+// puntos de cambio entre la raíz y ese ancestro ya no pueden cambiar. Los devuelve en
+// orden creciente y convierte el ancestro en la nueva raíz, liberando la historia anterior.
+func (o *Online) confirm() []int {
+	common := o.candidates[0].node
+	for _, c := range o.candidates[1:] {
+		common = commonAncestor(common, c.node)
+	}
+	if common == o.root {
+		return nil
+	}
+	var confirmed []int
+	for n := common; n != o.root; n = n.prev {
+		confirmed = append(confirmed, n.pos)
+	}
+	sort.Ints(confirmed)
+	common.prev = nil
+	o.root = common
+	return confirmed
+}
+
+// commonAncestor devuelve el nodo más profundo común a las cadenas de a y b.
+func commonAncestor(a, b *onlineNode) *onlineNode {
+	for a.depth > b.depth {
+		a = a.prev
+	}
+	for b.depth > a.depth {
+		b = b.prev
+	}
+	for a != b {
+		a, b = a.prev, b.prev
+	}
+	return a
+}
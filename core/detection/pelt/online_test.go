@@ -0,0 +1,207 @@
+package pelt_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/datasets"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+)
+
+func TestOnlineMatchesPelt(t *testing.T) {
+	// En estas señales, sin límite de candidatos, los puntos confirmados más los pendientes
+	// coinciden con la segmentación de Pelt sobre la señal completa (en general solo se
+	// aproximan, ver TestOnlineApproximatesPelt).
+	tests := []struct {
+		name    string
+		seed    int64
+		noise   float64
+		minSize int
+		penalty float64
+	}{
+		{"Noiseless", 1, 0, 2, 1},
+		{"Noisy", 2, 1, 2, 10},
+		{"NoisyMinSize", 3, 1, 10, 10},
+		{"LowPenalty", 4, 2, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := datasets.DefaultParams()
+			params.NSamples = 1000
+			params.NBkps = 5
+			params.NoiseStd = tt.noise
+			params.Seed = tt.seed
+			signal, _, err := datasets.PwConstant(params)
+			if err != nil {
+				t.Fatalf("PwConstant failed: %v", err)
+			}
+			want, err := pelt.NewPelt(cost.NewCostL2(), tt.minSize, 1).FitPredict(signal, tt.penalty)
+			if err != nil {
+				t.Fatalf("FitPredict failed: %v", err)
+			}
+
+			online := pelt.NewOnline(tt.penalty, tt.minSize)
+			var got []int
+			for _, sample := range signal {
+				confirmed, err := online.Push(sample)
+				if err != nil {
+					t.Fatalf("Push failed: %v", err)
+				}
+				got = append(got, confirmed...)
+			}
+			got = append(got, online.Pending()...)
+			got = append(got, len(signal))
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestOnlineApproximatesPelt(t *testing.T) {
+	// Online poda cada candidato por separado y Pelt solo el prefijo: con MinSize > 1 las
+	// segmentaciones pueden diferir. Tolerancia: con MinSize = 1 coinciden; si no, el costo
+	// penalizado de Online supera al de Pelt en menos de un 1 %, el F1 con margen MinSize
+	// es al menos 0.95 y al menos el 90 % de las señales dan la misma segmentación.
+	const nSignals = 300
+	same := 0
+	for seed := int64(1); seed <= nSignals; seed++ {
+		params := datasets.DefaultParams()
+		params.NSamples, params.NBkps, params.NoiseStd, params.Seed = 600, 4, 2, seed
+		minSize, penalty := 1+int(seed%5), 3+3*float64(seed%4)
+		signal, _, err := datasets.PwConstant(params)
+		if err != nil {
+			t.Fatalf("PwConstant failed: %v", err)
+		}
+		want, err := pelt.NewPelt(cost.NewCostL2(), minSize, 1).FitPredict(signal, penalty)
+		if err != nil {
+			t.Fatalf("FitPredict failed: %v", err)
+		}
+		online := pelt.NewOnline(penalty, minSize)
+		var got []int
+		for _, sample := range signal {
+			confirmed, err := online.Push(sample)
+			if err != nil {
+				t.Fatalf("Push failed: %v", err)
+			}
+			got = append(got, confirmed...)
+		}
+		got = append(got, online.Pending()...)
+		got = append(got, len(signal))
+		if reflect.DeepEqual(got, want) {
+			same++
+			continue
+		}
+		if minSize == 1 {
+			t.Errorf("seed %d: expected %v with min_size 1, got %v", seed, want, got)
+			continue
+		}
+		c := cost.NewCostL2()
+		if err := c.Fit(signal); err != nil {
+			t.Fatalf("Fit failed: %v", err)
+		}
+		penalized := func(bkps []int) float64 {
+			total, start := float64(len(bkps)-1)*penalty, 0
+			for _, end := range bkps {
+				v, err := c.Error(start, end)
+				if err != nil {
+					t.Fatalf("Error failed: %v", err)
+				}
+				total, start = total+v, end
+			}
+			return total
+		}
+		if g, w := penalized(got), penalized(want); g > w*1.01 {
+			t.Errorf("seed %d: penalized cost %g, Pelt %g", seed, g, w)
+		}
+		f1, err := metrics.F1Score(want, got, minSize)
+		if err != nil {
+			t.Fatalf("F1Score failed: %v", err)
+		}
+		if f1 < 0.95 {
+			t.Errorf("seed %d: F1 = %g against Pelt (Pelt %v, Online %v)", seed, f1, want, got)
+		}
+	}
+	if same < nSignals*9/10 {
+		t.Errorf("same segmentation for %d of %d signals, want at least 90 %%", same, nSignals)
+	}
+}
+
+func TestOnlineConfirmsEarly(t *testing.T) {
+	params := datasets.DefaultParams()
+	params.NSamples = 2000
+	params.NFeatures = 3
+	params.NBkps = 4
+	params.NoiseStd = 1
+	params.DeltaMin, params.DeltaMax = 5, 10
+	params.Seed = 5
+	signal, trueBkps, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+
+	online := pelt.NewOnline(30, 5)
+	online.MaxCandidates = 100
+	var got []int
+	for i, sample := range signal {
+		confirmed, err := online.Push(sample)
+		if err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+		for _, c := range confirmed {
+			// Un punto de cambio claro se confirma poco después de ocurrir.
+			if delay := i + 1 - c; delay > 100 {
+				t.Errorf("change point %d confirmed after %d samples", c, delay)
+			}
+		}
+		got = append(got, confirmed...)
+		if online.Candidates() > online.MaxCandidates {
+			t.Fatalf("sample %d: %d candidates, limit %d", i, online.Candidates(), online.MaxCandidates)
+		}
+	}
+	if len(got) < params.NBkps-1 {
+		t.Errorf("expected most change points to be confirmed while streaming, got %v", got)
+	}
+	got = append(got, online.Flush()...)
+	got = append(got, len(signal))
+	f1, err := metrics.F1Score(trueBkps, got, 10)
+	if err != nil {
+		t.Fatalf("F1Score failed: %v", err)
+	}
+	if f1 < 1 {
+		t.Errorf("expected F1 = 1, got %g (true %v, detected %v)", f1, trueBkps, got)
+	}
+	if online.Pending() != nil || online.NSamples() != len(signal) {
+		t.Errorf("expected an empty state after Flush, got pending %v after %d samples", online.Pending(), online.NSamples())
+	}
+}
+
+func TestOnlineErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		online  *pelt.Online
+		samples [][]float64
+	}{
+		{"ZeroPenalty", pelt.NewOnline(0, 2), [][]float64{{1}}},
+		{"ZeroMinSize", pelt.NewOnline(1, 0), [][]float64{{1}}},
+		{"EmptySample", pelt.NewOnline(1, 2), [][]float64{{}}},
+		{"DimensionChange", pelt.NewOnline(1, 2), [][]float64{{1, 2}, {1}}},
+		{"NotFinite", pelt.NewOnline(1, 2), [][]float64{{1}, {math.NaN()}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			for _, s := range tt.samples {
+				if _, err = tt.online.Push(s); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
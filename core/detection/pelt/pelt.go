@@ -1,6 +1,7 @@
 package pelt
 
 import (
+	"log/slog"
 
 	// Asegúrate de importar sort
 
@@ -10,11 +11,13 @@ import (
 )
 
 type Pelt struct {
-	Cost     base.CostFunction // La función de costo (ej. CostRbf, CostL1, CostL2)
-	MinSize  int               // Tamaño mínimo de un segmento
-	Jump     int               // Salto de subsampling (opcional, implementar más tarde si es necesario)
-	nSamples int               // Número de muestras en la señal
-	signal   types.Matrix      // La señal ajustada
+	Cost       base.CostFunction // La función de costo (ej. CostRbf, CostL1, CostL2)
+	MinSize    int               // Tamaño mínimo de un segmento
+	Jump       int               // Salto de subsampling: solo los múltiplos de Jump son puntos de cambio admisibles
+	OnProgress base.ProgressFunc // Si no es nil, recibe el progreso de Predict (ver SetProgressFunc)
+	Logger     *slog.Logger      // Logger estructurado; si es nil se usa logging.Logger() (silencioso por defecto)
+	nSamples   int               // Número de muestras en la señal
+	signal     types.Matrix      // La señal ajustada
 }
 
 // NewPelt crea una nueva instancia de Pelt.
@@ -35,3 +38,20 @@ func (p *Pelt) Fit(signal types.Matrix) error {
 	p.nSamples = len(signal)
 	return p.Cost.Fit(signal) // Asegura que la función de costo se ajuste a la señal
 }
+
+// FitPredict ajusta el detector a la señal y predice los puntos de cambio
+// con la penalización dada. Junto con Fit y Predict, hace que Pelt
+// implemente base.Estimator.
+func (p *Pelt) FitPredict(signal types.Matrix, penalty float64) ([]int, error) {
+	if err := p.Fit(signal); err != nil {
+		return nil, err
+	}
+	return p.Predict(penalty)
+}
+
+// admissible indica si t puede ser un punto de cambio: con Jump > 1 solo lo son los
+// múltiplos de Jump, como en ruptures, además del final de la señal. Los costos
+// óptimos de los demás índices quedan en +Inf y nunca se eligen como candidatos.
+func (p *Pelt) admissible(t int) bool {
+	return t%p.Jump == 0 || t == p.nSamples
+}
@@ -1,11 +1,19 @@
 package pelt_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
 
 	"github.com/theDataFlowClub/ruptures/core/cost"           // Para CostRbf
+	"github.com/theDataFlowClub/ruptures/core/datasets"       // Señales sintéticas con puntos de cambio conocidos
 	"github.com/theDataFlowClub/ruptures/core/detection/pelt" // Tu implementación de PELT
 	"github.com/theDataFlowClub/ruptures/core/types"          // Para types.Matrix
 )
@@ -142,3 +150,363 @@ func TestPeltBasicDetection(t *testing.T) {
 
 	// Puedes añadir más tests aquí para diferentes dimensiones, min_size, etc.
 }
+
+func TestPeltL2OnPiecewiseConstant(t *testing.T) {
+	// Señal sintética sin ruido: PELT con costo L2 debe recuperar los puntos de cambio exactos.
+	params := datasets.DefaultParams()
+	params.Seed = 3
+	signal, trueBkps, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+
+	p := pelt.NewPelt(cost.NewCostL2(), 2, 1)
+	bkps, err := p.FitPredict(signal, 1.0)
+	if err != nil {
+		t.Fatalf("FitPredict failed: %v", err)
+	}
+	if !reflect.DeepEqual(bkps, trueBkps) {
+		t.Errorf("expected %v, got %v", trueBkps, bkps)
+	}
+}
+
+func TestPeltPredictContext(t *testing.T) {
+	params := datasets.DefaultParams()
+	params.NoiseStd = 1
+	signal, _, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+
+	// CostEntropy requiere valores discretos en [0, 255).
+	discrete := make(types.Matrix, len(signal))
+	for i := range discrete {
+		discrete[i] = []float64{float64(i % 7)}
+	}
+
+	// Con un contexto ya cancelado, cada implementación debe devolver ctx.Err().
+	tests := []struct {
+		cost   base.CostFunction
+		signal types.Matrix
+	}{
+		{cost.NewCostL1(), signal},
+		{cost.NewCostL2(), signal},
+		{cost.NewCostRbf(nil), signal},
+		{cost.NewCostEntropy(), discrete},
+	}
+	for _, tt := range tests {
+		t.Run("Cancelled/"+tt.cost.Model(), func(t *testing.T) {
+			p := pelt.NewPelt(tt.cost, 2, 1)
+			if err := p.Fit(tt.signal); err != nil {
+				t.Fatalf("Fit failed: %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if _, err := p.PredictContext(ctx, 1.0); !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+		})
+	}
+
+	// Una ejecución RBF larga debe abortar poco después de que expire el plazo.
+	t.Run("DeadlineDuringRbf", func(t *testing.T) {
+		params.NSamples = 2000
+		long, _, err := datasets.PwConstant(params)
+		if err != nil {
+			t.Fatalf("PwConstant failed: %v", err)
+		}
+		p := pelt.NewPelt(cost.NewCostRbf(nil), 2, 1)
+		if err := p.Fit(long); err != nil {
+			t.Fatalf("Fit failed: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if _, err := p.PredictContext(ctx, 1e6); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("PredictContext returned %v after the deadline", elapsed)
+		}
+	})
+}
+
+func TestPeltProgress(t *testing.T) {
+	params := datasets.DefaultParams()
+	params.NSamples = 1000
+	params.NoiseStd = 1
+	signal, _, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+
+	var updates []base.Progress
+	p := pelt.NewPelt(cost.NewCostL2(), 2, 1)
+	p.SetProgressFunc(func(pr base.Progress) { updates = append(updates, pr) })
+	if _, err := p.FitPredict(signal, 10.0); err != nil {
+		t.Fatalf("FitPredict failed: %v", err)
+	}
+
+	// Alrededor de cien notificaciones, crecientes, y la última al final de la señal.
+	if len(updates) == 0 || len(updates) > 101 {
+		t.Fatalf("expected between 1 and 101 progress updates, got %d", len(updates))
+	}
+	for i := 1; i < len(updates); i++ {
+		if updates[i].Processed <= updates[i-1].Processed {
+			t.Errorf("progress not increasing: %d after %d", updates[i].Processed, updates[i-1].Processed)
+		}
+	}
+	last := updates[len(updates)-1]
+	if last.Processed != last.Total || last.Total != params.NSamples || last.Fraction() != 1 {
+		t.Errorf("last update = %+v, want Processed = Total = %d", last, params.NSamples)
+	}
+	if last.Candidates < 1 || last.Candidates > params.NSamples {
+		t.Errorf("last update has %d candidates, want between 1 and %d", last.Candidates, params.NSamples)
+	}
+}
+
+func TestPeltCrops(t *testing.T) {
+	params := datasets.DefaultParams()
+	params.NSamples = 300
+	params.NoiseStd = 1
+	params.Seed = 5
+	signal, trueBkps, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	l2 := cost.NewCostL2()
+	p := pelt.NewPelt(l2, 2, 1)
+	if err := p.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+
+	penMin, penMax := 0.5, 1e5
+	segs, err := p.Crops(penMin, penMax)
+	if err != nil {
+		t.Fatalf("Crops failed: %v", err)
+	}
+	if len(segs) < 3 {
+		t.Fatalf("expected several segmentations, got %d", len(segs))
+	}
+	if segs[0].PenaltyMin != penMin || segs[len(segs)-1].PenaltyMax != penMax {
+		t.Errorf("intervals do not cover [%g, %g]: first %+v, last %+v", penMin, penMax, segs[0], segs[len(segs)-1])
+	}
+
+	foundTruth := false
+	for i, seg := range segs {
+		if i > 0 {
+			if seg.NBkps >= segs[i-1].NBkps {
+				t.Errorf("segmentation %d has %d change points, previous one %d", i, seg.NBkps, segs[i-1].NBkps)
+			}
+			if math.Abs(seg.PenaltyMin-segs[i-1].PenaltyMax) > 1e-9 {
+				t.Errorf("gap between intervals %d and %d: %g != %g", i-1, i, segs[i-1].PenaltyMax, seg.PenaltyMin)
+			}
+		}
+		if seg.PenaltyMin > seg.PenaltyMax {
+			t.Errorf("segmentation %d has an empty interval [%g, %g]", i, seg.PenaltyMin, seg.PenaltyMax)
+		}
+
+		// El costo sin penalizar coincide con el de la función de costo.
+		want, err := base.SumOfCosts(l2, seg.Breakpoints)
+		if err != nil {
+			t.Fatalf("SumOfCosts failed: %v", err)
+		}
+		if math.Abs(seg.Cost-want) > 1e-6*math.Max(1, want) {
+			t.Errorf("segmentation %d: cost %g, SumOfCosts %g", i, seg.Cost, want)
+		}
+
+		// PELT en el centro del intervalo devuelve la misma segmentación.
+		mid := (seg.PenaltyMin + seg.PenaltyMax) / 2
+		bkps, err := p.Predict(mid)
+		if err != nil {
+			t.Fatalf("Predict(%g) failed: %v", mid, err)
+		}
+		if !reflect.DeepEqual(bkps, seg.Breakpoints) {
+			t.Errorf("Predict(%g) = %v, CROPS segmentation %v", mid, bkps, seg.Breakpoints)
+		}
+		if seg.NBkps == len(trueBkps)-1 {
+			foundTruth = true
+			for j, b := range seg.Breakpoints {
+				if math.Abs(float64(b-trueBkps[j])) > 5 {
+					t.Errorf("segmentation with %d change points %v is far from the truth %v", seg.NBkps, seg.Breakpoints, trueBkps)
+					break
+				}
+			}
+		}
+	}
+	if !foundTruth {
+		t.Errorf("no CROPS segmentation has the true number of change points (%d)", len(trueBkps)-1)
+	}
+
+	t.Run("InvalidRange", func(t *testing.T) {
+		if _, err := p.Crops(10, 1); err == nil {
+			t.Error("expected an error for penMin > penMax")
+		}
+		if _, err := p.Crops(0, 1); err == nil {
+			t.Error("expected an error for a non-positive penMin")
+		}
+	})
+}
+
+func TestPeltPredictResult(t *testing.T) {
+	// El costo penalizado de PELT debe coincidir con la suma de los costos de los
+	// segmentos más la penalización por cada punto de cambio, también el acumulado por el
+	// bucle optimizado (que CROPS usa como costo), con y sin matriz de Gram en caché.
+	params := datasets.DefaultParams()
+	params.NoiseStd = 1
+	signal, _, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	gamma := 0.5
+	tests := []struct {
+		name    string
+		cost    base.CostFunction
+		penalty float64
+	}{
+		{name: "L2", cost: cost.NewCostL2(), penalty: 20},
+		{name: "Rbf", cost: cost.NewCostRbf(nil), penalty: 10},
+		{name: "RbfGamma", cost: cost.NewCostRbf(&gamma), penalty: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := pelt.NewPelt(tt.cost, 2, 1)
+			if err := p.Fit(signal); err != nil {
+				t.Fatalf("Fit failed: %v", err)
+			}
+			res, err := p.PredictResult(tt.penalty)
+			if err != nil {
+				t.Fatalf("PredictResult failed: %v", err)
+			}
+			bkps, err := p.Predict(tt.penalty)
+			if err != nil {
+				t.Fatalf("Predict failed: %v", err)
+			}
+			if !reflect.DeepEqual(res.Breakpoints, bkps) || len(res.Segments) != len(bkps) {
+				t.Fatalf("result %v with %d segments, want breakpoints %v", res.Breakpoints, len(res.Segments), bkps)
+			}
+			want := res.Cost + tt.penalty*float64(res.NBkps())
+			if math.Abs(res.PenalizedCost-want) > 1e-6*math.Max(1, want) {
+				t.Errorf("PenalizedCost = %g, want %g", res.PenalizedCost, want)
+			}
+			segs, err := p.Crops(tt.penalty, tt.penalty)
+			if err != nil {
+				t.Fatalf("Crops failed: %v", err)
+			}
+			if math.Abs(segs[0].Cost-res.Cost) > 1e-6*math.Max(1, res.Cost) {
+				t.Errorf("cost of the PELT loop = %g, cost function %g", segs[0].Cost, res.Cost)
+			}
+			var _ base.ResultEstimator = p
+		})
+	}
+}
+
+func TestPeltRbfShortFirstSegment(t *testing.T) {
+	// Regresión: los finales t < 2·MinSize del bucle inicial de rbf partían de 0 en vez
+	// de M_V[0] = -penalty, de modo que un primer segmento más corto que 2·MinSize pagaba
+	// una penalización de más y el primer punto de cambio se desplazaba.
+	const minSize = 5
+	signal := make(types.Matrix, 0, 47)
+	for i := 0; i < 47; i++ {
+		level := 0.0
+		if i >= 7 {
+			level = 5
+		}
+		signal = append(signal, []float64{level})
+	}
+	gamma := 1.0
+	p := pelt.NewPelt(cost.NewCostRbf(&gamma), minSize, 1)
+	got, err := p.FitPredict(signal, 10)
+	if err != nil {
+		t.Fatalf("FitPredict failed: %v", err)
+	}
+	if want := []int{7, 47}; !reflect.DeepEqual(got, want) {
+		t.Errorf("breakpoints = %v, want %v", got, want)
+	}
+	if got[0] < minSize || got[0] >= 2*minSize {
+		t.Errorf("first breakpoint %d is not in [%d, %d)", got[0], minSize, 2*minSize)
+	}
+}
+
+// optimalPartition resuelve por fuerza bruta (O(n²)) la segmentación penalizada
+// óptima restringida a los puntos de cambio múltiplos de jump, y devuelve su costo.
+func optimalPartition(c base.CostFunction, n, minSize, jump int, penalty float64) float64 {
+	best := make([]float64, n+1)
+	for t := 1; t <= n; t++ {
+		best[t] = math.Inf(1)
+		if t%jump != 0 && t != n {
+			continue
+		}
+		for s := 0; s <= t-minSize; s += jump {
+			if math.IsInf(best[s], 1) && s > 0 {
+				continue
+			}
+			segmentCost, err := c.Error(s, t)
+			if err != nil {
+				continue
+			}
+			if v := best[s] + segmentCost + penalty; v < best[t] {
+				best[t] = v
+			}
+		}
+	}
+	return best[n] - penalty
+}
+
+func TestPeltJump(t *testing.T) {
+	// Con Jump > 1 los puntos de cambio son múltiplos de Jump y el costo penalizado
+	// coincide con el óptimo restringido a esa grilla.
+	params := datasets.DefaultParams()
+	params.NSamples = 97
+	params.NoiseStd = 1
+	params.Seed = 5
+	signal, _, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	// Señal discreta no negativa para el costo de entropía.
+	discrete := make(types.Matrix, len(signal))
+	for i, row := range signal {
+		discrete[i] = []float64{math.Abs(math.Round(row[0]))}
+	}
+	gamma := 0.5
+	tests := []struct {
+		name    string
+		cost    base.CostFunction
+		signal  types.Matrix
+		penalty float64
+	}{
+		{name: "L2", cost: cost.NewCostL2(), signal: signal, penalty: 10},
+		{name: "L1", cost: cost.NewCostL1(), signal: signal, penalty: 5},
+		{name: "Rbf", cost: cost.NewCostRbf(&gamma), signal: signal, penalty: 1},
+		{name: "Entropy", cost: cost.NewCostEntropy(), signal: discrete, penalty: 5},
+	}
+	for _, tt := range tests {
+		for _, jump := range []int{1, 4, 7} {
+			t.Run(fmt.Sprintf("%s/Jump%d", tt.name, jump), func(t *testing.T) {
+				p := pelt.NewPelt(tt.cost, 3, jump)
+				if err := p.Fit(tt.signal); err != nil {
+					t.Fatalf("Fit failed: %v", err)
+				}
+				res, err := p.PredictResult(tt.penalty)
+				if err != nil {
+					t.Fatalf("PredictResult failed: %v", err)
+				}
+				n := len(tt.signal)
+				for _, b := range res.Breakpoints {
+					if b%jump != 0 && b != n {
+						t.Errorf("breakpoint %d is not admissible in %v", b, res.Breakpoints)
+					}
+				}
+				got := res.PenalizedCost
+				want := optimalPartition(tt.cost, n, 3, jump, tt.penalty)
+				if math.Abs(got-want) > 1e-6*math.Max(1, math.Abs(want)) {
+					t.Errorf("penalized cost = %g, want %g (breakpoints %v)", got, want, res.Breakpoints)
+				}
+			})
+		}
+	}
+	if _, err := pelt.NewPelt(cost.NewCostL2(), 2, 0).FitPredict(signal, 10); err == nil {
+		t.Error("expected an error for jump 0")
+	}
+}
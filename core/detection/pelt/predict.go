@@ -1,45 +1,107 @@
 package pelt
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/theDataFlowClub/ruptures/core/base"
 	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/logging"
 )
 
 // Predict es la función principal que selecciona la implementación optimizada
-// basada en el tipo de CostFunction.
+// basada en el tipo de CostFunction. Equivale a PredictContext con context.Background().
 func (p *Pelt) Predict(penalty float64) ([]int, error) {
+	return p.PredictContext(context.Background(), penalty)
+}
+
+// PredictContext es como Predict, pero verifica ctx en cada iteración del bucle
+// principal de PELT y devuelve ctx.Err() en cuanto el contexto se cancela o expira.
+func (p *Pelt) PredictContext(ctx context.Context, penalty float64) ([]int, error) {
+	bkps, _, err := p.predictWithCost(ctx, penalty)
+	return bkps, err
+}
+
+// predictWithCost valida los parámetros y ejecuta PELT. Además de los puntos de
+// cambio devuelve el costo penalizado óptimo: la suma de los costos de los
+// segmentos más penalty por cada punto de cambio.
+func (p *Pelt) predictWithCost(ctx context.Context, penalty float64) ([]int, float64, error) {
 	// Validaciones generales antes de cualquier implementación específica
 	if p.signal == nil || p.nSamples == 0 {
-		return nil, errors.New("Pelt: detector not fitted. Call Fit() first.")
+		return nil, 0, errors.New("Pelt: detector not fitted. Call Fit() first.")
 	}
 	if penalty <= 0 {
-		return nil, errors.New("Pelt: penalty must be greater than 0.")
+		return nil, 0, errors.New("Pelt: penalty must be greater than 0.")
 	}
 	if p.MinSize < 1 {
-		return nil, errors.New("Pelt: min_size must be at least 1.")
+		return nil, 0, errors.New("Pelt: min_size must be at least 1.")
 	}
+	if p.Jump < 1 {
+		return nil, 0, errors.New("Pelt: jump must be at least 1.")
+	}
+
+	logger := p.logger().With(
+		logging.KeyDetector, "pelt",
+		logging.KeyCost, p.Cost.Model(),
+		logging.KeyNSamples, p.nSamples,
+		logging.KeyPenalty, penalty,
+	)
+	logger.Debug("pelt: predict started", "min_size", p.MinSize, "jump", p.Jump)
+	startTime := time.Now()
+	bkps, totalCost, err := p.predict(ctx, penalty)
+	if err != nil {
+		logger.Debug("pelt: predict failed", logging.KeyDuration, time.Since(startTime), "error", err)
+		return nil, 0, err
+	}
+	logger.Debug("pelt: predict finished", logging.KeyNBkps, len(bkps)-1, logging.KeyDuration, time.Since(startTime))
+	return bkps, totalCost, nil
+}
 
-	// Selecciona la función de predicción optimizada basada en el tipo de CostFunction
+// predict selecciona la función de predicción optimizada basada en el tipo de CostFunction.
+func (p *Pelt) predict(ctx context.Context, penalty float64) ([]int, float64, error) {
 	switch concreteCost := p.Cost.(type) {
 	case *cost.CostRbf:
-		fmt.Println("Pelt: Usando implementación optimizada para CostRbf.")
-		return p.predictRbfOptimized(concreteCost, penalty)
+		return p.predictRbfOptimized(ctx, concreteCost, penalty)
 	case *cost.CostL1:
-		fmt.Println("Pelt: Usando implementación optimizada para CostL1 (por implementar).")
-		return p.predictL1Optimized(concreteCost, penalty) // Llama a la función específica de L1
+		return p.predictL1Optimized(ctx, concreteCost, penalty) // Llama a la función específica de L1
 	case *cost.CostL2:
-		fmt.Println("Pelt: Usando implementación optimizada para CostL2 (por implementar).")
-		return p.predictL2Optimized(concreteCost, penalty) // Llama a la función específica de L2
-	case *cost.CostEntropy: // ¡NUEVO CASO!
-		fmt.Println("Pelt: Usando implementación genérica para CostEntropy.")
-		// Para Entropy, inicialmente usas la función genérica,
-		// ya que la optimización O(1) con prefix histograms es más compleja.
-		// podrías renombrar 'predictGeneric' a 'predictBasic' o similar si te gusta.
-		return p.predictEntropyOptimized(concreteCost, penalty) // Necesitarás implementar predictGeneric si aún no lo tienes.
+		return p.predictL2Optimized(ctx, concreteCost, penalty) // Llama a la función específica de L2
+	case *cost.CostEntropy:
+		// Para Entropy, el costo de cada segmento es O(AlphabetSize) con histogramas de prefijo.
+		return p.predictEntropyOptimized(ctx, concreteCost, penalty)
 	default:
 		// En caso de que se pase una función de costo no reconocida o no optimizada
-		return nil, fmt.Errorf("Pelt: la función de costo '%s' no tiene una implementación Predict optimizada. Considera añadirla o usar una función genérica.", p.Cost.Model())
+		return nil, 0, fmt.Errorf("Pelt: la función de costo '%s' no tiene una implementación Predict optimizada. Considera añadirla o usar una función genérica.", p.Cost.Model())
+	}
+}
+
+// logger devuelve el logger del detector, o el de la librería (silencioso por defecto)
+// si no se asignó ninguno.
+func (p *Pelt) logger() *slog.Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+	return logging.Logger()
+}
+
+// PredictResult devuelve la segmentación óptima para la penalización como un
+// base.Result, con las estadísticas y el costo de cada segmento. Equivale a
+// PredictResultContext con context.Background().
+func (p *Pelt) PredictResult(penalty float64) (base.Result, error) {
+	return p.PredictResultContext(context.Background(), penalty)
+}
+
+// PredictResultContext es como PredictResult, pero aborta con ctx.Err() en cuanto el
+// contexto se cancela o expira. Los costos de los segmentos y el costo penalizado se
+// evalúan con la función de costo ya ajustada (base.NewResult), como para los demás
+// detectores. Junto con Predict, hace que Pelt implemente base.ResultEstimator.
+func (p *Pelt) PredictResultContext(ctx context.Context, penalty float64) (base.Result, error) {
+	bkps, err := p.PredictContext(ctx, penalty)
+	if err != nil {
+		return base.Result{}, err
 	}
+	return base.NewResult(p.signal, p.Cost, bkps, penalty)
 }
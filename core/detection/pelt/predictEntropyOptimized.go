@@ -1,6 +1,7 @@
 package pelt
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -14,10 +15,10 @@ import (
 
 // predictEntropyOptimized es una implementación optimizada del algoritmo PELT para costo de Entropía.
 // Requiere que CostEntropy precalcule histogramas de prefijo para un cálculo de costo O(AlphabetSize).
-func (p *Pelt) predictEntropyOptimized(entropyCost *cost.CostEntropy, penalty float64) ([]int, error) {
+func (p *Pelt) predictEntropyOptimized(ctx context.Context, entropyCost *cost.CostEntropy, penalty float64) ([]int, float64, error) {
 	if len(p.signal) == 0 || len(p.signal[0]) != 1 {
 		// Aseguramos que la señal sea univariada (una dimensión)
-		return nil, errors.New("Entropy optimized PELT requires univariate signal")
+		return nil, 0, errors.New("Entropy optimized PELT requires univariate signal")
 	}
 
 	// numSamples: Número total de muestras en la señal.
@@ -42,8 +43,18 @@ func (p *Pelt) predictEntropyOptimized(entropyCost *cost.CostEntropy, penalty fl
 	// firstValidCandidate: El índice del primer punto de cambio potencial que no ha sido podado.
 	firstValidCandidate := 0
 
+	progress := p.newProgressReporter()
+
 	// Bucle principal de PELT: itera a través de los posibles puntos finales 'currentEnd' de los segmentos.
 	for currentEnd := p.MinSize; currentEnd <= numSamples; currentEnd++ {
+		// Permite abortar la búsqueda si el contexto fue cancelado o expiró.
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		// Con Jump > 1 solo se evalúan los finales admisibles.
+		if !p.admissible(currentEnd) {
+			continue
+		}
 		minCostsToEnd[currentEnd] = math.Inf(1) // Inicializa el costo mínimo para el `currentEnd`
 
 		// Evaluar el primer candidato no podado.
@@ -54,7 +65,7 @@ func (p *Pelt) predictEntropyOptimized(entropyCost *cost.CostEntropy, penalty fl
 			// Aquí es crucial que `entropyCost.Error()` sea eficiente (O(AlphabetSize) o O(1)).
 			segmentCost, err := entropyCost.Error(prevBreakpoint, currentEnd)
 			if err != nil {
-				return nil, fmt.Errorf("Pelt (Entropy): error calculating segment cost for [%d, %d): %w", prevBreakpoint, currentEnd, err)
+				return nil, 0, fmt.Errorf("Pelt (Entropy): error calculating segment cost for [%d, %d): %w", prevBreakpoint, currentEnd, err)
 			}
 
 			// Actualiza el valor de poda para el 'prevBreakpoint'.
@@ -72,10 +83,13 @@ func (p *Pelt) predictEntropyOptimized(entropyCost *cost.CostEntropy, penalty fl
 		// Recorre desde el siguiente candidato válido hasta el último punto de inicio posible
 		// para un segmento de longitud `MinSize` que termina en `currentEnd`.
 		for prevBreakpoint := firstValidCandidate + 1; prevBreakpoint <= currentEnd-p.MinSize; prevBreakpoint++ {
+			if !p.admissible(prevBreakpoint) {
+				continue
+			}
 			// Calcula el costo de entropía para el segmento [prevBreakpoint, currentEnd).
 			segmentCost, err := entropyCost.Error(prevBreakpoint, currentEnd)
 			if err != nil {
-				return nil, fmt.Errorf("Pelt (Entropy): error calculating segment cost for [%d, %d): %w", prevBreakpoint, currentEnd, err)
+				return nil, 0, fmt.Errorf("Pelt (Entropy): error calculating segment cost for [%d, %d): %w", prevBreakpoint, currentEnd, err)
 			}
 
 			// Actualiza el valor de poda para el 'prevBreakpoint'.
@@ -103,6 +117,7 @@ func (p *Pelt) predictEntropyOptimized(entropyCost *cost.CostEntropy, penalty fl
 				firstValidCandidate++
 			}
 		}
+		progress.report(currentEnd, currentEnd-p.MinSize-firstValidCandidate+1)
 	}
 
 	// --- Reconstrucción de los puntos de cambio ---
@@ -118,5 +133,5 @@ func (p *Pelt) predictEntropyOptimized(entropyCost *cost.CostEntropy, penalty fl
 	}
 	sort.Ints(changePoints) // Ordena los puntos de cambio de forma ascendente
 
-	return changePoints, nil
+	return changePoints, minCostsToEnd[numSamples], nil
 }
@@ -1,6 +1,7 @@
 package pelt
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -11,10 +12,10 @@ import (
 
 // predictL1Optimized es una implementación pragmática del algoritmo PELT para costo L1.
 // Funciona para señales univariadas, calculando la mediana de cada segmento.
-func (p *Pelt) predictL1Optimized(l1Cost *cost.CostL1, penalty float64) ([]int, error) {
+func (p *Pelt) predictL1Optimized(ctx context.Context, l1Cost *cost.CostL1, penalty float64) ([]int, float64, error) {
 	if len(p.signal) == 0 || len(p.signal[0]) != 1 {
 		// Aseguramos que la señal sea univariada (una dimensión)
-		return nil, errors.New("L1 optimized PELT requires univariate signal")
+		return nil, 0, errors.New("L1 optimized PELT requires univariate signal")
 	}
 
 	// Extraemos la señal univariada para facilitar el acceso
@@ -43,8 +44,18 @@ func (p *Pelt) predictL1Optimized(l1Cost *cost.CostL1, penalty float64) ([]int,
 	// s_min: El primer índice candidato que no ha sido podado.
 	firstValidCandidate := 0
 
+	progress := p.newProgressReporter()
+
 	// Bucle principal de PELT: itera a través de los posibles puntos finales 't' de los segmentos.
 	for currentEnd := p.MinSize; currentEnd <= numSamples; currentEnd++ {
+		// Permite abortar la búsqueda si el contexto fue cancelado o expiró.
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		// Con Jump > 1 solo se evalúan los finales admisibles.
+		if !p.admissible(currentEnd) {
+			continue
+		}
 		minCostsToEnd[currentEnd] = math.Inf(1) // Inicializamos el costo mínimo para el `currentEnd`
 
 		// Evaluar el primer candidato no podado
@@ -54,7 +65,7 @@ func (p *Pelt) predictL1Optimized(l1Cost *cost.CostL1, penalty float64) ([]int,
 			segmentCost, err := l1Cost.Error(prevBreakpoint, currentEnd)
 			if err != nil {
 				// Manejo de errores si l1Cost.Error falla (aunque con l1SegmentCost no debería)
-				return nil, fmt.Errorf("Pelt (L1): error calculating segment cost for [%d, %d): %w", prevBreakpoint, currentEnd, err)
+				return nil, 0, fmt.Errorf("Pelt (L1): error calculating segment cost for [%d, %d): %w", prevBreakpoint, currentEnd, err)
 			}
 
 			// Actualiza el valor de poda para el 'prevBreakpoint'
@@ -72,11 +83,14 @@ func (p *Pelt) predictL1Optimized(l1Cost *cost.CostL1, penalty float64) ([]int,
 		// Recorre desde el siguiente candidato válido hasta el último punto de inicio posible
 		// para un segmento de longitud `MinSize` que termina en `currentEnd`.
 		for prevBreakpoint := firstValidCandidate + 1; prevBreakpoint <= currentEnd-p.MinSize; prevBreakpoint++ {
+			if !p.admissible(prevBreakpoint) {
+				continue
+			}
 			// Calcula el costo L1 para el segmento [prevBreakpoint, currentEnd)
 			segmentCost, err := l1Cost.Error(prevBreakpoint, currentEnd)
 			if err != nil {
 				// Manejo de errores
-				return nil, fmt.Errorf("Pelt (L1): error calculating segment cost for [%d, %d): %w", prevBreakpoint, currentEnd, err)
+				return nil, 0, fmt.Errorf("Pelt (L1): error calculating segment cost for [%d, %d): %w", prevBreakpoint, currentEnd, err)
 			}
 
 			// Actualiza el valor de poda para el 'prevBreakpoint'
@@ -103,6 +117,7 @@ func (p *Pelt) predictL1Optimized(l1Cost *cost.CostL1, penalty float64) ([]int,
 				firstValidCandidate++
 			}
 		}
+		progress.report(currentEnd, currentEnd-p.MinSize-firstValidCandidate+1)
 	}
 
 	// --- Reconstrucción de los puntos de cambio ---
@@ -117,7 +132,7 @@ func (p *Pelt) predictL1Optimized(l1Cost *cost.CostL1, penalty float64) ([]int,
 	}
 	sort.Ints(changePoints) // Ordena los puntos de cambio de forma ascendente
 
-	return changePoints, nil
+	return changePoints, minCostsToEnd[numSamples], nil
 }
 
 // l1SegmentCost calcula el costo L1 para un segmento univariado [startIdx, endIdx).
@@ -1,6 +1,7 @@
 package pelt
 
 import (
+	"context"
 	"errors"
 	"math"
 	"sort"
@@ -14,10 +15,10 @@ import (
 //
 // predictL2Optimized es una implementación optimizada del algoritmo PELT para costo L2.
 // Utiliza sumas acumuladas para calcular el costo de segmento en O(1), optimizado para señales univariadas.
-func (p *Pelt) predictL2Optimized(l2Cost *cost.CostL2, penalty float64) ([]int, error) {
+func (p *Pelt) predictL2Optimized(ctx context.Context, l2Cost *cost.CostL2, penalty float64) ([]int, float64, error) {
 	if len(p.signal) == 0 || len(p.signal[0]) != 1 {
 		// Aseguramos que la señal sea univariada (una dimensión)
-		return nil, errors.New("L2 optimized PELT requires univariate signal")
+		return nil, 0, errors.New("L2 optimized PELT requires univariate signal")
 	}
 
 	numSamples := p.nSamples
@@ -58,8 +59,18 @@ func (p *Pelt) predictL2Optimized(l2Cost *cost.CostL2, penalty float64) ([]int,
 	// firstValidCandidate: El índice del primer punto de cambio potencial que no ha sido podado.
 	firstValidCandidate := 0
 
+	progress := p.newProgressReporter()
+
 	// Bucle principal de PELT: itera a través de los posibles puntos finales 'currentEnd' de los segmentos.
 	for currentEnd := p.MinSize; currentEnd <= numSamples; currentEnd++ {
+		// Permite abortar la búsqueda si el contexto fue cancelado o expiró.
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		// Con Jump > 1 solo se evalúan los finales admisibles.
+		if !p.admissible(currentEnd) {
+			continue
+		}
 		minCostsToEnd[currentEnd] = math.Inf(1) // Inicializa el costo mínimo para el `currentEnd`
 
 		// Evaluar el primer candidato no podado.
@@ -88,6 +99,9 @@ func (p *Pelt) predictL2Optimized(l2Cost *cost.CostL2, penalty float64) ([]int,
 		// Recorre desde el siguiente candidato válido hasta el último punto de inicio posible
 		// para un segmento de longitud `MinSize` que termina en `currentEnd`.
 		for prevBreakpoint := firstValidCandidate + 1; prevBreakpoint <= currentEnd-p.MinSize; prevBreakpoint++ {
+			if !p.admissible(prevBreakpoint) {
+				continue
+			}
 			// Calcula el costo L2 para el segmento [prevBreakpoint, currentEnd)
 			segmentCost := calculateL2SegmentCostFromPrefixSums(
 				prefixSums,
@@ -120,6 +134,7 @@ func (p *Pelt) predictL2Optimized(l2Cost *cost.CostL2, penalty float64) ([]int,
 				firstValidCandidate++
 			}
 		}
+		progress.report(currentEnd, currentEnd-p.MinSize-firstValidCandidate+1)
 	}
 
 	// --- Reconstrucción de los puntos de cambio ---
@@ -134,7 +149,7 @@ func (p *Pelt) predictL2Optimized(l2Cost *cost.CostL2, penalty float64) ([]int,
 	}
 	sort.Ints(changePoints) // Ordena los puntos de cambio de forma ascendente
 
-	return changePoints, nil
+	return changePoints, minCostsToEnd[numSamples], nil
 }
 
 // calculateL2SegmentCostFromPrefixSums calcula el costo L2 para un segmento [startIdx, endIdx)
@@ -154,8 +169,13 @@ func calculateL2SegmentCostFromPrefixSums(
 	// Suma de los cuadrados de los valores en el segmento [startIdx, endIdx)
 	sumSquares := prefixSquares[endIdx] - prefixSquares[startIdx]
 
+	return l2CostFromSums(sumValues, sumSquares, segmentLength)
+}
+
+// l2CostFromSums calcula el costo L2 de un segmento a partir de la suma de sus valores,
+// la suma de sus cuadrados y su longitud. Lo comparten PELT y el detector en línea (Online).
+func l2CostFromSums(sumValues, sumSquares, segmentLength float64) float64 {
 	// Fórmula del costo L2 (varianza dentro del segmento, multiplicada por la longitud para ser consistente)
 	// Costo = Sum(y_i^2) - (Sum(y_i))^2 / N
-	cost := sumSquares - (sumValues*sumValues)/segmentLength
-	return cost
+	return sumSquares - (sumValues*sumValues)/segmentLength
 }
@@ -1,6 +1,7 @@
 package pelt
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sort"
@@ -9,15 +10,10 @@ import (
 )
 
 // predictRbfOptimized es la implementación de PELT optimizada para CostRbf.
-// Esta es tu función `Predict` original, renombrada.
-func (p *Pelt) predictRbfOptimized(rbfCost *cost.CostRbf, penalty float64) ([]int, error) {
-	// --- Obtener el kernel de la CostFunctionRbf ---
-	// Esta sección es específica de RBF y está bien aquí.
-	currentKernel, err := rbfCost.GetKernel()
-	if err != nil {
-		return nil, fmt.Errorf("Pelt (RBF): failed to get kernel from CostRbf: %w", err)
-	}
-	// --- FIN: Obtener el kernel ---
+func (p *Pelt) predictRbfOptimized(ctx context.Context, rbfCost *cost.CostRbf, penalty float64) ([]int, float64, error) {
+	// Los valores del kernel se leen con rbfCost.Kernel, que coincide con la matriz de
+	// Gram de CostRbf (diagonal 1 incluida): el costo penalizado de PELT es así la suma
+	// de los costos de CostRbf.Error más penalty por punto de cambio.
 
 	// Arrays auxiliares (específicos de RBF)
 	M_V := make([]float64, p.nSamples+1)
@@ -43,51 +39,69 @@ func (p *Pelt) predictRbfOptimized(rbfCost *cost.CostRbf, penalty float64) ([]in
 		c_cost, c_cost_sum, c_r float64
 	)
 
+	progress := p.newProgressReporter()
+
 	// Bucle inicial para t < 2 * min_size
 	for t = 1; t < 2*p.MinSize && t <= p.nSamples; t++ {
-		diag_element_val, err := currentKernel.Compute(p.signal[t-1], p.signal[t-1])
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		diag_element_val, err := rbfCost.Kernel(t-1, t-1)
 		if err != nil {
-			return nil, fmt.Errorf("Pelt (RBF): error computing diagonal kernel element at t=%d: %w", t, err)
+			return nil, 0, fmt.Errorf("Pelt (RBF): error computing diagonal kernel element at t=%d: %w", t, err)
 		}
 		D[t] = D[t-1] + diag_element_val
 
 		c_r = 0.0
 		for s = t - 1; s >= 0; s-- {
-			val, err := currentKernel.Compute(p.signal[s], p.signal[t-1])
+			val, err := rbfCost.Kernel(s, t-1)
 			if err != nil {
-				return nil, fmt.Errorf("Pelt (RBF): error computing kernel element for S at s=%d, t-1=%d: %w", s, t-1, err)
+				return nil, 0, fmt.Errorf("Pelt (RBF): error computing kernel element for S at s=%d, t-1=%d: %w", s, t-1, err)
 			}
 			c_r += val
 			S[s] += 2*c_r - diag_element_val
 		}
 
+		if !p.admissible(t) {
+			continue // Con Jump > 1, M_V[t] queda en +Inf.
+		}
 		if t > 0 {
 			c_cost = (D[t] - D[0]) - (S[0] / float64(t))
 		} else {
 			c_cost = 0.0
 		}
-		M_V[t] = c_cost + penalty
+		// Un único segmento [0, t): mismo origen que el bucle principal (M_V[0] = -penalty),
+		// de modo que M_V[t] es siempre la suma de costos más penalty por punto de cambio.
+		M_V[t] = M_V[0] + c_cost + penalty
 		M_path[t] = 0
 	}
 
 	// Bucle de computación principal (PELT)
 	for t = 2 * p.MinSize; t <= p.nSamples; t++ {
-		diag_element_val, err := currentKernel.Compute(p.signal[t-1], p.signal[t-1])
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		diag_element_val, err := rbfCost.Kernel(t-1, t-1)
 		if err != nil {
-			return nil, fmt.Errorf("Pelt (RBF): error computing diagonal kernel element at t=%d in main loop: %w", t, err)
+			return nil, 0, fmt.Errorf("Pelt (RBF): error computing diagonal kernel element at t=%d in main loop: %w", t, err)
 		}
 		D[t] = D[t-1] + diag_element_val
 
 		c_r = 0.0
 		for s = t - 1; s >= s_min; s-- {
-			val, err := currentKernel.Compute(p.signal[s], p.signal[t-1])
+			val, err := rbfCost.Kernel(s, t-1)
 			if err != nil {
-				return nil, fmt.Errorf("Pelt (RBF): error computing kernel element for S in main loop at s=%d, t-1=%d: %w", s, t-1, err)
+				return nil, 0, fmt.Errorf("Pelt (RBF): error computing kernel element for S in main loop at s=%d, t-1=%d: %w", s, t-1, err)
 			}
 			c_r += val
 			S[s] += 2*c_r - diag_element_val
 		}
 
+		// Las sumas D y S se actualizan en cada muestra; con Jump > 1 solo se evalúan
+		// los finales admisibles.
+		if !p.admissible(t) {
+			continue
+		}
 		M_V[t] = math.Inf(1)
 
 		if s_min <= t-p.MinSize {
@@ -102,6 +116,9 @@ func (p *Pelt) predictRbfOptimized(rbfCost *cost.CostRbf, penalty float64) ([]in
 		}
 
 		for s = s_min + 1; s <= t-p.MinSize; s++ {
+			if !p.admissible(s) {
+				continue
+			}
 			segmentLen := float64(t - s)
 			c_cost = (D[t] - D[s]) - (S[s] / segmentLen)
 			c_cost_sum = M_V[s] + c_cost
@@ -122,6 +139,7 @@ func (p *Pelt) predictRbfOptimized(rbfCost *cost.CostRbf, penalty float64) ([]in
 				s_min++
 			}
 		}
+		progress.report(t, t-p.MinSize-s_min+1)
 	}
 
 	// Reconstruir los puntos de cambio
@@ -135,5 +153,5 @@ func (p *Pelt) predictRbfOptimized(rbfCost *cost.CostRbf, penalty float64) ([]in
 	}
 	sort.Ints(resultBkps)
 
-	return resultBkps, nil
+	return resultBkps, M_V[p.nSamples], nil
 }
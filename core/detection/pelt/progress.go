@@ -0,0 +1,49 @@
+package pelt
+
+import (
+	"time"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+)
+
+// progressSteps es el número aproximado de notificaciones de progreso por predicción.
+const progressSteps = 100
+
+// SetProgressFunc registra fn para recibir el progreso de Predict; nil lo desactiva.
+// Con esto Pelt implementa base.ProgressReporter.
+func (p *Pelt) SetProgressFunc(fn base.ProgressFunc) {
+	p.OnProgress = fn
+}
+
+// progressReporter limita la frecuencia con que se llama a OnProgress.
+type progressReporter struct {
+	fn    base.ProgressFunc
+	total int
+	step  int
+	start time.Time
+}
+
+// newProgressReporter prepara el reporte de progreso de una predicción.
+func (p *Pelt) newProgressReporter() *progressReporter {
+	return &progressReporter{
+		fn:    p.OnProgress,
+		total: p.nSamples,
+		step:  max(1, p.nSamples/progressSteps),
+		start: time.Now(),
+	}
+}
+
+// report notifica el progreso tras procesar la muestra 'processed', con 'candidates'
+// candidatos supervivientes a la poda. Solo llama a la función cada 'step' muestras
+// y al final de la señal.
+func (r *progressReporter) report(processed, candidates int) {
+	if r.fn == nil || (processed%r.step != 0 && processed != r.total) {
+		return
+	}
+	r.fn(base.Progress{
+		Processed:  processed,
+		Total:      r.total,
+		Candidates: max(0, candidates),
+		Elapsed:    time.Since(r.start),
+	})
+}
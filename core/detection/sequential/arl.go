@@ -0,0 +1,135 @@
+package sequential
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// ARLOptions configures the Monte Carlo estimation of average run lengths.
+type ARLOptions struct {
+	Runs int // Number of simulated runs; defaults to 200.
+	// MaxLength stops a run without alarm after this many samples, counting it as
+	// MaxLength (which biases the estimate down); defaults to 1e6, or to 20 times the
+	// target ARL in CalibrateThreshold.
+	MaxLength int
+	Seed      int64 // Seed of the random number generator.
+	// Sample draws an in-control sample; defaults to the standard normal distribution,
+	// which matches detectors configured in units of the noise standard deviation.
+	Sample func(rng *rand.Rand) float64
+}
+
+// withDefaults fills the zero fields of o, with maxLength as default MaxLength.
+func (o ARLOptions) withDefaults(maxLength int) ARLOptions {
+	if o.Runs <= 0 {
+		o.Runs = 200
+	}
+	if o.MaxLength <= 0 {
+		o.MaxLength = maxLength
+	}
+	if o.Sample == nil {
+		o.Sample = func(rng *rand.Rand) float64 { return rng.NormFloat64() }
+	}
+	return o
+}
+
+// AverageRunLength estimates by simulation the in-control average run length of the
+// detector: the mean number of samples until the first (false) alarm when no change
+// occurs. The detector is reset before every run.
+func AverageRunLength(d Detector, opts ARLOptions) float64 {
+	opts = opts.withDefaults(1_000_000)
+	rng := rand.New(rand.NewSource(opts.Seed))
+	total := 0
+	for run := 0; run < opts.Runs; run++ {
+		d.Reset()
+		length := 1
+		for ; length < opts.MaxLength; length++ {
+			if _, alarm := d.Update(opts.Sample(rng)); alarm {
+				break
+			}
+		}
+		total += length
+	}
+	d.Reset()
+	return float64(total) / float64(opts.Runs)
+}
+
+// CalibrateThreshold returns the threshold for which the in-control average run
+// length of the detector built by newDetector is targetARL, by bisection on
+// AverageRunLength. Every evaluation reuses the same random samples, so that the
+// estimated ARL increases with the threshold.
+//
+// Parameters:
+//
+//	newDetector: Builds the detector with the given threshold and fixed other parameters.
+//	targetARL:   The desired mean number of samples between false alarms; must exceed 1.
+//	opts:        The simulation settings.
+//
+// Returns:
+//
+//	float64: The threshold, within 0.1% of the exact value of the simulation.
+//	error:   An error when targetARL is invalid or cannot be reached.
+func CalibrateThreshold(newDetector func(threshold float64) Detector, targetARL float64, opts ARLOptions) (float64, error) {
+	if !(targetARL > 1) || math.IsInf(targetARL, 0) {
+		return 0, fmt.Errorf("sequential: target ARL must be greater than 1, got %g", targetARL)
+	}
+	opts = opts.withDefaults(int(math.Min(20*targetARL, 1e8)))
+	arl := func(threshold float64) float64 { return AverageRunLength(newDetector(threshold), opts) }
+
+	lo, hi := 0.0, 1.0
+	for arl(hi) < targetARL {
+		lo, hi = hi, 2*hi
+		if hi > 1e12 {
+			return 0, errors.New("sequential: the target ARL is not reached for any threshold")
+		}
+	}
+	for hi-lo > 1e-3*hi {
+		mid := (lo + hi) / 2
+		if arl(mid) < targetARL {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, nil
+}
+
+// CUSUMARL returns Siegmund's (1985) approximation of the in-control average run
+// length of the two-sided CUSUM with drift k and threshold h, both in units of the
+// noise standard deviation, for Gaussian samples.
+func CUSUMARL(k, h float64) float64 {
+	b := h + 1.166 // Correction for the overshoot of the threshold.
+	var oneSided float64
+	if k == 0 {
+		oneSided = b * b
+	} else {
+		oneSided = (math.Exp(2*k*b) - 2*k*b - 1) / (2 * k * k)
+	}
+	// 1/ARL = 1/ARL⁺ + 1/ARL⁻, with equal one-sided ARLs by symmetry.
+	return oneSided / 2
+}
+
+// CUSUMThreshold returns the threshold h for which CUSUMARL(k, h) equals targetARL.
+// It is instantaneous, unlike CalibrateThreshold, and accurate to a few percent.
+func CUSUMThreshold(k, targetARL float64) (float64, error) {
+	if k < 0 {
+		return 0, fmt.Errorf("sequential: drift must be non-negative, got %g", k)
+	}
+	if !(targetARL > CUSUMARL(k, 0)) || math.IsInf(targetARL, 0) {
+		return 0, fmt.Errorf("sequential: target ARL must exceed %g for drift %g, got %g", CUSUMARL(k, 0), k, targetARL)
+	}
+	lo, hi := 0.0, 1.0
+	for CUSUMARL(k, hi) < targetARL {
+		lo, hi = hi, 2*hi
+	}
+	for i := 0; i < 100 && hi-lo > 1e-9*hi; i++ {
+		mid := (lo + hi) / 2
+		if CUSUMARL(k, mid) < targetARL {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2, nil
+}
@@ -0,0 +1,262 @@
+// Package sequential provides classic sequential change detectors for a single
+// channel: two-sided CUSUM, Page–Hinkley and Shiryaev–Roberts. They process one
+// sample at a time in constant time and memory and raise an alarm as soon as the
+// evidence for a change exceeds a threshold, which makes them suited to cheap
+// edge-side monitoring; the offline detectors (e.g. pelt.Pelt) remain the tool for
+// segmenting a whole signal.
+//
+// The threshold trades detection delay against false alarms. The in-control
+// average run length (ARL), the mean number of samples between false alarms, is the
+// usual way to set it: CalibrateThreshold finds the threshold achieving a target ARL
+// by simulation, and CUSUMThreshold gives Siegmund's approximation for CUSUM.
+//
+// Example:
+//
+//	d := sequential.NewCUSUM(0, 0.5, 5) // target mean 0, drift 0.5σ, threshold 5σ
+//	for _, x := range stream {
+//		if alarm, ok := d.Update(x); ok {
+//			fmt.Println("change starting at sample", alarm.Start)
+//		}
+//	}
+package sequential
+
+import "math"
+
+// Alarm describes a detected change.
+type Alarm struct {
+	Index     int // Index of the sample that raised the alarm (0 for the first sample since Reset).
+	Start     int // Estimated index of the first sample after the change.
+	Direction int // +1 for an increase of the mean, -1 for a decrease.
+}
+
+// Detector is a sequential change detector. After an alarm the detector restarts
+// from its initial state, so that monitoring continues with the next sample.
+type Detector interface {
+	// Update processes the next sample and reports whether it raises an alarm.
+	Update(x float64) (Alarm, bool)
+	// Statistic returns the current detection statistic, compared with the threshold.
+	Statistic() float64
+	// Reset restarts the detector, including the sample index.
+	Reset()
+}
+
+// Run feeds all samples to the detector and returns the alarms raised.
+func Run(d Detector, xs []float64) []Alarm {
+	var alarms []Alarm
+	for _, x := range xs {
+		if alarm, ok := d.Update(x); ok {
+			alarms = append(alarms, alarm)
+		}
+	}
+	return alarms
+}
+
+// standardize returns (x - target) / scale, with a zero scale taken as 1.
+func standardize(x, target, scale float64) float64 {
+	if scale == 0 {
+		scale = 1
+	}
+	return (x - target) / scale
+}
+
+// CUSUM is the two-sided cumulative sum detector of Page (1954) for shifts of the
+// mean away from Target:
+//
+//	S⁺ = max(0, S⁺ + z - Drift),  S⁻ = max(0, S⁻ - z - Drift),  z = (x - Target) / Scale
+//
+// with an alarm when S⁺ or S⁻ exceeds Threshold. Drift is usually half the smallest
+// shift worth detecting, in units of Scale.
+type CUSUM struct {
+	Target    float64 // In-control mean.
+	Scale     float64 // Noise standard deviation; 0 means 1 (raw units).
+	Drift     float64 // Allowance k subtracted at each sample; must be non-negative.
+	Threshold float64 // Decision interval h.
+
+	index     int
+	up, down  float64
+	upStart   int
+	downStart int
+}
+
+// NewCUSUM creates a CUSUM detector in units of the raw samples (Scale 1).
+func NewCUSUM(target, drift, threshold float64) *CUSUM {
+	return &CUSUM{Target: target, Drift: drift, Threshold: threshold}
+}
+
+// Update implements Detector.
+func (c *CUSUM) Update(x float64) (Alarm, bool) {
+	z := standardize(x, c.Target, c.Scale)
+	i := c.index
+	c.index++
+	// A statistic back at zero means that no change started before the next sample.
+	if c.up = math.Max(0, c.up+z-c.Drift); c.up == 0 {
+		c.upStart = i + 1
+	}
+	if c.down = math.Max(0, c.down-z-c.Drift); c.down == 0 {
+		c.downStart = i + 1
+	}
+	switch {
+	case c.up > c.Threshold:
+		return c.alarm(Alarm{Index: i, Start: c.upStart, Direction: 1}), true
+	case c.down > c.Threshold:
+		return c.alarm(Alarm{Index: i, Start: c.downStart, Direction: -1}), true
+	}
+	return Alarm{}, false
+}
+
+// alarm restarts the statistics after an alarm, keeping the sample index.
+func (c *CUSUM) alarm(a Alarm) Alarm {
+	c.up, c.down = 0, 0
+	c.upStart, c.downStart = c.index, c.index
+	return a
+}
+
+// Statistic implements Detector: the larger of S⁺ and S⁻.
+func (c *CUSUM) Statistic() float64 {
+	return math.Max(c.up, c.down)
+}
+
+// Reset implements Detector.
+func (c *CUSUM) Reset() {
+	c.index, c.up, c.down, c.upStart, c.downStart = 0, 0, 0, 0, 0
+}
+
+// PageHinkley is the two-sided Page–Hinkley test, which compares every sample with
+// the running mean of the samples since the last alarm, so that no in-control mean
+// has to be known:
+//
+//	m⁺ = Σ (x - x̄ - Delta),  m⁻ = Σ (x̄ - x - Delta)
+//
+// with an alarm when m⁺ - min m⁺ or m⁻ - min m⁻ exceeds Threshold.
+type PageHinkley struct {
+	Delta     float64 // Magnitude of changes tolerated; must be non-negative.
+	Threshold float64 // Decision threshold λ.
+
+	index, count       int
+	mean               float64
+	up, down           float64 // Cumulative sums m⁺ and m⁻.
+	minUp, minDown     float64
+	upStart, downStart int
+}
+
+// NewPageHinkley creates a Page–Hinkley detector.
+func NewPageHinkley(delta, threshold float64) *PageHinkley {
+	return &PageHinkley{Delta: delta, Threshold: threshold}
+}
+
+// Update implements Detector.
+func (p *PageHinkley) Update(x float64) (Alarm, bool) {
+	i := p.index
+	p.index++
+	p.count++
+	p.mean += (x - p.mean) / float64(p.count)
+	p.up += x - p.mean - p.Delta
+	p.down += p.mean - x - p.Delta
+	if p.up < p.minUp {
+		p.minUp, p.upStart = p.up, i+1
+	}
+	if p.down < p.minDown {
+		p.minDown, p.downStart = p.down, i+1
+	}
+	switch {
+	case p.up-p.minUp > p.Threshold:
+		return p.alarm(Alarm{Index: i, Start: p.upStart, Direction: 1}), true
+	case p.down-p.minDown > p.Threshold:
+		return p.alarm(Alarm{Index: i, Start: p.downStart, Direction: -1}), true
+	}
+	return Alarm{}, false
+}
+
+// alarm restarts the test after an alarm, keeping the sample index.
+func (p *PageHinkley) alarm(a Alarm) Alarm {
+	p.count, p.mean, p.up, p.down, p.minUp, p.minDown = 0, 0, 0, 0, 0, 0
+	p.upStart, p.downStart = p.index, p.index
+	return a
+}
+
+// Statistic implements Detector: the larger of m⁺ - min m⁺ and m⁻ - min m⁻.
+func (p *PageHinkley) Statistic() float64 {
+	return math.Max(p.up-p.minUp, p.down-p.minDown)
+}
+
+// Reset implements Detector.
+func (p *PageHinkley) Reset() {
+	*p = PageHinkley{Delta: p.Delta, Threshold: p.Threshold}
+}
+
+// ShiryaevRoberts is the two-sided Shiryaev–Roberts procedure for a Gaussian mean
+// shift of ±Shift (in units of Scale) away from Target:
+//
+//	R = (1 + R) · Λ(x),  log Λ(x) = ±Shift · z - Shift²/2,  z = (x - Target) / Scale
+//
+// with an alarm when R exceeds Threshold, for either direction. Its in-control ARL
+// is close to Threshold for each direction, so Threshold is easy to choose. The
+// statistic is kept in log scale to avoid overflow. The change start is estimated
+// by the last reset of the log-likelihood-ratio CUSUM run alongside.
+type ShiryaevRoberts struct {
+	Target    float64 // In-control mean.
+	Scale     float64 // Noise standard deviation; 0 means 1 (raw units).
+	Shift     float64 // Expected magnitude of the shift, in units of Scale; must be positive.
+	Threshold float64 // Decision threshold A on R.
+
+	index              int
+	logUp, logDown     float64 // log(1 + R) for each direction, 0 in the initial state R = 0.
+	cusumUp, cusumDown float64
+	upStart, downStart int
+}
+
+// NewShiryaevRoberts creates a Shiryaev–Roberts detector in units of the raw samples (Scale 1).
+func NewShiryaevRoberts(target, shift, threshold float64) *ShiryaevRoberts {
+	return &ShiryaevRoberts{Target: target, Shift: shift, Threshold: threshold}
+}
+
+// Update implements Detector.
+func (s *ShiryaevRoberts) Update(x float64) (Alarm, bool) {
+	z := standardize(x, s.Target, s.Scale)
+	i := s.index
+	s.index++
+	llrUp := s.Shift*z - s.Shift*s.Shift/2
+	llrDown := -s.Shift*z - s.Shift*s.Shift/2
+	logRUp, logRDown := s.logUp+llrUp, s.logDown+llrDown
+	s.logUp, s.logDown = log1pExp(logRUp), log1pExp(logRDown)
+	if s.cusumUp = math.Max(0, s.cusumUp+llrUp); s.cusumUp == 0 {
+		s.upStart = i + 1
+	}
+	if s.cusumDown = math.Max(0, s.cusumDown+llrDown); s.cusumDown == 0 {
+		s.downStart = i + 1
+	}
+	logThreshold := math.Log(s.Threshold)
+	switch {
+	case logRUp > logThreshold:
+		return s.alarm(Alarm{Index: i, Start: s.upStart, Direction: 1}), true
+	case logRDown > logThreshold:
+		return s.alarm(Alarm{Index: i, Start: s.downStart, Direction: -1}), true
+	}
+	return Alarm{}, false
+}
+
+// alarm restarts the statistics after an alarm, keeping the sample index.
+func (s *ShiryaevRoberts) alarm(a Alarm) Alarm {
+	s.logUp, s.logDown = 0, 0
+	s.cusumUp, s.cusumDown = 0, 0
+	s.upStart, s.downStart = s.index, s.index
+	return a
+}
+
+// Statistic implements Detector: the larger R of both directions.
+func (s *ShiryaevRoberts) Statistic() float64 {
+	return math.Expm1(math.Max(s.logUp, s.logDown))
+}
+
+// Reset implements Detector.
+func (s *ShiryaevRoberts) Reset() {
+	*s = ShiryaevRoberts{Target: s.Target, Scale: s.Scale, Shift: s.Shift, Threshold: s.Threshold}
+}
+
+// log1pExp returns log(1 + exp(v)) without overflow.
+func log1pExp(v float64) float64 {
+	if v > 35 {
+		return v
+	}
+	return math.Log1p(math.Exp(v))
+}
@@ -0,0 +1,116 @@
+package sequential_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/detection/sequential"
+)
+
+// shiftedSignal returns n in-control standard normal samples followed by n samples
+// whose mean is shifted by delta.
+func shiftedSignal(n int, delta float64, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	xs := make([]float64, 2*n)
+	for i := range xs {
+		xs[i] = rng.NormFloat64()
+		if i >= n {
+			xs[i] += delta
+		}
+	}
+	return xs
+}
+
+func TestDetectorsFindShift(t *testing.T) {
+	detectors := []struct {
+		name string
+		new  func() sequential.Detector
+	}{
+		{"CUSUM", func() sequential.Detector { return sequential.NewCUSUM(0, 0.5, 8) }},
+		{"PageHinkley", func() sequential.Detector { return sequential.NewPageHinkley(0.5, 25) }},
+		{"ShiryaevRoberts", func() sequential.Detector { return sequential.NewShiryaevRoberts(0, 1.5, 1e5) }},
+	}
+	for _, det := range detectors {
+		for _, delta := range []float64{2, -2} {
+			name := det.name + "/Up"
+			if delta < 0 {
+				name = det.name + "/Down"
+			}
+			t.Run(name, func(t *testing.T) {
+				alarms := sequential.Run(det.new(), shiftedSignal(300, delta, 1))
+				if len(alarms) == 0 {
+					t.Fatal("expected an alarm")
+				}
+				a := alarms[0]
+				if a.Index < 300 || a.Index > 330 {
+					t.Errorf("expected the first alarm shortly after sample 300, got %+v", a)
+				}
+				if math.Abs(float64(a.Start-300)) > 10 {
+					t.Errorf("expected the change to start near sample 300, got %+v", a)
+				}
+				if want := int(math.Copysign(1, delta)); a.Direction != want {
+					t.Errorf("expected direction %d, got %+v", want, a)
+				}
+			})
+		}
+	}
+}
+
+func TestCUSUMARL(t *testing.T) {
+	// Tabulated in-control ARL of the two-sided CUSUM with k = 0.5 and h = 5: about 465.
+	if arl := sequential.CUSUMARL(0.5, 5); math.Abs(arl-465) > 0.05*465 {
+		t.Errorf("expected CUSUMARL(0.5, 5) close to 465, got %g", arl)
+	}
+	h, err := sequential.CUSUMThreshold(0.5, 465)
+	if err != nil {
+		t.Fatalf("CUSUMThreshold failed: %v", err)
+	}
+	if math.Abs(h-5) > 0.1 {
+		t.Errorf("expected a threshold close to 5, got %g", h)
+	}
+	if _, err := sequential.CUSUMThreshold(0.5, 1); err == nil {
+		t.Error("expected an error for an unreachable ARL")
+	}
+
+	// The simulation agrees with the approximation.
+	simulated := sequential.AverageRunLength(sequential.NewCUSUM(0, 0.5, 4), sequential.ARLOptions{Runs: 500, Seed: 1})
+	if want := sequential.CUSUMARL(0.5, 4); math.Abs(simulated-want) > 0.15*want {
+		t.Errorf("expected a simulated ARL close to %g, got %g", want, simulated)
+	}
+}
+
+func TestCalibrateThreshold(t *testing.T) {
+	const target = 200
+	h, err := sequential.CalibrateThreshold(func(h float64) sequential.Detector {
+		return sequential.NewCUSUM(0, 0.5, h)
+	}, target, sequential.ARLOptions{Seed: 3})
+	if err != nil {
+		t.Fatalf("CalibrateThreshold failed: %v", err)
+	}
+	want, _ := sequential.CUSUMThreshold(0.5, target)
+	if math.Abs(h-want) > 0.1*want {
+		t.Errorf("expected a threshold close to %g, got %g", want, h)
+	}
+
+	if _, err := sequential.CalibrateThreshold(func(h float64) sequential.Detector {
+		return sequential.NewPageHinkley(0.5, h)
+	}, 0.5, sequential.ARLOptions{}); err == nil {
+		t.Error("expected an error for a target ARL below 1")
+	}
+}
+
+func TestDetectorReset(t *testing.T) {
+	d := sequential.NewCUSUM(0, 0, 1)
+	if _, ok := d.Update(5); !ok {
+		t.Fatal("expected an alarm")
+	}
+	d.Update(0.5)
+	d.Reset()
+	if d.Statistic() != 0 {
+		t.Errorf("expected a zero statistic after Reset, got %g", d.Statistic())
+	}
+	if a, ok := d.Update(5); !ok || a.Index != 0 || a.Start != 0 {
+		t.Errorf("expected an alarm at sample 0 after Reset, got %+v, %v", a, ok)
+	}
+}
@@ -0,0 +1,335 @@
+// Package wbs implements Wild Binary Segmentation (Fryzlewicz, 2014) and Seeded
+// Binary Segmentation (Kovács, Li, Bühlmann and Munk, 2023) on top of any
+// base.CostFunction.
+//
+// Plain binary segmentation splits a segment at the point of largest cost
+// reduction over the whole segment, and can miss a short segment lying between two
+// long ones: over the whole segment, its two change points nearly cancel out. Both
+// detectors instead look for the best split within many sub-intervals of every
+// segment, and keep the largest reduction among the intervals contained in it. A
+// short segment is then found from an interval that brackets it closely.
+//
+// The gain of splitting an interval [s, e) at t is the CUSUM-style cost reduction
+//
+//	gain(s, t, e) = C(s, e) - C(s, t) - C(t, e)
+//
+// computed from CostFunction.Error; with the L2 cost it is the squared norm of the
+// CUSUM statistic, computed in O(d) from cumulative sums of the signal instead. A
+// split is kept while its gain exceeds the penalty, so that Predict(penalty)
+// follows the same convention as pelt.Pelt.
+//
+// WBS draws its intervals at random, from a seeded generator for reproducibility;
+// SeededBS uses a deterministic multiscale grid of intervals, which needs far fewer
+// intervals for the same guarantees.
+package wbs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// DefaultIntervals is the number of random intervals WBS draws when NIntervals is 0.
+const DefaultIntervals = 1000
+
+// DefaultDecay is the decay of the interval lengths between layers of SeededBS when
+// Decay is 0.
+const DefaultDecay = 1 / math.Sqrt2
+
+// interval is a sub-interval [start, end) of the signal with its best split.
+type interval struct {
+	start, end int
+	split      int     // Best split point, or -1 when none is admissible.
+	gain       float64 // Gain of the best split.
+}
+
+// segmenter holds what WBS and SeededBS share: the cost, the constraints on the
+// splits, and the fitted intervals.
+type segmenter struct {
+	cost      base.CostFunction
+	minSize   int
+	jump      int
+	nSamples  int
+	intervals []interval
+	fitted    bool
+	sums      *cost.L2Sums // Cumulative sums of the signal with the L2 cost, nil otherwise.
+}
+
+// fit fits the cost function on the signal.
+func (s *segmenter) fit(signal types.Matrix) error {
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return exceptions.ErrInvalidSignal
+	}
+	if s.minSize < 1 || s.jump < 1 {
+		return fmt.Errorf("wbs: min_size and jump must be at least 1, got %d and %d", s.minSize, s.jump)
+	}
+	if err := s.cost.Fit(signal); err != nil {
+		return err
+	}
+	s.sums = nil
+	if _, ok := s.cost.(*cost.CostL2); ok {
+		s.sums = cost.NewL2Sums(signal)
+	}
+	s.nSamples, s.intervals, s.fitted = len(signal), nil, true
+	return nil
+}
+
+// bestSplit returns the admissible split of [start, end) with the largest gain, or
+// -1 when the interval is too short to be split, for the detector or for the cost
+// function.
+func (s *segmenter) bestSplit(ctx context.Context, start, end int) (int, float64, error) {
+	if end-start < 2*s.minSize {
+		return -1, 0, nil
+	}
+	first := start + s.minSize
+	if r := first % s.jump; r != 0 {
+		first += s.jump - r
+	}
+	if s.sums != nil {
+		return s.bestSplitL2(start, end, first)
+	}
+	total, err := s.cost.Error(start, end)
+	if errors.Is(err, exceptions.ErrNotEnoughPoints) {
+		return -1, 0, nil
+	} else if err != nil {
+		return -1, 0, err
+	}
+	best, bestGain := -1, math.Inf(-1)
+	for t := first; t <= end-s.minSize; t += s.jump {
+		// Each split costs O(end - start) with most cost functions.
+		if err := ctx.Err(); err != nil {
+			return -1, 0, err
+		}
+		left, err := s.cost.Error(start, t)
+		if errors.Is(err, exceptions.ErrNotEnoughPoints) {
+			continue
+		} else if err != nil {
+			return -1, 0, err
+		}
+		right, err := s.cost.Error(t, end)
+		if errors.Is(err, exceptions.ErrNotEnoughPoints) {
+			continue
+		} else if err != nil {
+			return -1, 0, err
+		}
+		if gain := total - left - right; gain > bestGain {
+			best, bestGain = t, gain
+		}
+	}
+	return best, bestGain, nil
+}
+
+// bestSplitL2 is bestSplit for the L2 cost, computed from the cumulative sums in
+// O(d) per split. Like CostL2.Error, it rejects the sides shorter than its MinSize.
+func (s *segmenter) bestSplitL2(start, end, first int) (int, float64, error) {
+	minSize := s.cost.(*cost.CostL2).MinSize
+	if end-start < 2*minSize {
+		return -1, 0, nil
+	}
+	total := s.sums.Cost(start, end)
+	best, bestGain := -1, math.Inf(-1)
+	for t := first; t <= end-s.minSize; t += s.jump {
+		if t-start < minSize || end-t < minSize {
+			continue
+		}
+		if gain := total - s.sums.Cost(start, t) - s.sums.Cost(t, end); gain > bestGain {
+			best, bestGain = t, gain
+		}
+	}
+	return best, bestGain, nil
+}
+
+// predict runs the binary segmentation over the intervals produced by draw.
+func (s *segmenter) predict(ctx context.Context, penalty float64, draw func(n, minLength int) [][2]int) ([]int, error) {
+	if !s.fitted {
+		return nil, errors.New("wbs: detector not fitted, call Fit() first")
+	}
+	if penalty <= 0 {
+		return nil, errors.New("wbs: penalty must be greater than 0")
+	}
+	if s.intervals == nil {
+		bounds := draw(s.nSamples, 2*s.minSize)
+		s.intervals = make([]interval, 0, len(bounds))
+		for _, b := range bounds {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			split, gain, err := s.bestSplit(ctx, b[0], b[1])
+			if err != nil {
+				return nil, err
+			}
+			if split >= 0 {
+				s.intervals = append(s.intervals, interval{b[0], b[1], split, gain})
+			}
+		}
+	}
+
+	bkps := []int{s.nSamples}
+	pending := [][2]int{{0, s.nSamples}}
+	for len(pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		seg := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		// The segment itself is always a candidate interval, as in binary segmentation.
+		split, gain, err := s.bestSplit(ctx, seg[0], seg[1])
+		if err != nil {
+			return nil, err
+		}
+		for _, iv := range s.intervals {
+			if iv.start >= seg[0] && iv.end <= seg[1] && iv.gain > gain {
+				split, gain = iv.split, iv.gain
+			}
+		}
+		if split < 0 || gain <= penalty {
+			continue
+		}
+		bkps = append(bkps, split)
+		pending = append(pending, [2]int{seg[0], split}, [2]int{split, seg[1]})
+	}
+	sort.Ints(bkps)
+	return bkps, nil
+}
+
+// WBS is the Wild Binary Segmentation detector. It implements base.Estimator.
+type WBS struct {
+	NIntervals int   // Number of random intervals; 0 selects DefaultIntervals.
+	Seed       int64 // Seed of the random generator drawing the intervals.
+	segmenter
+}
+
+// NewWBS creates a WBS detector.
+//
+// Parameters:
+//
+//	costFunc:   The cost function measuring the homogeneity of a segment.
+//	minSize:    The minimum segment length.
+//	jump:       The subsample step: only multiples of jump are considered as change points.
+//	nIntervals: The number of random intervals; 0 selects DefaultIntervals.
+//	seed:       The seed of the random generator, for reproducible results.
+func NewWBS(costFunc base.CostFunction, minSize, jump, nIntervals int, seed int64) *WBS {
+	return &WBS{NIntervals: nIntervals, Seed: seed, segmenter: segmenter{cost: costFunc, minSize: minSize, jump: jump}}
+}
+
+// Fit fits the cost function on the signal.
+func (w *WBS) Fit(signal types.Matrix) error {
+	return w.fit(signal)
+}
+
+// Predict returns the breakpoints whose splits reduce the cost by more than penalty.
+func (w *WBS) Predict(penalty float64) ([]int, error) {
+	return w.PredictContext(context.Background(), penalty)
+}
+
+// PredictContext is like Predict, but aborts with ctx.Err() once ctx is done.
+func (w *WBS) PredictContext(ctx context.Context, penalty float64) ([]int, error) {
+	return w.predict(ctx, penalty, w.randomIntervals)
+}
+
+// FitPredict fits the detector on the signal and predicts its breakpoints.
+func (w *WBS) FitPredict(signal types.Matrix, penalty float64) ([]int, error) {
+	if err := w.Fit(signal); err != nil {
+		return nil, err
+	}
+	return w.Predict(penalty)
+}
+
+// randomIntervals draws NIntervals intervals with uniform end points, of at least
+// minLength samples.
+func (w *WBS) randomIntervals(n, minLength int) [][2]int {
+	if n < minLength {
+		return nil
+	}
+	count := w.NIntervals
+	if count <= 0 {
+		count = DefaultIntervals
+	}
+	rng := rand.New(rand.NewSource(w.Seed))
+	bounds := make([][2]int, 0, count)
+	for len(bounds) < count {
+		s, e := rng.Intn(n+1), rng.Intn(n+1)
+		if s > e {
+			s, e = e, s
+		}
+		if e-s >= minLength {
+			bounds = append(bounds, [2]int{s, e})
+		}
+	}
+	return bounds
+}
+
+// SeededBS is the Seeded Binary Segmentation detector. It implements base.Estimator.
+type SeededBS struct {
+	// Decay is the ratio between the interval lengths of consecutive layers, in
+	// [0.5, 1); 0 selects DefaultDecay. Values closer to 1 give more intervals.
+	Decay float64
+	segmenter
+}
+
+// NewSeededBS creates a Seeded Binary Segmentation detector.
+//
+// Parameters:
+//
+//	costFunc: The cost function measuring the homogeneity of a segment.
+//	minSize:  The minimum segment length.
+//	jump:     The subsample step: only multiples of jump are considered as change points.
+//	decay:    The decay of the interval lengths, in [0.5, 1); 0 selects DefaultDecay.
+func NewSeededBS(costFunc base.CostFunction, minSize, jump int, decay float64) *SeededBS {
+	return &SeededBS{Decay: decay, segmenter: segmenter{cost: costFunc, minSize: minSize, jump: jump}}
+}
+
+// Fit fits the cost function on the signal.
+func (b *SeededBS) Fit(signal types.Matrix) error {
+	if b.Decay != 0 && (b.Decay < 0.5 || b.Decay >= 1) {
+		return fmt.Errorf("wbs: decay must be in [0.5, 1), got %g", b.Decay)
+	}
+	return b.fit(signal)
+}
+
+// Predict returns the breakpoints whose splits reduce the cost by more than penalty.
+func (b *SeededBS) Predict(penalty float64) ([]int, error) {
+	return b.PredictContext(context.Background(), penalty)
+}
+
+// PredictContext is like Predict, but aborts with ctx.Err() once ctx is done.
+func (b *SeededBS) PredictContext(ctx context.Context, penalty float64) ([]int, error) {
+	return b.predict(ctx, penalty, b.seededIntervals)
+}
+
+// FitPredict fits the detector on the signal and predicts its breakpoints.
+func (b *SeededBS) FitPredict(signal types.Matrix, penalty float64) ([]int, error) {
+	if err := b.Fit(signal); err != nil {
+		return nil, err
+	}
+	return b.Predict(penalty)
+}
+
+// seededIntervals returns the seeded intervals: layer k holds 2⌈n/l_k⌉ - 1 evenly
+// shifted intervals of length l_k = n·Decay^(k-1), overlapping by half, down to the
+// minimal length.
+func (b *SeededBS) seededIntervals(n, minLength int) [][2]int {
+	decay := b.Decay
+	if decay == 0 {
+		decay = DefaultDecay
+	}
+	var bounds [][2]int
+	for length := float64(n) * decay; length >= float64(minLength); length *= decay {
+		count := 2*int(math.Ceil(float64(n)/length)) - 1
+		shift := (float64(n) - length) / float64(count-1)
+		for i := 0; i < count; i++ {
+			s := int(math.Floor(float64(i) * shift))
+			e := min(n, int(math.Ceil(float64(i)*shift+length)))
+			bounds = append(bounds, [2]int{s, e})
+		}
+	}
+	return bounds
+}
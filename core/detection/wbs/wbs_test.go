@@ -0,0 +1,216 @@
+package wbs_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/detection/internal/detectiontest"
+	"github.com/theDataFlowClub/ruptures/core/detection/wbs"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+func TestDetectShortBurst(t *testing.T) {
+	signal, truth := detectiontest.BurstSignal(1)
+	tests := []struct {
+		name string
+		est  base.Estimator
+		pen  float64
+	}{
+		{name: "WBS", est: wbs.NewWBS(cost.NewCostL2(), 2, 1, 100, 7), pen: 10},
+		{name: "SeededBS", est: wbs.NewSeededBS(cost.NewCostL2(), 2, 1, 0), pen: 10},
+		// The L1 cost is on the scale of the absolute deviations.
+		{name: "SeededBSL1", est: wbs.NewSeededBS(cost.NewCostL1(), 2, 1, 0.5), pen: 20},
+		{name: "SeededBSJump", est: wbs.NewSeededBS(cost.NewCostL2(), 2, 4, 0), pen: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bkps, err := tt.est.FitPredict(signal, tt.pen)
+			if err != nil {
+				t.Fatalf("FitPredict failed: %v", err)
+			}
+			f1, err := metrics.F1Score(truth, bkps, 4)
+			if err != nil {
+				t.Fatalf("F1Score failed: %v", err)
+			}
+			if f1 < 1 {
+				t.Errorf("breakpoints = %v, want close to %v (F1 = %g)", bkps, truth, f1)
+			}
+		})
+	}
+}
+
+func TestPenaltyLimitsSplits(t *testing.T) {
+	signal, _ := detectiontest.BurstSignal(2)
+	b := wbs.NewSeededBS(cost.NewCostL2(), 2, 1, 0)
+	if err := b.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	bkps, err := b.Predict(1e6)
+	if err != nil {
+		t.Fatalf("Predict failed: %v", err)
+	}
+	if !reflect.DeepEqual(bkps, []int{300}) {
+		t.Errorf("breakpoints = %v, want [300] with a huge penalty", bkps)
+	}
+}
+
+func TestWBSSeedReproducible(t *testing.T) {
+	signal, _ := detectiontest.BurstSignal(3)
+	run := func(seed int64) []int {
+		w := wbs.NewWBS(cost.NewCostL2(), 2, 1, 30, seed)
+		bkps, err := w.FitPredict(signal, 3)
+		if err != nil {
+			t.Fatalf("FitPredict failed: %v", err)
+		}
+		return bkps
+	}
+	if a, b := run(11), run(11); !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed gave %v and %v", a, b)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	signal, _ := detectiontest.BurstSignal(4)
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "NotFitted", run: func() error {
+			_, err := wbs.NewWBS(cost.NewCostL2(), 2, 1, 0, 0).Predict(1)
+			return err
+		}},
+		{name: "ZeroPenalty", run: func() error {
+			_, err := wbs.NewSeededBS(cost.NewCostL2(), 2, 1, 0).FitPredict(signal, 0)
+			return err
+		}},
+		{name: "BadDecay", run: func() error {
+			return wbs.NewSeededBS(cost.NewCostL2(), 2, 1, 0.2).Fit(signal)
+		}},
+		{name: "BadMinSize", run: func() error {
+			return wbs.NewWBS(cost.NewCostL2(), 0, 1, 0, 0).Fit(signal)
+		}},
+		{name: "EmptySignal", run: func() error {
+			return wbs.NewWBS(cost.NewCostL2(), 2, 1, 0, 0).Fit(types.Matrix{})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestPredictContextCanceled(t *testing.T) {
+	signal, _ := detectiontest.BurstSignal(5)
+	w := wbs.NewWBS(cost.NewCostL2(), 2, 1, 0, 0)
+	if err := w.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.PredictContext(ctx, 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("PredictContext error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// genericL2 hides the type of CostL2, so that the detectors evaluate it through
+// CostFunction.Error instead of the cumulative sums.
+type genericL2 struct{ *cost.CostL2 }
+
+func TestL2CumulativeSums(t *testing.T) {
+	// The cumulative sums give the same gains, hence the same breakpoints, as the
+	// L2 cost function, with and without jump.
+	rng := rand.New(rand.NewSource(6))
+	signal := make(types.Matrix, 400)
+	for i := range signal {
+		signal[i] = []float64{float64(i/100%2)*2 + rng.NormFloat64(), float64(i/150) + rng.NormFloat64()}
+	}
+	for _, jump := range []int{1, 3} {
+		fast, err := wbs.NewSeededBS(cost.NewCostL2(), 2, jump, 0).FitPredict(signal, 10)
+		if err != nil {
+			t.Fatalf("FitPredict failed: %v", err)
+		}
+		slow, err := wbs.NewSeededBS(genericL2{cost.NewCostL2()}, 2, jump, 0).FitPredict(signal, 10)
+		if err != nil {
+			t.Fatalf("FitPredict failed: %v", err)
+		}
+		if !reflect.DeepEqual(fast, slow) || len(fast) < 3 {
+			t.Errorf("jump %d: breakpoints = %v, want %v", jump, fast, slow)
+		}
+	}
+}
+
+func TestShortIntervalsForCost(t *testing.T) {
+	// Intervals shorter than the minimum segment length of the cost function have
+	// no admissible split; they are skipped rather than aborting the detection.
+	signal, truth := detectiontest.BurstSignal(8)
+	l2 := cost.NewCostL2()
+	l2.MinSize = 10
+	l1 := cost.NewCostL1()
+	l1.MinSize = 10
+	tests := []struct {
+		name string
+		cost base.CostFunction
+		pen  float64
+	}{
+		{name: "L2", cost: l2, pen: 10},
+		{name: "L1", cost: l1, pen: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bkps, err := wbs.NewWBS(tt.cost, 2, 1, 200, 3).FitPredict(signal, tt.pen)
+			if err != nil {
+				t.Fatalf("FitPredict failed: %v", err)
+			}
+			f1, err := metrics.F1Score(truth, bkps, 4)
+			if err != nil {
+				t.Fatalf("F1Score failed: %v", err)
+			}
+			if f1 < 1 {
+				t.Errorf("breakpoints = %v, want close to %v (F1 = %g)", bkps, truth, f1)
+			}
+		})
+	}
+}
+
+// cancelingL2 cancels a context once its Error has been called after calls.
+type cancelingL2 struct {
+	genericL2
+	cancel context.CancelFunc
+	calls  *int
+	after  int
+}
+
+func (c cancelingL2) Error(start, end int) (float64, error) {
+	if *c.calls++; *c.calls == c.after {
+		c.cancel()
+	}
+	return c.genericL2.Error(start, end)
+}
+
+func TestPredictContextCanceledDuringSplit(t *testing.T) {
+	// The search of the best split of an interval stops as soon as the context is
+	// done, not at the next interval.
+	signal, _ := detectiontest.BurstSignal(7)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	w := wbs.NewWBS(cancelingL2{genericL2{cost.NewCostL2()}, cancel, &calls, 10}, 2, 1, 1, 0)
+	if err := w.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	if _, err := w.PredictContext(ctx, 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("PredictContext error = %v, want %v", err, context.Canceled)
+	}
+	if calls > 11 {
+		t.Errorf("%d calls of Error, want the search stopped after the 10th", calls)
+	}
+}
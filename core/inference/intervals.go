@@ -0,0 +1,507 @@
+// Package inference quantifies the uncertainty of a segmentation found by any
+// detector: how precisely each change point is located, and whether it is real
+// at all (see Significance).
+//
+// Two methods compute a confidence interval for every breakpoint. Both keep the
+// neighbouring breakpoints fixed and relocate the breakpoint within the window
+// they delimit, where it is the single change point.
+//
+//   - Bootstrap resamples each of the two segments of the window by circular
+//     blocks, relocates the breakpoint on every replicate by minimizing the cost
+//     of the split, and takes the percentiles of the relocated breakpoints.
+//     Resampling blocks of a segment is a block bootstrap of the residuals around
+//     its fitted value: the distribution of each segment is preserved, whatever
+//     the change, and blocks keep the short-range dependence of the noise. It
+//     works with any cost function.
+//   - Profile inverts the likelihood-ratio test of the location: the interval
+//     holds the breakpoints whose split cost exceeds the best one by less than a
+//     critical value (ProfileCriticalValue). It needs no resampling but assumes
+//     independent Gaussian (l2) or Laplace (l1) noise, whose level is estimated
+//     from the signal.
+//
+// Both intervals always contain the breakpoint, and are widened to do so when the
+// detector placed it away from the best split of its window.
+package inference
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/penalty"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Methods of confidence intervals, as accepted by NewIntervalEstimator.
+const (
+	MethodBootstrap = "bootstrap"
+	MethodProfile   = "profile"
+)
+
+// Defaults of the interval parameters.
+const (
+	DefaultLevel      = 0.95
+	DefaultReplicates = 199
+)
+
+// ErrUnknownMethod is returned when a method name is not one of Methods().
+var ErrUnknownMethod = errors.New("inference: unknown method")
+
+// Methods returns the names of the interval methods.
+func Methods() []string {
+	return []string{MethodBootstrap, MethodProfile}
+}
+
+// Interval is a confidence interval of a breakpoint. Lower and Upper are
+// breakpoints too (the first sample after the change), both included.
+type Interval struct {
+	Breakpoint int     `json:"breakpoint"`
+	Lower      int     `json:"lower"`
+	Upper      int     `json:"upper"`
+	Level      float64 `json:"level"` // Confidence level, e.g. 0.95.
+}
+
+// IntervalEstimator computes confidence intervals of the breakpoints of a
+// segmentation. It is implemented by Bootstrap and Profile.
+type IntervalEstimator interface {
+	// IntervalsContext returns one interval per change point of bkps, in order
+	// (the final breakpoint, the number of samples, has none). The cost function
+	// must be fitted on signal. It aborts with ctx.Err() once ctx is done.
+	IntervalsContext(ctx context.Context, signal types.Matrix, c base.CostFunction, bkps []int) ([]Interval, error)
+}
+
+// NewIntervalEstimator creates the interval estimator of the named method with
+// the default parameters of its constructor.
+//
+// Parameters:
+//
+//	method:  MethodBootstrap or MethodProfile.
+//	minSize: The minimum segment length when relocating a breakpoint.
+//	level:   The confidence level; 0 selects DefaultLevel.
+//	seed:    The seed of the bootstrap; ignored by the profile method.
+//
+// Returns:
+//
+//	IntervalEstimator: The estimator.
+//	error:             ErrUnknownMethod for an unknown method name.
+func NewIntervalEstimator(method string, minSize int, level float64, seed int64) (IntervalEstimator, error) {
+	switch method {
+	case MethodBootstrap:
+		return NewBootstrap(minSize, 0, level, seed), nil
+	case MethodProfile:
+		return NewProfile(minSize, level), nil
+	default:
+		return nil, fmt.Errorf("%w %q (available: %s)", ErrUnknownMethod, method, strings.Join(Methods(), ", "))
+	}
+}
+
+// Bootstrap computes percentile intervals by block bootstrap. It implements
+// IntervalEstimator.
+type Bootstrap struct {
+	MinSize    int     // Minimum segment length when relocating a breakpoint.
+	Replicates int     // Number of bootstrap replicates per breakpoint.
+	Level      float64 // Confidence level, in (0, 1).
+	// BlockSize is the length of the resampled blocks; 1 resamples single samples
+	// and 0 selects the cube root of each segment length.
+	BlockSize int
+	Seed      int64 // Seed of the random generator drawing the blocks.
+	// NewCost builds the cost function fitted on every replicate. When nil, the
+	// model of the cost passed to IntervalsContext is created with cost.NewCost,
+	// with its default parameters. The l2 and rbf costs are never refitted: the l2
+	// profile is computed from cumulative sums, and the rbf one from the kernel of
+	// the cost passed to IntervalsContext, in O(n²) per replicate of n samples.
+	NewCost func() (base.CostFunction, error)
+}
+
+// NewBootstrap creates a block bootstrap with automatic block sizes.
+//
+// Parameters:
+//
+//	minSize:    The minimum segment length when relocating a breakpoint.
+//	replicates: The number of replicates per breakpoint; 0 selects DefaultReplicates.
+//	level:      The confidence level; 0 selects DefaultLevel.
+//	seed:       The seed of the random generator, for reproducible intervals.
+func NewBootstrap(minSize, replicates int, level float64, seed int64) *Bootstrap {
+	if replicates == 0 {
+		replicates = DefaultReplicates
+	}
+	if level == 0 {
+		level = DefaultLevel
+	}
+	return &Bootstrap{MinSize: minSize, Replicates: replicates, Level: level, Seed: seed}
+}
+
+// Intervals returns the confidence intervals of the change points of bkps.
+func (b *Bootstrap) Intervals(signal types.Matrix, c base.CostFunction, bkps []int) ([]Interval, error) {
+	return b.IntervalsContext(context.Background(), signal, c, bkps)
+}
+
+// IntervalsContext is like Intervals, but aborts with ctx.Err() once ctx is done.
+func (b *Bootstrap) IntervalsContext(ctx context.Context, signal types.Matrix, c base.CostFunction, bkps []int) ([]Interval, error) {
+	switch {
+	case b.MinSize < 1:
+		return nil, fmt.Errorf("inference: min_size must be at least 1, got %d", b.MinSize)
+	case b.Replicates < 1:
+		return nil, fmt.Errorf("inference: replicates must be at least 1, got %d", b.Replicates)
+	case b.BlockSize < 0:
+		return nil, fmt.Errorf("inference: block size must be non-negative, got %d", b.BlockSize)
+	}
+	if err := checkLevel(b.Level); err != nil {
+		return nil, err
+	}
+	if err := base.CheckBreakpoints(signal, bkps); err != nil {
+		return nil, fmt.Errorf("inference: %w", err)
+	}
+
+	profiler, err := newSplitProfiler(signal, c, b.NewCost, b.MinSize)
+	if err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(b.Seed))
+	intervals := make([]Interval, 0, len(bkps)-1)
+	start := 0
+	for k, bkp := range bkps[:len(bkps)-1] {
+		end := bkps[k+1]
+		// replicate holds the indices in signal of the samples of the replicate.
+		replicate := make([]int, end-start)
+		estimates := make([]int, 0, b.Replicates)
+		for r := 0; r < b.Replicates; r++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			resample(rng, replicate[:bkp-start], start, b.BlockSize)
+			resample(rng, replicate[bkp-start:], bkp, b.BlockSize)
+			prof, _, err := profiler.profile(ctx, replicate)
+			if err != nil {
+				return nil, err
+			}
+			if at := argmin(prof); at >= 0 {
+				estimates = append(estimates, start+at)
+			}
+		}
+		intervals = append(intervals, percentiles(bkp, estimates, b.Level))
+		start = bkp
+	}
+	return intervals, nil
+}
+
+// Profile computes likelihood-ratio intervals for the l2 and l1 costs. It
+// implements IntervalEstimator.
+type Profile struct {
+	MinSize int     // Minimum segment length when relocating a breakpoint.
+	Level   float64 // Confidence level, in (0, 1).
+	// Sigma is the noise standard deviation; 0 estimates it from the signal with
+	// penalty.EstimateSigmas.
+	Sigma float64
+}
+
+// NewProfile creates a likelihood profile estimator with an estimated noise level.
+//
+// Parameters:
+//
+//	minSize: The minimum segment length when relocating a breakpoint.
+//	level:   The confidence level; 0 selects DefaultLevel.
+func NewProfile(minSize int, level float64) *Profile {
+	if level == 0 {
+		level = DefaultLevel
+	}
+	return &Profile{MinSize: minSize, Level: level}
+}
+
+// ProfileCriticalValue returns the critical value of the profile deviance for the
+// confidence level. For a change in mean with Gaussian noise, the deviance of the
+// true location, 2·(ℓ(τ̂) - ℓ(τ)), converges to twice the maximum of two
+// independent Brownian motions with drift -1/2, whose distribution function is
+// (1 - e^{-x/2})²; the critical value is its quantile -2·log(1 - √level).
+func ProfileCriticalValue(level float64) float64 {
+	return -2 * math.Log(1-math.Sqrt(level))
+}
+
+// Intervals returns the confidence intervals of the change points of bkps.
+func (p *Profile) Intervals(signal types.Matrix, c base.CostFunction, bkps []int) ([]Interval, error) {
+	return p.IntervalsContext(context.Background(), signal, c, bkps)
+}
+
+// IntervalsContext is like Intervals, but aborts with ctx.Err() once ctx is done.
+func (p *Profile) IntervalsContext(ctx context.Context, signal types.Matrix, c base.CostFunction, bkps []int) ([]Interval, error) {
+	if p.MinSize < 1 {
+		return nil, fmt.Errorf("inference: min_size must be at least 1, got %d", p.MinSize)
+	}
+	if p.Sigma < 0 {
+		return nil, fmt.Errorf("inference: sigma must be non-negative, got %g", p.Sigma)
+	}
+	if err := checkLevel(p.Level); err != nil {
+		return nil, err
+	}
+	if err := base.CheckBreakpoints(signal, bkps); err != nil {
+		return nil, fmt.Errorf("inference: %w", err)
+	}
+	sigma := p.Sigma
+	if sigma == 0 {
+		sigmas, err := penalty.EstimateSigmas(signal)
+		if err != nil {
+			return nil, err
+		}
+		var sumSquares float64
+		for _, s := range sigmas {
+			sumSquares += s * s
+		}
+		sigma = math.Sqrt(sumSquares / float64(len(sigmas)))
+	}
+	// scale converts a difference of costs into a deviance, twice the difference
+	// of log-likelihoods: the cost is Σ(x - μ)² = 2σ²·(-ℓ) for the Gaussian, and
+	// Σ|x - m| = b·(-ℓ) for the Laplace distribution of scale b = σ/√2.
+	var scale float64
+	switch c.Model() {
+	case "l2":
+		scale = sigma * sigma
+	case "l1":
+		scale = sigma / (2 * math.Sqrt2)
+	default:
+		return nil, fmt.Errorf("inference: profile intervals need the l2 or l1 cost, got %q; use the bootstrap", c.Model())
+	}
+	critical := ProfileCriticalValue(p.Level)
+
+	intervals := make([]Interval, 0, len(bkps)-1)
+	start := 0
+	for k, bkp := range bkps[:len(bkps)-1] {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := bkps[k+1]
+		prof, err := profile(ctx, c, signal, start, end, p.MinSize)
+		if err != nil {
+			return nil, err
+		}
+		iv := Interval{Breakpoint: bkp, Lower: bkp, Upper: bkp, Level: p.Level}
+		if best := argmin(prof); best >= 0 {
+			for i, v := range prof {
+				excess := v - prof[best]
+				// A noiseless signal only admits the best splits.
+				if (scale > 0 && excess/scale <= critical) || excess <= 0 {
+					iv.Lower, iv.Upper = min(iv.Lower, start+i), max(iv.Upper, start+i)
+				}
+			}
+		}
+		intervals = append(intervals, iv)
+		start = bkp
+	}
+	return intervals, nil
+}
+
+// checkLevel validates a confidence level.
+func checkLevel(level float64) error {
+	if !(level > 0 && level < 1) {
+		return fmt.Errorf("inference: level must be in (0, 1), got %g", level)
+	}
+	return nil
+}
+
+// profile returns the cost C(start, t) + C(t, end) of splitting [start, end) at
+// every t, indexed by t - start, with +Inf where t is closer than minSize to
+// either end or a side is too short for the cost. The l2 cost is computed from
+// the cumulative sums of the signal; the others call the cost function, which
+// must be fitted on the signal, and abort with ctx.Err() once ctx is done.
+func profile(ctx context.Context, c base.CostFunction, signal types.Matrix, start, end, minSize int) ([]float64, error) {
+	prof := make([]float64, end-start+1)
+	for i := range prof {
+		prof[i] = math.Inf(1)
+	}
+	if c.Model() == "l2" {
+		l2Profile(prof, signal[start:end], minSize)
+		return prof, nil
+	}
+	for t := start + minSize; t <= end-minSize; t++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		left, err := c.Error(start, t)
+		if errors.Is(err, exceptions.ErrNotEnoughPoints) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		right, err := c.Error(t, end)
+		if errors.Is(err, exceptions.ErrNotEnoughPoints) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		prof[t-start] = left + right
+	}
+	return prof, nil
+}
+
+// l2Profile fills prof with the l2 cost of every split of the window in O(n·d),
+// from its cumulative sums, and returns the cost of the whole window.
+func l2Profile(prof []float64, window types.Matrix, minSize int) float64 {
+	sums := cost.NewL2Sums(window)
+	n := len(window)
+	for t := minSize; t <= n-minSize; t++ {
+		prof[t] = sums.Cost(0, t) + sums.Cost(t, n)
+	}
+	return sums.Cost(0, n)
+}
+
+// splitProfiler computes the split profiles of windows resampled from a signal,
+// the bootstrap replicates and the permutations, given by the indices in the
+// signal of their samples.
+type splitProfiler struct {
+	signal  types.Matrix
+	minSize int
+	rbf     *cost.CostRbf     // Fitted on signal; its kernel gives the rbf profiles.
+	refit   base.CostFunction // Fitted on every window, for the costs other than l2 and rbf.
+	rows    types.Matrix      // The samples of the last window.
+}
+
+// newSplitProfiler returns the profiler of the cost c, fitted on the signal.
+// newCost builds the cost refitted on every window; when nil, the model of c is
+// created with cost.NewCost.
+func newSplitProfiler(signal types.Matrix, c base.CostFunction, newCost func() (base.CostFunction, error), minSize int) (*splitProfiler, error) {
+	p := &splitProfiler{signal: signal, minSize: minSize}
+	if rbf, ok := c.(*cost.CostRbf); ok {
+		p.rbf = rbf
+		return p, nil
+	}
+	if c.Model() == "l2" {
+		return p, nil
+	}
+	if newCost == nil {
+		newCost = func() (base.CostFunction, error) { return cost.NewCost(c.Model()) }
+	}
+	var err error
+	if p.refit, err = newCost(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// profile returns the profile of the window of samples signal[idx[0]],
+// signal[idx[1]], ... (see profile) and the cost of the whole window.
+func (p *splitProfiler) profile(ctx context.Context, idx []int) ([]float64, float64, error) {
+	if p.rbf != nil {
+		return p.kernelProfile(ctx, idx)
+	}
+	p.rows = p.rows[:0]
+	for _, i := range idx {
+		p.rows = append(p.rows, p.signal[i])
+	}
+	if p.refit == nil {
+		prof := make([]float64, len(idx)+1)
+		for i := range prof {
+			prof[i] = math.Inf(1)
+		}
+		whole := l2Profile(prof, p.rows, p.minSize)
+		return prof, whole, nil
+	}
+	if err := p.refit.Fit(p.rows); err != nil {
+		return nil, 0, err
+	}
+	prof, err := profile(ctx, p.refit, p.rows, 0, len(p.rows), p.minSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	whole, err := p.refit.Error(0, len(p.rows))
+	if err != nil {
+		return nil, 0, err
+	}
+	return prof, whole, nil
+}
+
+// kernelProfile computes the rbf profile of the window from the kernel values of
+// its samples, read once per pair. The cost of [s, e) is Σ_i K_ii - Σ_{i,j} K_ij /
+// (e - s); with inner[t] = Σ_{i,j < t} K_ij and the row sums of K, the sum over
+// [t, n) is the total minus inner[t] minus twice the cross sum Σ_{i < t, j ≥ t}.
+func (p *splitProfiler) kernelProfile(ctx context.Context, idx []int) ([]float64, float64, error) {
+	n := len(idx)
+	inner := make([]float64, n+1)
+	rowSums := make([]float64, n)
+	for t := 0; t < n; t++ {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		rowSums[t]++ // K_tt = 1.
+		cross := 0.0
+		for j := 0; j < t; j++ {
+			// A sample drawn twice is at distance 0 from itself, clipped to 1e-2 off
+			// the diagonal as in the Gram matrix of the window.
+			k := math.Exp(-1e-2)
+			if idx[j] != idx[t] {
+				var err error
+				if k, err = p.rbf.Kernel(idx[j], idx[t]); err != nil {
+					return nil, 0, err
+				}
+			}
+			cross += k
+			rowSums[j] += k
+		}
+		rowSums[t] += cross
+		inner[t+1] = inner[t] + 1 + 2*cross
+	}
+	total := inner[n]
+	prof := make([]float64, n+1)
+	for i := range prof {
+		prof[i] = math.Inf(1)
+	}
+	leftRows := 0.0 // Σ_{i < t} of the row sums.
+	for t := 0; t < n; t++ {
+		if t >= p.minSize && t <= n-p.minSize {
+			right := total - inner[t] - 2*(leftRows-inner[t])
+			prof[t] = math.Max(float64(t)-inner[t]/float64(t), 0) + math.Max(float64(n-t)-right/float64(n-t), 0)
+		}
+		leftRows += rowSums[t]
+	}
+	return prof, math.Max(float64(n)-total/float64(n), 0), nil
+}
+
+// argmin returns the index of the smallest finite value, the first one on ties,
+// or -1 when there is none.
+func argmin(values []float64) int {
+	best := -1
+	for i, v := range values {
+		if !math.IsInf(v, 1) && (best < 0 || v < values[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// resample fills dst with the indices of circular blocks of the len(dst) samples
+// starting at offset, drawn at random. A blockSize of 0 selects the cube root of
+// len(dst).
+func resample(rng *rand.Rand, dst []int, offset, blockSize int) {
+	n := len(dst)
+	if blockSize == 0 {
+		blockSize = max(1, int(math.Round(math.Cbrt(float64(n)))))
+	}
+	for i := 0; i < n; {
+		from := rng.Intn(n)
+		for j := 0; j < blockSize && i < n; j++ {
+			dst[i] = offset + (from+j)%n
+			i++
+		}
+	}
+}
+
+// percentiles returns the equal-tailed percentile interval of the relocated
+// breakpoints, widened to contain the breakpoint.
+func percentiles(bkp int, estimates []int, level float64) Interval {
+	iv := Interval{Breakpoint: bkp, Lower: bkp, Upper: bkp, Level: level}
+	if len(estimates) == 0 {
+		return iv
+	}
+	sort.Ints(estimates)
+	tail := (1 - level) / 2
+	r := float64(len(estimates))
+	lo := min(int(math.Floor(tail*r)), len(estimates)-1)
+	hi := max(int(math.Ceil((1-tail)*r))-1, 0)
+	iv.Lower, iv.Upper = min(iv.Lower, estimates[lo]), max(iv.Upper, estimates[hi])
+	return iv
+}
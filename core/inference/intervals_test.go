@@ -0,0 +1,291 @@
+package inference_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/inference"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// stepSignal returns a univariate signal with unit Gaussian noise whose mean moves
+// by delta at every breakpoint of bkps.
+func stepSignal(seed int64, delta float64, bkps []int) types.Matrix {
+	rng := rand.New(rand.NewSource(seed))
+	signal := make(types.Matrix, bkps[len(bkps)-1])
+	level, next := 0.0, 0
+	for i := range signal {
+		if i == bkps[next] {
+			level += delta
+			next++
+		}
+		signal[i] = []float64{level + rng.NormFloat64()}
+	}
+	return signal
+}
+
+// fitted returns the cost of the model fitted on the signal.
+func fitted(t *testing.T, model string, signal types.Matrix) base.CostFunction {
+	t.Helper()
+	c, err := cost.NewCost(model)
+	if err != nil {
+		t.Fatalf("NewCost failed: %v", err)
+	}
+	if err := c.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	return c
+}
+
+func TestProfileCriticalValue(t *testing.T) {
+	// (1 - e^{-q/2})² = 0.95 for q ≈ 7.352.
+	if q := inference.ProfileCriticalValue(0.95); math.Abs(q-7.352) > 1e-3 {
+		t.Errorf("ProfileCriticalValue(0.95) = %g, want 7.352", q)
+	}
+}
+
+func TestIntervalsWidth(t *testing.T) {
+	bkps := []int{100, 200}
+	for _, method := range inference.Methods() {
+		t.Run(method, func(t *testing.T) {
+			widths := map[float64]int{}
+			for _, delta := range []float64{0.7, 3} {
+				signal := stepSignal(1, delta, bkps)
+				est, err := inference.NewIntervalEstimator(method, 2, 0.95, 1)
+				if err != nil {
+					t.Fatalf("NewIntervalEstimator failed: %v", err)
+				}
+				ivs, err := est.IntervalsContext(context.Background(), signal, fitted(t, "l2", signal), bkps)
+				if err != nil {
+					t.Fatalf("IntervalsContext failed: %v", err)
+				}
+				if len(ivs) != 1 || ivs[0].Breakpoint != 100 || ivs[0].Lower > 100 || ivs[0].Upper < 100 || ivs[0].Level != 0.95 {
+					t.Fatalf("intervals = %+v", ivs)
+				}
+				widths[delta] = ivs[0].Upper - ivs[0].Lower
+			}
+			if widths[3] > 5 || widths[0.7] < 5*max(widths[3], 1) {
+				t.Errorf("widths = %v, want a narrow interval for a large change and a wide one for a small change", widths)
+			}
+		})
+	}
+}
+
+func TestIntervalsCoverage(t *testing.T) {
+	// The intervals around the detected change point must contain the true one for
+	// about 90% of the signals.
+	const runs = 60
+	for _, method := range inference.Methods() {
+		t.Run(method, func(t *testing.T) {
+			covered, total := 0, 0
+			for seed := int64(0); seed < runs; seed++ {
+				signal := stepSignal(seed, 1, []int{100, 200})
+				bkps, err := pelt.NewPelt(cost.NewCostL2(), 2, 1).FitPredict(signal, 30)
+				if err != nil {
+					t.Fatalf("FitPredict failed: %v", err)
+				}
+				if len(bkps) != 2 {
+					continue
+				}
+				est, _ := inference.NewIntervalEstimator(method, 2, 0.9, seed)
+				ivs, err := est.IntervalsContext(context.Background(), signal, fitted(t, "l2", signal), bkps)
+				if err != nil {
+					t.Fatalf("IntervalsContext failed: %v", err)
+				}
+				total++
+				if ivs[0].Lower <= 100 && ivs[0].Upper >= 100 {
+					covered++
+				}
+			}
+			if total < runs/2 || float64(covered) < 0.8*float64(total) {
+				t.Errorf("covered %d of %d", covered, total)
+			}
+		})
+	}
+}
+
+func TestIntervalsSeveralChanges(t *testing.T) {
+	bkps := []int{60, 120, 150, 250}
+	signal := stepSignal(2, 2.5, bkps)
+	tests := []struct {
+		name     string
+		est      inference.IntervalEstimator
+		model    string
+		maxWidth int
+	}{
+		{name: "BootstrapL2", est: inference.NewBootstrap(2, 99, 0.9, 3), model: "l2", maxWidth: 10},
+		{name: "BootstrapL1", est: inference.NewBootstrap(2, 99, 0.9, 3), model: "l1", maxWidth: 10},
+		{name: "BootstrapIID", est: &inference.Bootstrap{MinSize: 2, Replicates: 99, Level: 0.9, BlockSize: 1}, model: "l2", maxWidth: 10},
+		{name: "BootstrapRbf", est: inference.NewBootstrap(2, 19, 0.9, 3), model: "rbf", maxWidth: 20},
+		{name: "ProfileL2", est: inference.NewProfile(2, 0.9), model: "l2", maxWidth: 10},
+		{name: "ProfileL1", est: inference.NewProfile(2, 0.9), model: "l1", maxWidth: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ivs, err := tt.est.IntervalsContext(context.Background(), signal, fitted(t, tt.model, signal), bkps)
+			if err != nil {
+				t.Fatalf("IntervalsContext failed: %v", err)
+			}
+			if len(ivs) != len(bkps)-1 {
+				t.Fatalf("intervals = %+v, want %d", ivs, len(bkps)-1)
+			}
+			prev := 0
+			for i, iv := range ivs {
+				if iv.Breakpoint != bkps[i] || iv.Lower > iv.Breakpoint || iv.Upper < iv.Breakpoint ||
+					iv.Lower <= prev || iv.Upper >= bkps[i+1] || iv.Upper-iv.Lower > tt.maxWidth {
+					t.Errorf("interval %d = %+v", i, iv)
+				}
+				prev = bkps[i]
+			}
+		})
+	}
+}
+
+func TestBootstrapSeedReproducible(t *testing.T) {
+	bkps := []int{100, 200}
+	signal := stepSignal(4, 0.8, bkps)
+	run := func(seed int64) []inference.Interval {
+		ivs, err := inference.NewBootstrap(2, 49, 0.9, seed).Intervals(signal, fitted(t, "l2", signal), bkps)
+		if err != nil {
+			t.Fatalf("Intervals failed: %v", err)
+		}
+		return ivs
+	}
+	if a, b := run(5), run(5); !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed gave %+v and %+v", a, b)
+	}
+}
+
+func TestIntervalsNoChange(t *testing.T) {
+	signal := stepSignal(6, 0, []int{50})
+	for _, method := range inference.Methods() {
+		est, _ := inference.NewIntervalEstimator(method, 2, 0, 0)
+		ivs, err := est.IntervalsContext(context.Background(), signal, fitted(t, "l2", signal), []int{50})
+		if err != nil || len(ivs) != 0 {
+			t.Errorf("%s: intervals = %+v, err = %v, want none", method, ivs, err)
+		}
+	}
+}
+
+func TestIntervalsErrors(t *testing.T) {
+	bkps := []int{50, 100}
+	signal := stepSignal(7, 1, bkps)
+	l2 := fitted(t, "l2", signal)
+	tests := []struct {
+		name   string
+		est    inference.IntervalEstimator
+		c      base.CostFunction
+		signal types.Matrix
+		bkps   []int
+	}{
+		{name: "BootstrapMinSize", est: inference.NewBootstrap(0, 0, 0, 0), c: l2, signal: signal, bkps: bkps},
+		{name: "BootstrapLevel", est: inference.NewBootstrap(2, 0, 1, 0), c: l2, signal: signal, bkps: bkps},
+		{name: "BootstrapBlockSize", est: &inference.Bootstrap{MinSize: 2, Replicates: 9, Level: 0.9, BlockSize: -1}, c: l2, signal: signal, bkps: bkps},
+		{name: "ProfileCost", est: inference.NewProfile(2, 0), c: fitted(t, "rbf", signal), signal: signal, bkps: bkps},
+		{name: "ProfileSigma", est: &inference.Profile{MinSize: 2, Level: 0.9, Sigma: -1}, c: l2, signal: signal, bkps: bkps},
+		{name: "LastBreakpoint", est: inference.NewProfile(2, 0), c: l2, signal: signal, bkps: []int{50}},
+		{name: "Unsorted", est: inference.NewProfile(2, 0), c: l2, signal: signal, bkps: []int{60, 50, 100}},
+		{name: "Empty", est: inference.NewProfile(2, 0), c: l2, signal: types.Matrix{}, bkps: []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.est.IntervalsContext(context.Background(), tt.signal, tt.c, tt.bkps); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+	if _, err := inference.NewIntervalEstimator("jackknife", 2, 0, 0); !errors.Is(err, inference.ErrUnknownMethod) {
+		t.Errorf("NewIntervalEstimator error = %v, want %v", err, inference.ErrUnknownMethod)
+	}
+}
+
+func TestIntervalsContextCanceled(t *testing.T) {
+	bkps := []int{100, 200}
+	signal := stepSignal(8, 1, bkps)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, method := range inference.Methods() {
+		est, _ := inference.NewIntervalEstimator(method, 2, 0, 0)
+		if _, err := est.IntervalsContext(ctx, signal, fitted(t, "l2", signal), bkps); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: error = %v, want %v", method, err, context.Canceled)
+		}
+	}
+}
+
+// refitRbf hides the concrete type of the rbf cost, so that the bootstrap refits
+// it on every replicate instead of reading its kernel.
+type refitRbf struct{ *cost.CostRbf }
+
+func TestBootstrapRbfKernel(t *testing.T) {
+	// The profiles read from the kernel of the fitted cost are those of the cost
+	// refitted on every replicate with the same gamma.
+	bkps := []int{60, 120, 150}
+	signal := stepSignal(9, 1.5, bkps)
+	gamma := 0.3
+	newCost := func() (base.CostFunction, error) { return cost.NewCostRbf(&gamma), nil }
+	kernel := cost.NewCostRbf(&gamma)
+	if err := kernel.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	refit := refitRbf{cost.NewCostRbf(&gamma)}
+	if err := refit.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	b := &inference.Bootstrap{MinSize: 2, Replicates: 29, Level: 0.9, Seed: 4, NewCost: newCost}
+	want, err := b.Intervals(signal, refit, bkps)
+	if err != nil {
+		t.Fatalf("Intervals failed: %v", err)
+	}
+	got, err := b.Intervals(signal, kernel, bkps)
+	if err != nil {
+		t.Fatalf("Intervals failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("intervals from the kernel = %+v, refitted %+v", got, want)
+	}
+	if kernel.GetCachedGramForTest() != nil {
+		t.Error("the bootstrap built the Gram matrix of the signal")
+	}
+}
+
+// cancelingL1 is an l1 cost that cancels a context on its given call to Error.
+type cancelingL1 struct {
+	*cost.CostL1
+	cancel   context.CancelFunc
+	calls    *int
+	cancelAt int
+}
+
+func (c cancelingL1) Error(start, end int) (float64, error) {
+	*c.calls++
+	if *c.calls == c.cancelAt {
+		c.cancel()
+	}
+	return c.CostL1.Error(start, end)
+}
+
+func TestBootstrapContextCanceledDuringProfile(t *testing.T) {
+	// The bootstrap stops within the profile of a replicate, not after it.
+	bkps := []int{100, 200}
+	signal := stepSignal(10, 1, bkps)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	b := inference.NewBootstrap(2, 9, 0, 0)
+	b.NewCost = func() (base.CostFunction, error) {
+		return cancelingL1{CostL1: cost.NewCostL1(), cancel: cancel, calls: &calls, cancelAt: 10}, nil
+	}
+	if _, err := b.IntervalsContext(ctx, signal, fitted(t, "l1", signal), bkps); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	if calls > 11 {
+		t.Errorf("Error called %d times after the cancellation on call 10", calls)
+	}
+}
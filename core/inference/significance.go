@@ -0,0 +1,378 @@
+package inference
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Tests of the significance of a change point, as accepted by Significance.Test.
+const (
+	TestLRT         = "lrt"
+	TestPermutation = "permutation"
+)
+
+// Corrections for multiple testing, as accepted by Significance.Correction.
+const (
+	CorrectionNone       = "none"
+	CorrectionBonferroni = "bonferroni" // Family-wise error rate, any dependence.
+	CorrectionHolm       = "holm"       // Family-wise error rate, uniformly more powerful than Bonferroni.
+	CorrectionBH         = "bh"         // False discovery rate (Benjamini-Hochberg).
+)
+
+// Defaults of the significance parameters.
+const (
+	DefaultAlpha        = 0.05
+	DefaultPermutations = 199
+)
+
+// ErrUnknownTest is returned when a test or correction name is unknown.
+var ErrUnknownTest = errors.New("inference: unknown test")
+
+// Tests returns the names of the significance tests.
+func Tests() []string {
+	return []string{TestLRT, TestPermutation}
+}
+
+// Corrections returns the names of the multiple-testing corrections.
+func Corrections() []string {
+	return []string{CorrectionNone, CorrectionBonferroni, CorrectionHolm, CorrectionBH}
+}
+
+// BreakpointTest is the outcome of the test of a change point.
+type BreakpointTest struct {
+	Breakpoint int `json:"breakpoint"`
+	// Statistic is the likelihood-ratio statistic of the LRT, or the decrease of the
+	// cost when splitting at the breakpoint for the permutation test.
+	Statistic float64 `json:"statistic"`
+	PValue    float64 `json:"p_value"`
+	// AdjustedPValue is PValue corrected for the number of tested change points.
+	AdjustedPValue float64 `json:"adjusted_p_value"`
+	// Significant reports whether AdjustedPValue is at most the level alpha.
+	Significant bool `json:"significant"`
+}
+
+// Significance tests whether each change point of a segmentation is real: the
+// samples between its two neighbouring breakpoints are tested for a single change
+// at the breakpoint against no change.
+//
+// The location of a change point was chosen by the detector, which favours
+// large statistics: a test ignoring that choice rejects far too often. Both tests
+// account for it by comparing the statistic at the breakpoint with the
+// distribution of the best split of a window without change.
+//
+//   - TestLRT is the likelihood-ratio test of a change in mean with Gaussian noise,
+//     for the l2 cost. The statistic divides the decrease of the cost by the
+//     residual variance of the split, and its p-value is the asymptotic one of the
+//     maximum over all splits (Csörgő and Horváth, 1997), which is conservative.
+//   - TestPermutation works with any cost: the statistic is the decrease of the
+//     cost, and the p-value is the proportion of random permutations of the window
+//     whose best split decreases the cost at least as much. It assumes
+//     exchangeable samples without change, so it is liberal with correlated noise.
+type Significance struct {
+	// Test is TestLRT or TestPermutation; empty selects TestLRT for the l2 cost and
+	// TestPermutation for the others.
+	Test       string
+	Alpha      float64 // Level of the tests, in (0, 1).
+	Correction string  // Correction for multiple testing, one of Corrections().
+	// Permutations is the number of permutations of each permutation test. The
+	// smallest p-value is 1/(Permutations+1): with the Holm or Bonferroni
+	// correction of k change points, at least k/Alpha permutations are needed for
+	// any of them to be significant.
+	Permutations int
+	MinSize      int   // Minimum segment length of the permuted splits.
+	Seed         int64 // Seed of the random generator drawing the permutations.
+	// NewCost builds the cost function fitted on every permutation. When nil, the
+	// model of the cost passed to EvaluateContext is created with cost.NewCost,
+	// with its default parameters. As for Bootstrap.NewCost, the l2 and rbf costs
+	// are never refitted.
+	NewCost func() (base.CostFunction, error)
+}
+
+// NewSignificance creates the default tests for the cost (see Significance.Test)
+// with the Holm correction.
+//
+// Parameters:
+//
+//	minSize: The minimum segment length of the permuted splits.
+//	alpha:   The level of the tests; 0 selects DefaultAlpha.
+//	seed:    The seed of the permutations, for reproducible p-values.
+func NewSignificance(minSize int, alpha float64, seed int64) *Significance {
+	if alpha == 0 {
+		alpha = DefaultAlpha
+	}
+	return &Significance{Alpha: alpha, Correction: CorrectionHolm, Permutations: DefaultPermutations, MinSize: minSize, Seed: seed}
+}
+
+// Evaluate returns the test of every change point of bkps, in order.
+func (s *Significance) Evaluate(signal types.Matrix, c base.CostFunction, bkps []int) ([]BreakpointTest, error) {
+	return s.EvaluateContext(context.Background(), signal, c, bkps)
+}
+
+// EvaluateContext is like Evaluate, but aborts with ctx.Err() once ctx is done.
+// The cost function must be fitted on signal.
+func (s *Significance) EvaluateContext(ctx context.Context, signal types.Matrix, c base.CostFunction, bkps []int) ([]BreakpointTest, error) {
+	t, err := s.newTester(signal, c, bkps)
+	if err != nil {
+		return nil, err
+	}
+	return t.run(ctx, bkps)
+}
+
+// Prune removes the change points of bkps that are not significant and returns
+// the remaining breakpoints with their tests.
+func (s *Significance) Prune(signal types.Matrix, c base.CostFunction, bkps []int) ([]int, []BreakpointTest, error) {
+	return s.PruneContext(context.Background(), signal, c, bkps)
+}
+
+// PruneContext is like Prune, but aborts with ctx.Err() once ctx is done.
+//
+// Removing a change point merges the windows of its neighbours, whose tests
+// change, so change points are removed one at a time by backward elimination:
+// the one with the largest adjusted p-value goes, and the others are tested again,
+// until every remaining change point is significant.
+func (s *Significance) PruneContext(ctx context.Context, signal types.Matrix, c base.CostFunction, bkps []int) ([]int, []BreakpointTest, error) {
+	t, err := s.newTester(signal, c, bkps)
+	if err != nil {
+		return nil, nil, err
+	}
+	kept := append([]int(nil), bkps...)
+	for {
+		tests, err := t.run(ctx, kept)
+		if err != nil {
+			return nil, nil, err
+		}
+		worst := -1
+		for i, bt := range tests {
+			if !bt.Significant && (worst < 0 || bt.AdjustedPValue > tests[worst].AdjustedPValue) {
+				worst = i
+			}
+		}
+		if worst < 0 {
+			return kept, tests, nil
+		}
+		kept = append(kept[:worst], kept[worst+1:]...)
+	}
+}
+
+// tester runs the tests of a Significance on one signal. The raw result of every
+// window is cached, since pruning tests most windows again.
+type tester struct {
+	*Significance
+	test     string
+	signal   types.Matrix
+	cost     base.CostFunction // Fitted on signal.
+	profiler *splitProfiler    // Profiles of the permutations.
+	rng      *rand.Rand
+	cache    map[[3]int]BreakpointTest
+}
+
+// newTester validates the parameters and the segmentation.
+func (s *Significance) newTester(signal types.Matrix, c base.CostFunction, bkps []int) (*tester, error) {
+	if !(s.Alpha > 0 && s.Alpha < 1) {
+		return nil, fmt.Errorf("inference: alpha must be in (0, 1), got %g", s.Alpha)
+	}
+	if !contains(Corrections(), s.Correction) {
+		return nil, fmt.Errorf("%w: correction %q (available: %s)", ErrUnknownTest, s.Correction, strings.Join(Corrections(), ", "))
+	}
+	if err := base.CheckBreakpoints(signal, bkps); err != nil {
+		return nil, fmt.Errorf("inference: %w", err)
+	}
+	t := &tester{Significance: s, test: s.Test, signal: signal, cost: c, cache: map[[3]int]BreakpointTest{}}
+	if t.test == "" {
+		t.test = TestPermutation
+		if c.Model() == "l2" {
+			t.test = TestLRT
+		}
+	}
+	switch t.test {
+	case TestLRT:
+		if c.Model() != "l2" {
+			return nil, fmt.Errorf("inference: the likelihood-ratio test needs the l2 cost, got %q; use the permutation test", c.Model())
+		}
+	case TestPermutation:
+		if s.Permutations < 1 {
+			return nil, fmt.Errorf("inference: permutations must be at least 1, got %d", s.Permutations)
+		}
+		if s.MinSize < 1 {
+			return nil, fmt.Errorf("inference: min_size must be at least 1, got %d", s.MinSize)
+		}
+		var err error
+		if t.profiler, err = newSplitProfiler(signal, c, s.NewCost, s.MinSize); err != nil {
+			return nil, err
+		}
+		t.rng = rand.New(rand.NewSource(s.Seed))
+	default:
+		return nil, fmt.Errorf("%w %q (available: %s)", ErrUnknownTest, t.test, strings.Join(Tests(), ", "))
+	}
+	return t, nil
+}
+
+// run tests every change point of bkps and applies the correction.
+func (t *tester) run(ctx context.Context, bkps []int) ([]BreakpointTest, error) {
+	tests := make([]BreakpointTest, 0, len(bkps)-1)
+	start := 0
+	for k, bkp := range bkps[:len(bkps)-1] {
+		window := [3]int{start, bkp, bkps[k+1]}
+		bt, ok := t.cache[window]
+		if !ok {
+			var err error
+			if bt, err = t.window(ctx, window[0], window[1], window[2]); err != nil {
+				return nil, err
+			}
+			t.cache[window] = bt
+		}
+		tests = append(tests, bt)
+		start = bkp
+	}
+
+	pValues := make([]float64, len(tests))
+	for i, bt := range tests {
+		pValues[i] = bt.PValue
+	}
+	for i, p := range adjust(pValues, t.Correction) {
+		tests[i].AdjustedPValue = p
+		tests[i].Significant = p <= t.Alpha
+	}
+	return tests, nil
+}
+
+// window tests the change at bkp within [start, end).
+func (t *tester) window(ctx context.Context, start, bkp, end int) (BreakpointTest, error) {
+	if err := ctx.Err(); err != nil {
+		return BreakpointTest{}, err
+	}
+	bt := BreakpointTest{Breakpoint: bkp, PValue: 1}
+	whole, split, err := splitCosts(t.cost, start, bkp, end)
+	if errors.Is(err, exceptions.ErrNotEnoughPoints) {
+		return bt, nil // A side too short for the cost: no evidence of a change.
+	} else if err != nil {
+		return BreakpointTest{}, err
+	}
+	gain := math.Max(whole-split, 0)
+
+	if t.test == TestLRT {
+		n, nFeatures := end-start, len(t.signal[0])
+		variance := split / float64(nFeatures*max(n-2, 1))
+		switch {
+		case variance > 0:
+			bt.Statistic = gain / variance
+			bt.PValue = lrtPValue(n, nFeatures, bt.Statistic)
+		case gain > 0:
+			// A noiseless change.
+			bt.Statistic, bt.PValue = math.Inf(1), 0
+		}
+		return bt, nil
+	}
+
+	bt.Statistic = gain
+	// perm holds the indices in signal of the permuted samples.
+	perm := make([]int, end-start)
+	for i := range perm {
+		perm[i] = start + i
+	}
+	exceed := 0
+	for r := 0; r < t.Permutations; r++ {
+		if err := ctx.Err(); err != nil {
+			return BreakpointTest{}, err
+		}
+		t.rng.Shuffle(len(perm), func(i, j int) { perm[i], perm[j] = perm[j], perm[i] })
+		prof, permWhole, err := t.profiler.profile(ctx, perm)
+		if err != nil {
+			return BreakpointTest{}, err
+		}
+		best := argmin(prof)
+		if best < 0 {
+			continue
+		}
+		if permWhole-prof[best] >= gain {
+			exceed++
+		}
+	}
+	bt.PValue = float64(exceed+1) / float64(t.Permutations+1)
+	return bt, nil
+}
+
+// splitCosts returns the cost of [start, end) and the cost of its split at bkp.
+func splitCosts(c base.CostFunction, start, bkp, end int) (whole, split float64, err error) {
+	if whole, err = c.Error(start, end); err != nil {
+		return 0, 0, err
+	}
+	left, err := c.Error(start, bkp)
+	if err != nil {
+		return 0, 0, err
+	}
+	right, err := c.Error(bkp, end)
+	if err != nil {
+		return 0, 0, err
+	}
+	return whole, left + right, nil
+}
+
+// lrtPValue returns the asymptotic p-value of the maximum over all splits of the
+// likelihood-ratio statistic of a change in the mean of n samples of nFeatures
+// features: P(a·√max - b ≤ x) → exp(-2·e^{-x}), with a = √(2·log log n) and
+// b = 2·log log n + (d/2)·log log log n - log Γ(d/2). Windows shorter than 16
+// samples, where log log log n is not positive, use the constants of 16.
+func lrtPValue(n, nFeatures int, stat float64) float64 {
+	loglog := math.Log(math.Log(float64(max(n, 16))))
+	a := math.Sqrt(2 * loglog)
+	lgamma, _ := math.Lgamma(float64(nFeatures) / 2)
+	b := 2*loglog + float64(nFeatures)/2*math.Log(loglog) - lgamma
+	return -math.Expm1(-2 * math.Exp(b-a*math.Sqrt(stat)))
+}
+
+// adjust returns the p-values corrected for multiple testing.
+func adjust(pValues []float64, correction string) []float64 {
+	m := len(pValues)
+	adjusted := make([]float64, m)
+	order := make([]int, m)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return pValues[order[i]] < pValues[order[j]] })
+	switch correction {
+	case CorrectionBonferroni:
+		for i, p := range pValues {
+			adjusted[i] = math.Min(1, float64(m)*p)
+		}
+	case CorrectionHolm:
+		// The k-th smallest p-value is multiplied by m - k + 1, keeping the
+		// adjusted values increasing.
+		running := 0.0
+		for k, i := range order {
+			running = math.Max(running, math.Min(1, float64(m-k)*pValues[i]))
+			adjusted[i] = running
+		}
+	case CorrectionBH:
+		// The k-th smallest p-value is multiplied by m / k, keeping the adjusted
+		// values increasing from the largest down.
+		running := 1.0
+		for k := m - 1; k >= 0; k-- {
+			i := order[k]
+			running = math.Min(running, float64(m)/float64(k+1)*pValues[i])
+			adjusted[i] = running
+		}
+	default:
+		copy(adjusted, pValues)
+	}
+	return adjusted
+}
+
+// contains reports whether names holds name.
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
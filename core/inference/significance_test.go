@@ -0,0 +1,237 @@
+package inference_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/inference"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// spuriousSignal returns a signal with changes of 2 at 100 and 200 and
+// breakpoints adding two spurious change points, at 50 and 150.
+func spuriousSignal(seed int64) (types.Matrix, []int) {
+	return stepSignal(seed, 2, []int{100, 200, 300}), []int{50, 100, 150, 200, 300}
+}
+
+func TestEvaluate(t *testing.T) {
+	signal, bkps := spuriousSignal(1)
+	tests := []struct {
+		name  string
+		sig   *inference.Significance
+		model string
+	}{
+		{name: "LRT", sig: inference.NewSignificance(2, 0.05, 0), model: "l2"},
+		{name: "PermutationL2", sig: &inference.Significance{Test: inference.TestPermutation, Alpha: 0.05, Correction: inference.CorrectionHolm, Permutations: 99, MinSize: 2, Seed: 1}, model: "l2"},
+		{name: "PermutationL1", sig: inference.NewSignificance(2, 0.05, 2), model: "l1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.sig.Evaluate(signal, fitted(t, tt.model, signal), bkps)
+			if err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+			if len(got) != len(bkps)-1 {
+				t.Fatalf("tests = %+v, want %d", got, len(bkps)-1)
+			}
+			for i, bt := range got {
+				want := bt.Breakpoint == 100 || bt.Breakpoint == 200
+				if bt.Breakpoint != bkps[i] || bt.Significant != want || bt.AdjustedPValue < bt.PValue {
+					t.Errorf("test %d = %+v, want significant = %v", i, bt, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	signal, bkps := spuriousSignal(3)
+	for _, test := range inference.Tests() {
+		t.Run(test, func(t *testing.T) {
+			sig := inference.NewSignificance(2, 0.05, 4)
+			sig.Test = test
+			kept, tests, err := sig.Prune(signal, fitted(t, "l2", signal), bkps)
+			if err != nil {
+				t.Fatalf("Prune failed: %v", err)
+			}
+			if want := []int{100, 200, 300}; !reflect.DeepEqual(kept, want) {
+				t.Errorf("kept = %v, want %v", kept, want)
+			}
+			if len(tests) != len(kept)-1 {
+				t.Errorf("tests = %+v, want one per kept change point", tests)
+			}
+			for _, bt := range tests {
+				if !bt.Significant {
+					t.Errorf("kept change point %+v is not significant", bt)
+				}
+			}
+			if !reflect.DeepEqual(bkps, []int{50, 100, 150, 200, 300}) {
+				t.Errorf("Prune modified its input: %v", bkps)
+			}
+		})
+	}
+}
+
+func TestLRTNullRejections(t *testing.T) {
+	// Without change, the best split of each signal is rejected at most about 5% of
+	// the time, although it was chosen to maximize the statistic.
+	const runs = 200
+	rejected := 0
+	for seed := int64(0); seed < runs; seed++ {
+		signal := stepSignal(seed, 0, []int{100})
+		c := fitted(t, "l2", signal)
+		best, bestCost := 0, math.Inf(1)
+		for b := 2; b <= 98; b++ {
+			left, _ := c.Error(0, b)
+			right, _ := c.Error(b, 100)
+			if left+right < bestCost {
+				best, bestCost = b, left+right
+			}
+		}
+		got, err := inference.NewSignificance(2, 0.05, 0).Evaluate(signal, c, []int{best, 100})
+		if err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+		if got[0].Significant {
+			rejected++
+		}
+	}
+	if rejected > runs/10 {
+		t.Errorf("%d of %d signals without change rejected", rejected, runs)
+	}
+}
+
+func TestCorrections(t *testing.T) {
+	signal := stepSignal(5, 1, []int{40, 80, 120, 160, 200})
+	bkps := []int{40, 80, 120, 160, 200}
+	c := fitted(t, "l2", signal)
+	adjusted := map[string][]inference.BreakpointTest{}
+	for _, correction := range inference.Corrections() {
+		sig := inference.NewSignificance(2, 0.05, 0)
+		sig.Correction = correction
+		got, err := sig.Evaluate(signal, c, bkps)
+		if err != nil {
+			t.Fatalf("%s: Evaluate failed: %v", correction, err)
+		}
+		adjusted[correction] = got
+	}
+	for i, raw := range adjusted[inference.CorrectionNone] {
+		p := raw.PValue
+		bh := adjusted[inference.CorrectionBH][i].AdjustedPValue
+		holm := adjusted[inference.CorrectionHolm][i].AdjustedPValue
+		bonferroni := adjusted[inference.CorrectionBonferroni][i].AdjustedPValue
+		if raw.AdjustedPValue != p || math.Abs(bonferroni-math.Min(1, 4*p)) > 1e-12 || !(p <= bh && bh <= holm && holm <= bonferroni) {
+			t.Errorf("change point %d: p = %g, bh = %g, holm = %g, bonferroni = %g", i, p, bh, holm, bonferroni)
+		}
+	}
+}
+
+func TestSignificanceSeedReproducible(t *testing.T) {
+	signal, bkps := spuriousSignal(6)
+	run := func() []inference.BreakpointTest {
+		got, err := inference.NewSignificance(2, 0.05, 7).Evaluate(signal, fitted(t, "l1", signal), bkps)
+		if err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+		return got
+	}
+	if a, b := run(), run(); !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed gave %+v and %+v", a, b)
+	}
+}
+
+func TestSignificanceErrors(t *testing.T) {
+	signal, bkps := spuriousSignal(8)
+	l2 := fitted(t, "l2", signal)
+	tests := []struct {
+		name string
+		sig  *inference.Significance
+		c    base.CostFunction
+	}{
+		{name: "Alpha", sig: &inference.Significance{Alpha: 1, Correction: inference.CorrectionNone}, c: l2},
+		{name: "Correction", sig: &inference.Significance{Alpha: 0.05, Correction: "sidak"}, c: l2},
+		{name: "Test", sig: &inference.Significance{Test: "wald", Alpha: 0.05, Correction: inference.CorrectionNone}, c: l2},
+		{name: "LRTCost", sig: &inference.Significance{Test: inference.TestLRT, Alpha: 0.05, Correction: inference.CorrectionNone}, c: fitted(t, "l1", signal)},
+		{name: "Permutations", sig: &inference.Significance{Test: inference.TestPermutation, Alpha: 0.05, Correction: inference.CorrectionNone, MinSize: 2}, c: l2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.sig.Evaluate(signal, tt.c, bkps); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+	if _, err := inference.NewSignificance(2, 0, 0).Evaluate(signal, l2, []int{100}); err == nil {
+		t.Error("expected an error for breakpoints not ending with the number of samples")
+	}
+}
+
+func TestPruneContextCanceled(t *testing.T) {
+	signal, bkps := spuriousSignal(9)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sig := inference.NewSignificance(2, 0, 0)
+	sig.Test = inference.TestPermutation
+	if _, _, err := sig.PruneContext(ctx, signal, fitted(t, "l2", signal), bkps); !errors.Is(err, context.Canceled) {
+		t.Errorf("PruneContext error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestPermutationRbfKernel(t *testing.T) {
+	// As for the bootstrap, the permutation profiles read from the kernel of the
+	// fitted cost are those of the cost refitted on every permutation.
+	signal, bkps := spuriousSignal(10)
+	gamma := 0.3
+	sig := inference.NewSignificance(2, 0.05, 11)
+	sig.Permutations = 29
+	sig.Correction = inference.CorrectionNone
+	sig.NewCost = func() (base.CostFunction, error) { return cost.NewCostRbf(&gamma), nil }
+	kernel := cost.NewCostRbf(&gamma)
+	if err := kernel.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	refit := refitRbf{cost.NewCostRbf(&gamma)}
+	if err := refit.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	want, err := sig.Evaluate(signal, refit, bkps)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	got, err := sig.Evaluate(signal, kernel, bkps)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	for i := range got {
+		if got[i].PValue != want[i].PValue || math.Abs(got[i].Statistic-want[i].Statistic) > 1e-9 {
+			t.Errorf("test %d from the kernel = %+v, refitted %+v", i, got[i], want[i])
+		}
+	}
+	for _, bt := range got {
+		if want := bt.Breakpoint == 100 || bt.Breakpoint == 200; bt.Significant != want {
+			t.Errorf("test %+v, want significant = %v", bt, want)
+		}
+	}
+}
+
+func TestPermutationContextCanceledDuringProfile(t *testing.T) {
+	signal, bkps := spuriousSignal(12)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	sig := inference.NewSignificance(2, 0, 0)
+	sig.NewCost = func() (base.CostFunction, error) {
+		return cancelingL1{CostL1: cost.NewCostL1(), cancel: cancel, calls: &calls, cancelAt: 10}, nil
+	}
+	if _, err := sig.EvaluateContext(ctx, signal, fitted(t, "l1", signal), bkps); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	if calls > 11 {
+		t.Errorf("Error called %d times after the cancellation on call 10", calls)
+	}
+}
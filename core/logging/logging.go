@@ -0,0 +1,45 @@
+// Package logging holds the structured logger shared by the ruptures library.
+//
+// The library never writes to stdout or stderr on its own: the default logger
+// discards every record. Programs that want diagnostics install their own
+// *slog.Logger with SetLogger, e.g.
+//
+//	logging.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+//
+// Records carry structured attributes; detectors use the keys defined below
+// (detector, cost, n_samples, duration, ...) so that they can be filtered and aggregated.
+package logging
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// Attribute keys used by the library.
+const (
+	KeyDetector = "detector"  // Name of the detection algorithm (e.g. "pelt").
+	KeyCost     = "cost"      // Cost model (e.g. "l2", "rbf").
+	KeyNSamples = "n_samples" // Number of samples of the signal.
+	KeyPenalty  = "penalty"   // Penalty value of a prediction.
+	KeyNBkps    = "n_bkps"    // Number of detected change points.
+	KeyDuration = "duration"  // Duration of an operation.
+)
+
+var (
+	discard = slog.New(slog.DiscardHandler)
+	current atomic.Pointer[slog.Logger]
+)
+
+// SetLogger installs l as the library logger. A nil logger restores the
+// default, silent logger. It is safe for concurrent use.
+func SetLogger(l *slog.Logger) {
+	current.Store(l)
+}
+
+// Logger returns the library logger, silent unless SetLogger installed another one.
+func Logger() *slog.Logger {
+	if l := current.Load(); l != nil {
+		return l
+	}
+	return discard
+}
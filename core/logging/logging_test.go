@@ -0,0 +1,31 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/logging"
+)
+
+func TestSetLogger(t *testing.T) {
+	defer logging.SetLogger(nil)
+
+	if logging.Logger().Enabled(context.Background(), slog.LevelError) {
+		t.Error("default logger should discard every record")
+	}
+
+	var buf bytes.Buffer
+	logging.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	logging.Logger().Info("fitted", logging.KeyDetector, "pelt", logging.KeyNSamples, 10)
+	if out := buf.String(); !strings.Contains(out, "detector=pelt") || !strings.Contains(out, "n_samples=10") {
+		t.Errorf("unexpected log output %q", out)
+	}
+
+	logging.SetLogger(nil)
+	if logging.Logger().Enabled(context.Background(), slog.LevelError) {
+		t.Error("SetLogger(nil) should restore the silent logger")
+	}
+}
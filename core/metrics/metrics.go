@@ -0,0 +1,55 @@
+// Package metrics provides evaluation measures that compare a predicted segmentation
+// with a reference (true) segmentation: precision and recall with a margin, F1 score,
+// Hausdorff distance, Rand index and annotation error.
+//
+// As everywhere in the library, a segmentation is given as a sorted slice of
+// breakpoints whose last element is the number of samples, e.g. [100, 250, 400].
+// Both segmentations of a comparison must end with the same number of samples.
+package metrics
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBreakpointsMismatch is returned when two segmentations do not describe
+// signals of the same length, or when a segmentation is malformed.
+var ErrBreakpointsMismatch = errors.New("metrics: invalid or mismatched breakpoints")
+
+// ErrInvalidMargin is returned when the tolerance of a metric is not positive.
+var ErrInvalidMargin = errors.New("metrics: margin must be at least 1")
+
+// sanityCheck validates a pair of segmentations: both must be non-empty, strictly
+// increasing, positive and end with the same number of samples.
+func sanityCheck(trueBkps, predBkps []int) error {
+	for _, bkps := range [][]int{trueBkps, predBkps} {
+		if len(bkps) == 0 {
+			return fmt.Errorf("%w: empty breakpoints", ErrBreakpointsMismatch)
+		}
+		prev := 0
+		for _, b := range bkps {
+			if b <= prev {
+				return fmt.Errorf("%w: breakpoints %v are not strictly increasing positive indices", ErrBreakpointsMismatch, bkps)
+			}
+			prev = b
+		}
+	}
+	if trueBkps[len(trueBkps)-1] != predBkps[len(predBkps)-1] {
+		return fmt.Errorf("%w: n_samples differ (%d vs %d)", ErrBreakpointsMismatch,
+			trueBkps[len(trueBkps)-1], predBkps[len(predBkps)-1])
+	}
+	return nil
+}
+
+// AnnotationError returns the absolute difference between the number of predicted
+// and true change points, |K_pred - K_true|.
+func AnnotationError(trueBkps, predBkps []int) (int, error) {
+	if err := sanityCheck(trueBkps, predBkps); err != nil {
+		return 0, err
+	}
+	diff := len(predBkps) - len(trueBkps)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff, nil
+}
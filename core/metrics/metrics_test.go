@@ -0,0 +1,178 @@
+package metrics_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+)
+
+// Define a small tolerance for float comparisons.
+const floatTolerance = 1e-9
+
+func TestPrecisionRecall(t *testing.T) {
+	testCases := []struct {
+		name              string
+		trueBkps          []int
+		predBkps          []int
+		margin            int
+		expectedPrecision float64
+		expectedRecall    float64
+	}{
+		{name: "Perfect", trueBkps: []int{100, 200, 300}, predBkps: []int{100, 200, 300}, margin: 5, expectedPrecision: 1, expectedRecall: 1},
+		{name: "WithinMargin", trueBkps: []int{100, 200, 300}, predBkps: []int{104, 197, 300}, margin: 5, expectedPrecision: 1, expectedRecall: 1},
+		{name: "OnMarginBoundaryIsMiss", trueBkps: []int{100, 300}, predBkps: []int{105, 300}, margin: 5, expectedPrecision: 0, expectedRecall: 0},
+		{name: "ExtraPrediction", trueBkps: []int{100, 300}, predBkps: []int{50, 100, 300}, margin: 5, expectedPrecision: 0.5, expectedRecall: 1},
+		{name: "MissedChange", trueBkps: []int{100, 200, 300}, predBkps: []int{100, 300}, margin: 5, expectedPrecision: 1, expectedRecall: 0.5},
+		{name: "OnePredictionMatchesOnce", trueBkps: []int{100, 102, 300}, predBkps: []int{101, 300}, margin: 5, expectedPrecision: 1, expectedRecall: 0.5},
+		{name: "NoPrediction", trueBkps: []int{100, 300}, predBkps: []int{300}, margin: 5, expectedPrecision: 0, expectedRecall: 0},
+		{name: "NoChangeAtAll", trueBkps: []int{300}, predBkps: []int{300}, margin: 5, expectedPrecision: 1, expectedRecall: 1},
+		{name: "FalseAlarmOnly", trueBkps: []int{300}, predBkps: []int{150, 300}, margin: 5, expectedPrecision: 0, expectedRecall: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			precision, recall, err := metrics.PrecisionRecall(tc.trueBkps, tc.predBkps, tc.margin)
+			if err != nil {
+				t.Fatalf("PrecisionRecall() got unexpected error: %v", err)
+			}
+			if math.Abs(precision-tc.expectedPrecision) > floatTolerance || math.Abs(recall-tc.expectedRecall) > floatTolerance {
+				t.Errorf("PrecisionRecall() = (%f, %f); want (%f, %f)", precision, recall, tc.expectedPrecision, tc.expectedRecall)
+			}
+		})
+	}
+}
+
+func TestF1Score(t *testing.T) {
+	f1, err := metrics.F1Score([]int{100, 200, 300}, []int{50, 100, 300}, 5)
+	if err != nil {
+		t.Fatalf("F1Score() got unexpected error: %v", err)
+	}
+	// precision = 1/2, recall = 1/2.
+	if math.Abs(f1-0.5) > floatTolerance {
+		t.Errorf("F1Score() = %f; want 0.5", f1)
+	}
+}
+
+func TestHausdorff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		bkps1    []int
+		bkps2    []int
+		expected float64
+	}{
+		{name: "Identical", bkps1: []int{100, 200, 300}, bkps2: []int{100, 200, 300}, expected: 0},
+		{name: "Shifted", bkps1: []int{100, 200, 300}, bkps2: []int{105, 190, 300}, expected: 10},
+		{name: "ExtraChange", bkps1: []int{100, 300}, bkps2: []int{100, 250, 300}, expected: 150},
+		{name: "BothEmpty", bkps1: []int{300}, bkps2: []int{300}, expected: 0},
+		{name: "OneEmpty", bkps1: []int{300}, bkps2: []int{100, 300}, expected: math.Inf(1)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := metrics.Hausdorff(tc.bkps1, tc.bkps2)
+			if err != nil {
+				t.Fatalf("Hausdorff() got unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Hausdorff() = %f; want %f", got, tc.expected)
+			}
+		})
+	}
+}
+
+// bruteForceRandIndex compares segment labels over every pair of samples.
+func bruteForceRandIndex(bkps1, bkps2 []int) float64 {
+	labels := func(bkps []int) []int {
+		out := make([]int, bkps[len(bkps)-1])
+		start := 0
+		for k, end := range bkps {
+			for i := start; i < end; i++ {
+				out[i] = k
+			}
+			start = end
+		}
+		return out
+	}
+	l1, l2 := labels(bkps1), labels(bkps2)
+	agree, total := 0, 0
+	for i := range l1 {
+		for j := i + 1; j < len(l1); j++ {
+			if (l1[i] == l1[j]) == (l2[i] == l2[j]) {
+				agree++
+			}
+			total++
+		}
+	}
+	return float64(agree) / float64(total)
+}
+
+func TestRandIndex(t *testing.T) {
+	testCases := []struct {
+		name  string
+		bkps1 []int
+		bkps2 []int
+	}{
+		{name: "Identical", bkps1: []int{10, 20, 30}, bkps2: []int{10, 20, 30}},
+		{name: "Shifted", bkps1: []int{10, 20, 30}, bkps2: []int{12, 18, 30}},
+		{name: "DifferentCounts", bkps1: []int{5, 30}, bkps2: []int{3, 11, 17, 25, 30}},
+		{name: "NoChange", bkps1: []int{30}, bkps2: []int{15, 30}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := metrics.RandIndex(tc.bkps1, tc.bkps2)
+			if err != nil {
+				t.Fatalf("RandIndex() got unexpected error: %v", err)
+			}
+			if want := bruteForceRandIndex(tc.bkps1, tc.bkps2); math.Abs(got-want) > floatTolerance {
+				t.Errorf("RandIndex() = %f; want %f", got, want)
+			}
+		})
+	}
+}
+
+func TestAnnotationError(t *testing.T) {
+	got, err := metrics.AnnotationError([]int{100, 200, 300}, []int{50, 120, 250, 280, 300})
+	if err != nil {
+		t.Fatalf("AnnotationError() got unexpected error: %v", err)
+	}
+	if got != 2 {
+		t.Errorf("AnnotationError() = %d; want 2", got)
+	}
+}
+
+func TestPrecisionRecallInvalidMargin(t *testing.T) {
+	for _, margin := range []int{0, -5} {
+		if _, _, err := metrics.PrecisionRecall([]int{100, 200}, []int{100, 200}, margin); !errors.Is(err, metrics.ErrInvalidMargin) {
+			t.Errorf("PrecisionRecall() with margin %d: error = %v, want %v", margin, err, metrics.ErrInvalidMargin)
+		}
+	}
+	if _, err := metrics.F1Score([]int{100, 200}, []int{100, 200}, 0); !errors.Is(err, metrics.ErrInvalidMargin) {
+		t.Errorf("F1Score() with margin 0: error = %v, want %v", err, metrics.ErrInvalidMargin)
+	}
+}
+
+func TestMismatchedBreakpoints(t *testing.T) {
+	testCases := []struct {
+		name  string
+		bkps1 []int
+		bkps2 []int
+	}{
+		{name: "DifferentLengths", bkps1: []int{100, 300}, bkps2: []int{100, 200}},
+		{name: "Empty", bkps1: []int{}, bkps2: []int{100}},
+		{name: "Unsorted", bkps1: []int{200, 100, 300}, bkps2: []int{300}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := metrics.Hausdorff(tc.bkps1, tc.bkps2); !errors.Is(err, metrics.ErrBreakpointsMismatch) {
+				t.Errorf("Hausdorff() error = %v, want %v", err, metrics.ErrBreakpointsMismatch)
+			}
+			if _, _, err := metrics.PrecisionRecall(tc.bkps1, tc.bkps2, 5); !errors.Is(err, metrics.ErrBreakpointsMismatch) {
+				t.Errorf("PrecisionRecall() error = %v, want %v", err, metrics.ErrBreakpointsMismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+)
+
+// PrecisionRecall computes the precision and recall of the predicted change points.
+// A true change point counts as detected when a predicted change point lies strictly
+// within margin samples of it; each predicted change point can match at most one
+// true change point. The final breakpoint (n_samples) is ignored.
+//
+// Equivalent to ruptures.metrics.precision_recall.
+//
+// Parameters:
+//
+//	trueBkps: The reference breakpoints, ending with n_samples.
+//	predBkps: The predicted breakpoints, ending with n_samples.
+//	margin:   The tolerance, in samples. Must be positive.
+//
+// Returns:
+//
+//	float64: The precision, the fraction of predicted change points that are true.
+//	float64: The recall, the fraction of true change points that were detected.
+//	error:   ErrBreakpointsMismatch if the segmentations are invalid,
+//	         ErrInvalidMargin if margin is less than 1.
+//
+// When there is no predicted change point both values are 0, unless there is no
+// true change point either, in which case both are 1. When there is no true change
+// point but some were predicted, the recall is 1 and the precision 0.
+func PrecisionRecall(trueBkps, predBkps []int, margin int) (float64, float64, error) {
+	if margin < 1 {
+		return 0, 0, fmt.Errorf("%w, got %d", ErrInvalidMargin, margin)
+	}
+	if err := sanityCheck(trueBkps, predBkps); err != nil {
+		return 0, 0, err
+	}
+	trueCps, predCps := trueBkps[:len(trueBkps)-1], predBkps[:len(predBkps)-1]
+	switch {
+	case len(predCps) == 0 && len(trueCps) == 0:
+		return 1, 1, nil
+	case len(predCps) == 0:
+		return 0, 0, nil
+	case len(trueCps) == 0:
+		return 0, 1, nil
+	}
+
+	used := make([]bool, len(predCps))
+	truePositives := 0
+	for _, tb := range trueCps {
+		for j, pb := range predCps {
+			if !used[j] && pb-margin < tb && tb < pb+margin {
+				used[j] = true
+				truePositives++
+				break
+			}
+		}
+	}
+	precision := float64(truePositives) / float64(len(predCps))
+	recall := float64(truePositives) / float64(len(trueCps))
+	return precision, recall, nil
+}
+
+// F1Score returns the harmonic mean of PrecisionRecall's precision and recall,
+// or 0 when both are 0.
+func F1Score(trueBkps, predBkps []int, margin int) (float64, error) {
+	precision, recall, err := PrecisionRecall(trueBkps, predBkps, margin)
+	if err != nil {
+		return 0, err
+	}
+	if precision+recall == 0 {
+		return 0, nil
+	}
+	return 2 * precision * recall / (precision + recall), nil
+}
+
+// Hausdorff computes the Hausdorff distance between the two sets of change points:
+// the largest distance from a change point of one set to the closest change point
+// of the other. The final breakpoint (n_samples) is ignored.
+//
+// Equivalent to ruptures.metrics.hausdorff. The distance is 0 when neither
+// segmentation has change points and +Inf when only one of them has.
+func Hausdorff(trueBkps, predBkps []int) (float64, error) {
+	if err := sanityCheck(trueBkps, predBkps); err != nil {
+		return 0, err
+	}
+	a, b := trueBkps[:len(trueBkps)-1], predBkps[:len(predBkps)-1]
+	if len(a) == 0 && len(b) == 0 {
+		return 0, nil
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return math.Inf(1), nil
+	}
+	return math.Max(directedHausdorff(a, b), directedHausdorff(b, a)), nil
+}
+
+// directedHausdorff returns max over x in a of min over y in b of |x - y|.
+func directedHausdorff(a, b []int) float64 {
+	worst := 0
+	for _, x := range a {
+		best := math.MaxInt
+		for _, y := range b {
+			d := x - y
+			if d < 0 {
+				d = -d
+			}
+			best = min(best, d)
+		}
+		worst = max(worst, best)
+	}
+	return float64(worst)
+}
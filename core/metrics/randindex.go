@@ -0,0 +1,54 @@
+package metrics
+
+// RandIndex computes the Rand index between two segmentations: the fraction of
+// pairs of samples on which both segmentations agree (both in the same segment,
+// or both in different segments). It equals 1 for identical segmentations.
+//
+// Equivalent to ruptures.metrics.randindex. The computation uses the overlaps
+// between segments and runs in O(K1 + K2) for K1 and K2 segments.
+func RandIndex(bkps1, bkps2 []int) (float64, error) {
+	if err := sanityCheck(bkps1, bkps2); err != nil {
+		return 0, err
+	}
+	nSamples := bkps1[len(bkps1)-1]
+	if nSamples < 2 {
+		return 1, nil
+	}
+
+	pairs := func(n int) float64 { return float64(n) * float64(n-1) / 2 }
+
+	// disagreements = sum_i C(a_i, 2) + sum_j C(b_j, 2) - 2 sum_ij C(n_ij, 2),
+	// where a_i, b_j are segment lengths and n_ij the overlap of segments i and j.
+	disagreements := 0.0
+	start := 0
+	for _, end := range bkps1 {
+		disagreements += pairs(end - start)
+		start = end
+	}
+	start = 0
+	for _, end := range bkps2 {
+		disagreements += pairs(end - start)
+		start = end
+	}
+
+	i, j := 0, 0
+	start1, start2 := 0, 0
+	for i < len(bkps1) && j < len(bkps2) {
+		overlap := min(bkps1[i], bkps2[j]) - max(start1, start2)
+		if overlap > 0 {
+			disagreements -= 2 * pairs(overlap)
+		}
+		if bkps1[i] < bkps2[j] {
+			start1 = bkps1[i]
+			i++
+		} else if bkps2[j] < bkps1[i] {
+			start2 = bkps2[j]
+			j++
+		} else {
+			start1, start2 = bkps1[i], bkps2[j]
+			i++
+			j++
+		}
+	}
+	return 1 - disagreements/pairs(nSamples), nil
+}
@@ -0,0 +1,301 @@
+// Package multires detects change points in long signals coarse to fine.
+//
+// A Pipeline averages the signal over blocks of Factor samples, Levels times,
+// runs any detector on the coarsest signal, then walks back down the levels: at
+// each level the breakpoints are scaled to its resolution and relocated within
+// ± Width samples by a refine.Refiner. A change inside a coarse block is thus
+// located to the sample in the original signal, while the detector only sees
+// n / Factor^Levels samples.
+//
+// Each relocation fits a fresh cost function on a local window of the level
+// around the breakpoint, Width + Margin samples on each side and never beyond
+// its neighbours, so memory stays bounded by the window even for costs that
+// are quadratic in the length of their signal (rbf). Changes shorter than a
+// coarse block may be missed by the detector.
+//
+// The detector sees block means, whose noise variance is Factor^Levels times
+// smaller than that of the original signal; the DetectFunc adapts its penalty,
+// minimum segment length and jump to the coarse signal (see DetectFunc). A block
+// straddling a change has a mean between the two levels, which the detector may
+// isolate in a segment of its own at any reasonable penalty: the coarse detection
+// is best seen as proposing candidates.
+//
+// With a Penalty, the pipeline finally removes, one at a time, the breakpoints
+// whose removal decreases the penalized cost of the original signal, so that the
+// result is a local optimum of the objective of a full-resolution detection.
+// Unlike the relocations, pruning fits the cost on two whole segments at a time,
+// which is expensive for the rbf cost on long segments.
+//
+// Block means suit the costs of continuous values (l2, l1, rbf), not the entropy
+// cost of discrete values. The ruptures command only accepts the l2 cost, the
+// only one whose penalty scales simply with the block length.
+package multires
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/refine"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// DetectFunc detects change points in the coarse signal and returns its
+// breakpoints, ending with the number of samples of the coarse signal.
+//
+// The pipeline passes the block means as they are: the function sets the
+// penalty, minimum segment length and jump of its detector for the coarse
+// signal. With the l2 cost, the means of blocks of b samples have b times less
+// noise variance, so a penalty tuned on the original signal is divided by b, and
+// the minimum segment length and jump are divided by b rounded up.
+type DetectFunc func(ctx context.Context, coarse types.Matrix) ([]int, error)
+
+// Pipeline is a coarse-to-fine detection (see the package documentation).
+type Pipeline struct {
+	Factor  int // Number of samples averaged into one at each level, at least 2.
+	Levels  int // Number of downsampling levels, at least 1.
+	Width   int // Half-width of the search window at each level, in samples of the level; 0 uses Factor.
+	Margin  int // Samples fitted on each side of the search window; 0 uses 2 * Width.
+	MinSize int // Minimum length of a segment in the original signal.
+	// Penalty of a change point in the original signal; breakpoints whose removal
+	// decreases the penalized cost are removed. 0 keeps all the breakpoints.
+	Penalty float64
+	// NewCost returns the unfitted cost function relocating the breakpoints.
+	NewCost func() (base.CostFunction, error)
+}
+
+// NewPipeline returns a Pipeline with the default width and margin.
+//
+// Parameters:
+//
+//	factor:  Number of samples averaged into one at each level.
+//	levels:  Number of downsampling levels.
+//	minSize: Minimum length of a segment in the original signal.
+//	newCost: Returns the unfitted cost function relocating the breakpoints.
+func NewPipeline(factor, levels, minSize int, newCost func() (base.CostFunction, error)) *Pipeline {
+	return &Pipeline{Factor: factor, Levels: levels, MinSize: minSize, NewCost: newCost}
+}
+
+// Downsample returns the means of the consecutive blocks of factor samples of
+// the signal. The last block holds the remaining samples when factor does not
+// divide the number of samples, so the result has ⌈n / factor⌉ samples.
+func Downsample(signal types.Matrix, factor int) (types.Matrix, error) {
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return nil, exceptions.ErrInvalidSignal
+	}
+	if factor < 1 {
+		return nil, fmt.Errorf("multires: factor must be at least 1, got %d", factor)
+	}
+	nFeatures := len(signal[0])
+	coarse := make(types.Matrix, (len(signal)+factor-1)/factor)
+	for i := range coarse {
+		block := signal[i*factor : min((i+1)*factor, len(signal))]
+		row := make([]float64, nFeatures)
+		for _, sample := range block {
+			for j, v := range sample {
+				row[j] += v
+			}
+		}
+		for j := range row {
+			row[j] /= float64(len(block))
+		}
+		coarse[i] = row
+	}
+	return coarse, nil
+}
+
+// Run is RunContext with context.Background().
+func (p *Pipeline) Run(signal types.Matrix, detect DetectFunc) ([]int, error) {
+	return p.RunContext(context.Background(), signal, detect)
+}
+
+// RunContext detects the change points of the signal coarse to fine.
+//
+// Parameters:
+//
+//	signal: The original signal.
+//	detect: Detects the change points of the coarsest signal.
+//
+// Returns:
+//
+//	The breakpoints in the original signal, ending with its number of samples, or
+//	an error if the parameters are invalid, the detection or a cost fails, or ctx
+//	is done.
+func (p *Pipeline) RunContext(ctx context.Context, signal types.Matrix, detect DetectFunc) ([]int, error) {
+	if p.Factor < 2 {
+		return nil, fmt.Errorf("multires: factor must be at least 2, got %d", p.Factor)
+	}
+	if p.Levels < 1 {
+		return nil, fmt.Errorf("multires: levels must be at least 1, got %d", p.Levels)
+	}
+	if p.Width < 0 || p.Margin < 0 {
+		return nil, fmt.Errorf("multires: width and margin must be non-negative, got %d and %d", p.Width, p.Margin)
+	}
+	if p.MinSize < 1 {
+		return nil, fmt.Errorf("multires: min_size must be at least 1, got %d", p.MinSize)
+	}
+	if p.Penalty < 0 {
+		return nil, fmt.Errorf("multires: penalty must be non-negative, got %g", p.Penalty)
+	}
+	if p.NewCost == nil {
+		return nil, fmt.Errorf("multires: NewCost must not be nil")
+	}
+
+	// levels[l] is the signal averaged over blocks of Factor^l samples.
+	levels := []types.Matrix{signal}
+	for l := 1; l <= p.Levels; l++ {
+		coarse, err := Downsample(levels[l-1], p.Factor)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, coarse)
+	}
+	bkps, err := detect(ctx, levels[p.Levels])
+	if err != nil {
+		return nil, err
+	}
+	if err := base.CheckBreakpoints(levels[p.Levels], bkps); err != nil {
+		return nil, fmt.Errorf("multires: coarse detection: %w", err)
+	}
+
+	bkps = append([]int(nil), bkps...)
+	scale := 1
+	for l := 1; l < p.Levels; l++ {
+		scale *= p.Factor
+	}
+	for l := p.Levels - 1; l >= 0; l-- {
+		level := levels[l]
+		for i := range bkps {
+			bkps[i] = min(bkps[i]*p.Factor, len(level))
+		}
+		// Minimum length of a segment in samples of the level.
+		minSize := max(1, (p.MinSize+scale-1)/scale)
+		if err := p.refineLevel(ctx, level, bkps, minSize); err != nil {
+			return nil, err
+		}
+		scale /= p.Factor
+	}
+	if p.Penalty == 0 {
+		return bkps, nil
+	}
+	pruned, err := p.prune(ctx, signal, bkps)
+	if err != nil || len(pruned) == len(bkps) {
+		return pruned, err
+	}
+	// The remaining breakpoints were relocated next to the removed ones.
+	if err := p.refineLevel(ctx, signal, pruned, p.MinSize); err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
+
+// prune removes the breakpoint whose removal decreases the penalized cost the
+// most, C(left, right) - C(left, b) - C(b, right) < Penalty, until none does. The
+// cost is fitted on the two segments around each breakpoint.
+func (p *Pipeline) prune(ctx context.Context, signal types.Matrix, bkps []int) ([]int, error) {
+	bkps = append([]int(nil), bkps...)
+	// gains[i] is the increase of the cost when bkps[i] is removed.
+	gains := make([]float64, len(bkps)-1)
+	for i := range gains {
+		g, err := p.gain(signal, bkps, i)
+		if err != nil {
+			return nil, err
+		}
+		gains[i] = g
+	}
+	for len(gains) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		worst := 0
+		for i, g := range gains {
+			if g < gains[worst] {
+				worst = i
+			}
+		}
+		if gains[worst] >= p.Penalty {
+			break
+		}
+		bkps = append(bkps[:worst], bkps[worst+1:]...)
+		gains = append(gains[:worst], gains[worst+1:]...)
+		for _, i := range []int{worst - 1, worst} {
+			if i < 0 || i >= len(gains) {
+				continue
+			}
+			g, err := p.gain(signal, bkps, i)
+			if err != nil {
+				return nil, err
+			}
+			gains[i] = g
+		}
+	}
+	return bkps, nil
+}
+
+// gain returns the increase of the cost of the signal when bkps[i] is removed.
+func (p *Pipeline) gain(signal types.Matrix, bkps []int, i int) (float64, error) {
+	left := 0
+	if i > 0 {
+		left = bkps[i-1]
+	}
+	b, right := bkps[i]-left, bkps[i+1]-left
+	c, err := p.NewCost()
+	if err != nil {
+		return 0, err
+	}
+	if err := c.Fit(signal[left:bkps[i+1]]); err != nil {
+		return 0, fmt.Errorf("multires: fitting the cost on [%d, %d): %w", left, bkps[i+1], err)
+	}
+	merged, err := c.Error(0, right)
+	if err != nil {
+		return 0, err
+	}
+	l, err := c.Error(0, b)
+	if err != nil {
+		return 0, err
+	}
+	r, err := c.Error(b, right)
+	if err != nil {
+		return 0, err
+	}
+	return merged - l - r, nil
+}
+
+// refineLevel relocates every breakpoint of bkps, in place, from left to right,
+// fitting a cost on a window of the level around each one.
+func (p *Pipeline) refineLevel(ctx context.Context, level types.Matrix, bkps []int, minSize int) error {
+	width := p.Width
+	if width == 0 {
+		width = p.Factor
+	}
+	margin := p.Margin
+	if margin == 0 {
+		margin = 2 * width
+	}
+	refiner := &refine.Refiner{Width: width, MinSize: minSize, Sweeps: 1}
+	for i := 0; i < len(bkps)-1; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		left := 0
+		if i > 0 {
+			left = bkps[i-1]
+		}
+		lo, hi := max(left, bkps[i]-width-margin), min(bkps[i+1], bkps[i]+width+margin)
+		window := level[lo:hi]
+		c, err := p.NewCost()
+		if err != nil {
+			return err
+		}
+		if err := c.Fit(window); err != nil {
+			return fmt.Errorf("multires: fitting the cost on [%d, %d): %w", lo, hi, err)
+		}
+		refined, err := refiner.RefineContext(ctx, window, c, []int{bkps[i] - lo, hi - lo})
+		if err != nil {
+			return err
+		}
+		bkps[i] = lo + refined[0]
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package multires_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/datasets"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/multires"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// newCost returns a function building the cost of the model.
+func newCost(model string) func() (base.CostFunction, error) {
+	return func() (base.CostFunction, error) { return cost.NewCost(model) }
+}
+
+// peltDetect returns a DetectFunc running Pelt with the model and penalty.
+func peltDetect(model string, penalty float64) multires.DetectFunc {
+	return func(ctx context.Context, coarse types.Matrix) ([]int, error) {
+		c, err := cost.NewCost(model)
+		if err != nil {
+			return nil, err
+		}
+		p := pelt.NewPelt(c, 2, 1)
+		if err := p.Fit(coarse); err != nil {
+			return nil, err
+		}
+		return p.PredictContext(ctx, penalty)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	signal := types.Matrix{{1, 10}, {3, 20}, {5, 30}, {7, 40}, {9, 50}}
+	tests := []struct {
+		factor int
+		want   types.Matrix
+	}{
+		{factor: 1, want: signal},
+		{factor: 2, want: types.Matrix{{2, 15}, {6, 35}, {9, 50}}},
+		{factor: 5, want: types.Matrix{{5, 30}}},
+		{factor: 8, want: types.Matrix{{5, 30}}},
+	}
+	for _, tt := range tests {
+		got, err := multires.Downsample(signal, tt.factor)
+		if err != nil {
+			t.Fatalf("Downsample(%d) failed: %v", tt.factor, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Downsample(%d) = %v, want %v", tt.factor, got, tt.want)
+		}
+	}
+	if _, err := multires.Downsample(signal, 0); err == nil {
+		t.Error("expected an error for factor 0")
+	}
+	if _, err := multires.Downsample(types.Matrix{}, 2); err == nil {
+		t.Error("expected an error for an empty signal")
+	}
+}
+
+func TestPipelineMatchesFullResolution(t *testing.T) {
+	// The coarse-to-fine pipeline finds the breakpoints of a full-resolution Pelt
+	// run, whose detector only sees a hundredth of the samples with two levels.
+	params := datasets.DefaultParams()
+	params.NSamples, params.NBkps, params.NoiseStd, params.Seed = 20000, 6, 1, 1
+	signal, _, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	want, err := peltDetect("l2", 100)(context.Background(), signal)
+	if err != nil {
+		t.Fatalf("Pelt failed: %v", err)
+	}
+	tests := []struct {
+		name     string
+		pipeline *multires.Pipeline
+		penalty  float64 // Penalty of the coarse detection.
+		prune    bool
+	}{
+		// The noise variance of the coarse signal is divided by the block length; the
+		// breakpoints isolating blocks that straddle a change are pruned with the
+		// penalty of the full-resolution run.
+		{name: "OneLevel", pipeline: multires.NewPipeline(10, 1, 2, newCost("l2")), penalty: 10, prune: true},
+		{name: "TwoLevels", pipeline: multires.NewPipeline(10, 2, 2, newCost("l2")), penalty: 1, prune: true},
+		{name: "L1", pipeline: multires.NewPipeline(10, 1, 2, newCost("l1")), penalty: 10, prune: true},
+		// Pruning would fit the rbf cost on whole segments; a larger penalty avoids
+		// isolated blocks instead.
+		{name: "Rbf", pipeline: multires.NewPipeline(8, 2, 2, newCost("rbf")), penalty: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prune {
+				tt.pipeline.Penalty = 100
+			}
+			got, err := tt.pipeline.Run(signal, peltDetect("l2", tt.penalty))
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("breakpoints = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	params := datasets.DefaultParams()
+	signal, bkps, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	detect := peltDetect("l2", 1)
+	errDetect := errors.New("detection failed")
+	tests := []struct {
+		name     string
+		pipeline *multires.Pipeline
+		detect   multires.DetectFunc
+	}{
+		{name: "Factor", pipeline: multires.NewPipeline(1, 1, 2, newCost("l2")), detect: detect},
+		{name: "Levels", pipeline: multires.NewPipeline(2, 0, 2, newCost("l2")), detect: detect},
+		{name: "Width", pipeline: &multires.Pipeline{Factor: 2, Levels: 1, Width: -1, MinSize: 2, NewCost: newCost("l2")}, detect: detect},
+		{name: "MinSize", pipeline: multires.NewPipeline(2, 1, 0, newCost("l2")), detect: detect},
+		{name: "Penalty", pipeline: &multires.Pipeline{Factor: 2, Levels: 1, MinSize: 2, Penalty: -1, NewCost: newCost("l2")}, detect: detect},
+		{name: "NewCost", pipeline: multires.NewPipeline(2, 1, 2, nil), detect: detect},
+		{name: "UnknownCost", pipeline: multires.NewPipeline(2, 1, 2, newCost("l3")), detect: detect},
+		{name: "Detect", pipeline: multires.NewPipeline(2, 1, 2, newCost("l2")), detect: func(context.Context, types.Matrix) ([]int, error) {
+			return nil, errDetect
+		}},
+		// Breakpoints of the original signal instead of the coarse one.
+		{name: "Breakpoints", pipeline: multires.NewPipeline(2, 1, 2, newCost("l2")), detect: func(context.Context, types.Matrix) ([]int, error) {
+			return bkps, nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.pipeline.Run(signal, tt.detect); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := multires.NewPipeline(2, 1, 2, newCost("l2")).RunContext(ctx, signal, detect); !errors.Is(err, context.Canceled) {
+		t.Errorf("RunContext error = %v, want %v", err, context.Canceled)
+	}
+}
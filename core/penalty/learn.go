@@ -0,0 +1,535 @@
+package penalty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Objectives of the penalty learning, as accepted by LearnOptions.Objective.
+const (
+	// ObjectiveF1 maximizes the F1 score of the detected change points (with
+	// LearnOptions.Margin as tolerance); the loss of a signal is 1 - F1.
+	ObjectiveF1 = "f1"
+	// ObjectiveAnnotationError minimizes |K_pred - K_true|, the annotation error.
+	ObjectiveAnnotationError = "annotation_error"
+)
+
+// Features of a signal on which LearnLinear regresses the log-penalty, in the
+// order of Model.Weights.
+const (
+	FeatureLogNSamples = "log_n_samples" // log(n_samples).
+	FeatureLogVariance = "log_variance"  // log(sigma²), with sigma estimated by EstimateSigma; see noiseVariance.
+)
+
+// minRelativeVariance is the floor of the noise variance of FeatureLogVariance,
+// relative to the variance of the signal values, so that noiseless signals (whose
+// estimated noise level is zero) keep a finite feature.
+const minRelativeVariance = 1e-6
+
+// logMargin is the margin, in log-penalty units, that LearnLinear keeps between its
+// predictions and the ends of the target intervals when they are wide enough.
+const logMargin = 0.5
+
+// ErrUnknownObjective is returned when LearnOptions.Objective is not one of Objectives().
+var ErrUnknownObjective = errors.New("penalty: unknown objective")
+
+// Objectives returns the names of the supported learning objectives.
+func Objectives() []string {
+	return []string{ObjectiveF1, ObjectiveAnnotationError}
+}
+
+// Example is an annotated signal: a signal with the change points an operator labeled.
+type Example struct {
+	Name        string       // Optional name, used in error messages.
+	Signal      types.Matrix // Signal, one sample per row.
+	Breakpoints []int        // True breakpoints; the last one is n_samples.
+}
+
+// LearnOptions configures LearnGlobal and LearnLinear.
+type LearnOptions struct {
+	Objective string // One of Objectives(); defaults to ObjectiveF1.
+	Margin    int    // Tolerance, in samples, of the F1 score; defaults to 10.
+	Cost      string // Cost model of the detector (cost.NewCost); defaults to "l2".
+	MinSize   int    // Minimum segment length of the detector; defaults to 2.
+	Jump      int    // Subsample step of the detector; defaults to 1.
+}
+
+// DefaultLearnOptions returns the options used for the zero LearnOptions fields.
+func DefaultLearnOptions() LearnOptions {
+	return LearnOptions{Objective: ObjectiveF1, Margin: 10, Cost: "l2", MinSize: 2, Jump: 1}
+}
+
+// withDefaults fills the zero fields of o with DefaultLearnOptions and validates them.
+func (o LearnOptions) withDefaults() (LearnOptions, error) {
+	def := DefaultLearnOptions()
+	if o.Objective == "" {
+		o.Objective = def.Objective
+	}
+	o.Objective = strings.ToLower(o.Objective)
+	if o.Objective != ObjectiveF1 && o.Objective != ObjectiveAnnotationError {
+		return o, fmt.Errorf("%w %q (available: %s)", ErrUnknownObjective, o.Objective, strings.Join(Objectives(), ", "))
+	}
+	if o.Margin == 0 {
+		o.Margin = def.Margin
+	}
+	if o.Cost == "" {
+		o.Cost = def.Cost
+	}
+	if o.MinSize == 0 {
+		o.MinSize = def.MinSize
+	}
+	if o.Jump == 0 {
+		o.Jump = def.Jump
+	}
+	if o.Margin < 1 || o.MinSize < 1 || o.Jump < 1 {
+		return o, fmt.Errorf("penalty: margin, min size and jump must be positive, got %d, %d and %d", o.Margin, o.MinSize, o.Jump)
+	}
+	return o, nil
+}
+
+// Model predicts a penalty from the features of a signal:
+//
+//	log(penalty) = Intercept + Σ Weights[i] · feature_i(signal)
+//
+// with the features named by Features. A model without weights is a single
+// global penalty, exp(Intercept). Models marshal to and from JSON.
+type Model struct {
+	Objective string    `json:"objective"`
+	Cost      string    `json:"cost"`               // Cost model the penalty was learned for.
+	MinSize   int       `json:"min_size,omitempty"` // Minimum segment length the penalty was learned with.
+	Jump      int       `json:"jump,omitempty"`     // Subsample step the penalty was learned with.
+	Intercept float64   `json:"intercept"`
+	Features  []string  `json:"features,omitempty"`
+	Weights   []float64 `json:"weights,omitempty"`
+	// Loss is the mean loss of the model on the training examples: 1 - F1 for
+	// ObjectiveF1, the annotation error for ObjectiveAnnotationError.
+	Loss float64 `json:"train_loss"`
+}
+
+// Penalty returns the penalty the model predicts for the signal.
+func (m Model) Penalty(signal types.Matrix) (float64, error) {
+	if len(m.Weights) != len(m.Features) {
+		return 0, fmt.Errorf("penalty: model has %d weights for %d features", len(m.Weights), len(m.Features))
+	}
+	logPen := m.Intercept
+	if len(m.Weights) > 0 {
+		features, err := SignalFeatures(signal, m.Features)
+		if err != nil {
+			return 0, err
+		}
+		for i, w := range m.Weights {
+			logPen += w * features[i]
+		}
+	}
+	pen := math.Exp(logPen)
+	if !(pen > 0) || math.IsInf(pen, 0) {
+		return 0, fmt.Errorf("penalty: model predicts an invalid penalty %g", pen)
+	}
+	return pen, nil
+}
+
+// SignalFeatures computes the named features (FeatureLogNSamples, FeatureLogVariance) of a signal.
+func SignalFeatures(signal types.Matrix, names []string) ([]float64, error) {
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return nil, exceptions.ErrInvalidSignal
+	}
+	features := make([]float64, len(names))
+	for i, name := range names {
+		switch name {
+		case FeatureLogNSamples:
+			features[i] = math.Log(float64(len(signal)))
+		case FeatureLogVariance:
+			variance, err := noiseVariance(signal)
+			if err != nil {
+				return nil, err
+			}
+			features[i] = math.Log(variance)
+		default:
+			return nil, fmt.Errorf("penalty: unknown feature %q", name)
+		}
+	}
+	return features, nil
+}
+
+// noiseVariance returns sigma², with sigma estimated by EstimateSigmas, floored at
+// minRelativeVariance times the variance of the signal values: the estimate is
+// zero on noiseless piecewise-constant signals.
+func noiseVariance(signal types.Matrix) (float64, error) {
+	sigmas, err := EstimateSigmas(signal)
+	if err != nil {
+		return 0, err
+	}
+	var variance float64
+	for _, s := range sigmas {
+		variance += s * s
+	}
+	variance /= float64(len(sigmas))
+
+	var spread float64
+	for j := range sigmas {
+		var sum, sumSquares float64
+		for _, row := range signal {
+			sum += row[j]
+			sumSquares += row[j] * row[j]
+		}
+		mean := sum / float64(len(signal))
+		spread += math.Max(sumSquares/float64(len(signal))-mean*mean, 0)
+	}
+	spread /= float64(len(sigmas))
+	variance = math.Max(variance, minRelativeVariance*spread)
+	if variance == 0 {
+		return 0, errors.New("penalty: the signal is constant; its noise variance is undefined")
+	}
+	return variance, nil
+}
+
+// errorCurve is the loss of the detector on one example as a function of the
+// log-penalty: the loss is losses[i] on [logPens[i-1], logPens[i]], with
+// logPens[-1] = -Inf and logPens[len(logPens)] = +Inf.
+type errorCurve struct {
+	logPens []float64 // Increasing log-penalties where the segmentation changes.
+	losses  []float64 // len(logPens) + 1 losses.
+}
+
+// lossAt returns the loss of the curve at the log-penalty x.
+func (c errorCurve) lossAt(x float64) float64 {
+	return c.losses[sort.SearchFloat64s(c.logPens, x)]
+}
+
+// computeCurve runs CROPS on the example and scores every segmentation of the
+// solution path. The penalty range goes from a hundredth of the estimated noise
+// variance (or a millionth of the cost of the whole signal) to just above the cost
+// of the whole signal, beyond which no change point is ever detected. Below the
+// range, the loss is taken to stay that of the smallest penalty.
+func computeCurve(ctx context.Context, ex Example, opts LearnOptions) (errorCurve, error) {
+	c, err := cost.NewCost(opts.Cost)
+	if err != nil {
+		return errorCurve{}, err
+	}
+	detector := pelt.NewPelt(c, opts.MinSize, opts.Jump)
+	if err := detector.Fit(ex.Signal); err != nil {
+		return errorCurve{}, err
+	}
+	total, err := c.Error(0, len(ex.Signal))
+	if err != nil {
+		return errorCurve{}, err
+	}
+	penMax := 2*total + 1
+	penMin := penMax * 1e-6
+	if sigma, err := EstimateSigma(ex.Signal); err == nil && sigma*sigma/100 < penMax {
+		penMin = sigma * sigma / 100
+	}
+	segs, err := detector.CropsContext(ctx, penMin, penMax)
+	if err != nil {
+		return errorCurve{}, err
+	}
+
+	// CROPS sorts the segmentations by increasing penalty.
+	curve := errorCurve{logPens: make([]float64, 0, len(segs)-1), losses: make([]float64, len(segs))}
+	for i, s := range segs {
+		if curve.losses[i], err = exampleLoss(ex.Breakpoints, s.Breakpoints, opts); err != nil {
+			return errorCurve{}, err
+		}
+		if i > 0 {
+			curve.logPens = append(curve.logPens, math.Log(s.PenaltyMin))
+		}
+	}
+	return curve, nil
+}
+
+// exampleLoss returns the loss of a predicted segmentation for the objective.
+func exampleLoss(trueBkps, predBkps []int, opts LearnOptions) (float64, error) {
+	if opts.Objective == ObjectiveAnnotationError {
+		e, err := metrics.AnnotationError(trueBkps, predBkps)
+		return float64(e), err
+	}
+	f1, err := metrics.F1Score(trueBkps, predBkps, opts.Margin)
+	return 1 - f1, err
+}
+
+// exampleName returns the name of the i-th example, or its index when it has none.
+func exampleName(ex Example, i int) string {
+	if ex.Name == "" {
+		return fmt.Sprint(i)
+	}
+	return ex.Name
+}
+
+// computeCurves computes the error curves of all examples.
+func computeCurves(ctx context.Context, examples []Example, opts LearnOptions) ([]errorCurve, error) {
+	if len(examples) == 0 {
+		return nil, errors.New("penalty: no training examples")
+	}
+	curves := make([]errorCurve, len(examples))
+	for i, ex := range examples {
+		name := exampleName(ex, i)
+		if len(ex.Breakpoints) == 0 || ex.Breakpoints[len(ex.Breakpoints)-1] != len(ex.Signal) {
+			return nil, fmt.Errorf("penalty: example %s: breakpoints must end with n_samples (%d): %w",
+				name, len(ex.Signal), metrics.ErrBreakpointsMismatch)
+		}
+		curve, err := computeCurve(ctx, ex, opts)
+		if err != nil {
+			return nil, fmt.Errorf("penalty: example %s: %w", name, err)
+		}
+		curves[i] = curve
+	}
+	return curves, nil
+}
+
+// bestInterval returns the widest run of consecutive elementary intervals of the
+// log-penalty axis (delimited by cuts) on which loss is minimal, with that loss.
+// The ends of the run may be infinite.
+func bestInterval(cuts []float64, loss func(x float64) float64) (lo, hi, best float64) {
+	// The elementary intervals are (-Inf, cuts[0]], ..., [cuts[len-1], +Inf).
+	at := func(i int) float64 {
+		switch {
+		case len(cuts) == 0:
+			return 0
+		case i == 0:
+			return cuts[0] - 1
+		case i == len(cuts):
+			return cuts[len(cuts)-1] + 1
+		default:
+			return (cuts[i-1] + cuts[i]) / 2
+		}
+	}
+	bound := func(i int) float64 { // Left end of elementary interval i.
+		if i == 0 {
+			return math.Inf(-1)
+		}
+		if i > len(cuts) {
+			return math.Inf(1)
+		}
+		return cuts[i-1]
+	}
+	losses := make([]float64, len(cuts)+1)
+	best = math.Inf(1)
+	for i := range losses {
+		losses[i] = loss(at(i))
+		best = math.Min(best, losses[i])
+	}
+	lo, hi = math.NaN(), math.NaN()
+	for i := 0; i < len(losses); {
+		if losses[i] > best {
+			i++
+			continue
+		}
+		j := i
+		for j < len(losses) && losses[j] <= best {
+			j++
+		}
+		if l, h := bound(i), bound(j); math.IsNaN(lo) || h-l > hi-lo {
+			lo, hi = l, h
+		}
+		i = j
+	}
+	return lo, hi, best
+}
+
+// intervalCenter returns a representative log-penalty of [lo, hi]: its middle,
+// or a decade inside its finite end when the other one is infinite.
+func intervalCenter(lo, hi float64) float64 {
+	switch {
+	case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+		return 0
+	case math.IsInf(lo, -1):
+		return hi - math.Ln10
+	case math.IsInf(hi, 1):
+		return lo + math.Ln10
+	default:
+		return (lo + hi) / 2
+	}
+}
+
+// uniqueCuts merges the change points of all curves into a sorted slice without duplicates.
+func uniqueCuts(curves []errorCurve) []float64 {
+	var cuts []float64
+	for _, c := range curves {
+		cuts = append(cuts, c.logPens...)
+	}
+	sort.Float64s(cuts)
+	out := cuts[:0]
+	for i, x := range cuts {
+		if i == 0 || x > out[len(out)-1] {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// LearnGlobal learns a single penalty from annotated signals: the penalty that
+// minimizes the mean loss of the objective over the examples.
+//
+// The loss of every example is known exactly as a function of the penalty, since
+// CROPS (pelt.Pelt.Crops) gives every optimal segmentation with its penalty
+// interval. Among the penalties with the minimal mean loss, the widest interval is
+// kept and its geometric middle returned, which is the most robust choice.
+//
+// Parameters:
+//
+//	examples: The annotated signals.
+//	opts:     The objective and the detector settings; zero fields take their defaults.
+//
+// Returns:
+//
+//	Model: A model without features; Model.Penalty returns the learned penalty.
+//	error: ErrUnknownObjective, or an error describing an invalid example.
+func LearnGlobal(examples []Example, opts LearnOptions) (Model, error) {
+	return LearnGlobalContext(context.Background(), examples, opts)
+}
+
+// LearnGlobalContext is like LearnGlobal, but aborts with ctx.Err() once ctx is done.
+func LearnGlobalContext(ctx context.Context, examples []Example, opts LearnOptions) (Model, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return Model{}, err
+	}
+	curves, err := computeCurves(ctx, examples, opts)
+	if err != nil {
+		return Model{}, err
+	}
+	lo, hi, best := bestInterval(uniqueCuts(curves), func(x float64) float64 { return meanLoss(curves, x) })
+	return Model{Objective: opts.Objective, Cost: opts.Cost, MinSize: opts.MinSize, Jump: opts.Jump, Intercept: intervalCenter(lo, hi), Loss: best}, nil
+}
+
+// meanLoss returns the mean loss of the curves at the log-penalty x.
+func meanLoss(curves []errorCurve, x float64) float64 {
+	var sum float64
+	for _, c := range curves {
+		sum += c.lossAt(x)
+	}
+	return sum / float64(len(curves))
+}
+
+// LearnLinear learns a linear model of the log-penalty on the signal features
+// FeatureLogNSamples and FeatureLogVariance, so that the penalty adapts to the
+// length and the noise level of every signal.
+//
+// For every example, the target is the widest interval of log-penalties with the
+// minimal loss, computed exactly with CROPS. The model is fitted by max-margin
+// interval regression (Rigaill et al., 2013): the squared hinge loss of the
+// predictions falling outside the targets, with a small ridge on the weights, is
+// minimized by gradient descent from the LearnGlobal solution. The resulting
+// Model.Loss is the mean loss of its predicted penalties on the examples.
+func LearnLinear(examples []Example, opts LearnOptions) (Model, error) {
+	return LearnLinearContext(context.Background(), examples, opts)
+}
+
+// LearnLinearContext is like LearnLinear, but aborts with ctx.Err() once ctx is done.
+func LearnLinearContext(ctx context.Context, examples []Example, opts LearnOptions) (Model, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return Model{}, err
+	}
+	curves, err := computeCurves(ctx, examples, opts)
+	if err != nil {
+		return Model{}, err
+	}
+	names := []string{FeatureLogNSamples, FeatureLogVariance}
+	nFeatures := len(names)
+
+	// Targets and standardized features of every example.
+	los, his := make([]float64, len(curves)), make([]float64, len(curves))
+	x := make([][]float64, len(curves))
+	for i, c := range curves {
+		los[i], his[i], _ = bestInterval(c.logPens, c.lossAt)
+		if x[i], err = SignalFeatures(examples[i].Signal, names); err != nil {
+			return Model{}, fmt.Errorf("penalty: example %s: %w", exampleName(examples[i], i), err)
+		}
+	}
+	means, scales := make([]float64, nFeatures), make([]float64, nFeatures)
+	for j := range names {
+		for i := range x {
+			means[j] += x[i][j]
+		}
+		means[j] /= float64(len(x))
+		for i := range x {
+			scales[j] += (x[i][j] - means[j]) * (x[i][j] - means[j])
+		}
+		scales[j] = math.Sqrt(scales[j] / float64(len(x)))
+	}
+	z := make([][]float64, len(x))
+	for i := range x {
+		z[i] = make([]float64, nFeatures)
+		for j := range names {
+			if scales[j] > 0 {
+				z[i][j] = (x[i][j] - means[j]) / scales[j]
+			}
+		}
+	}
+
+	globalLo, globalHi, _ := bestInterval(uniqueCuts(curves), func(v float64) float64 { return meanLoss(curves, v) })
+	intercept := intervalCenter(globalLo, globalHi)
+	weights := make([]float64, nFeatures)
+	const (
+		ridge      = 1e-3
+		iterations = 5000
+	)
+	// The standardized features have unit variance, so the gradient of the
+	// objective is Lipschitz with a constant of at most 2 · (nFeatures + 1) + ridge.
+	step := 1 / (2*float64(nFeatures+1) + ridge)
+	gradW := make([]float64, nFeatures)
+	for it := 0; it < iterations; it++ {
+		if it%100 == 0 {
+			if err := ctx.Err(); err != nil {
+				return Model{}, err
+			}
+		}
+		gradB := 0.0
+		for j := range gradW {
+			gradW[j] = ridge * weights[j]
+		}
+		for i := range z {
+			f := intercept
+			for j, w := range weights {
+				f += w * z[i][j]
+			}
+			margin := math.Min(logMargin, (his[i]-los[i])/2)
+			g := 0.0
+			if d := los[i] + margin - f; d > 0 {
+				g -= 2 * d
+			}
+			if d := f - his[i] + margin; d > 0 {
+				g += 2 * d
+			}
+			g /= float64(len(z))
+			gradB += g
+			for j := range gradW {
+				gradW[j] += g * z[i][j]
+			}
+		}
+		intercept -= step * gradB
+		for j := range weights {
+			weights[j] -= step * gradW[j]
+		}
+	}
+
+	// Back to the original feature scale.
+	m := Model{Objective: opts.Objective, Cost: opts.Cost, MinSize: opts.MinSize, Jump: opts.Jump,
+		Intercept: intercept, Features: names, Weights: make([]float64, nFeatures)}
+	for j := range names {
+		if scales[j] > 0 {
+			m.Weights[j] = weights[j] / scales[j]
+			m.Intercept -= m.Weights[j] * means[j]
+		}
+	}
+	for i, c := range curves {
+		f := m.Intercept
+		for j, w := range m.Weights {
+			f += w * x[i][j]
+		}
+		m.Loss += c.lossAt(f)
+	}
+	m.Loss /= float64(len(curves))
+	return m, nil
+}
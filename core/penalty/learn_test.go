@@ -0,0 +1,182 @@
+package penalty_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/datasets"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+	"github.com/theDataFlowClub/ruptures/core/penalty"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// annotatedExamples builds piecewise constant signals with known change points,
+// with the given noise levels and lengths.
+func annotatedExamples(t *testing.T, seed int64, noises []float64, lengths []int) []penalty.Example {
+	t.Helper()
+	var examples []penalty.Example
+	for _, noise := range noises {
+		for _, n := range lengths {
+			params := datasets.DefaultParams()
+			params.NSamples = n
+			params.NBkps = 4
+			params.NoiseStd = noise
+			params.DeltaMin, params.DeltaMax = 4*noise, 8*noise
+			params.Seed = seed
+			seed++
+			signal, bkps, err := datasets.PwConstant(params)
+			if err != nil {
+				t.Fatalf("PwConstant failed: %v", err)
+			}
+			examples = append(examples, penalty.Example{Signal: signal, Breakpoints: bkps})
+		}
+	}
+	return examples
+}
+
+// meanF1 returns the mean F1 score of the model on the examples.
+func meanF1(t *testing.T, m penalty.Model, examples []penalty.Example) float64 {
+	t.Helper()
+	var sum float64
+	for _, ex := range examples {
+		pen, err := m.Penalty(ex.Signal)
+		if err != nil {
+			t.Fatalf("Penalty failed: %v", err)
+		}
+		bkps, err := pelt.NewPelt(cost.NewCostL2(), 2, 1).FitPredict(ex.Signal, pen)
+		if err != nil {
+			t.Fatalf("FitPredict failed: %v", err)
+		}
+		f1, err := metrics.F1Score(ex.Breakpoints, bkps, 10)
+		if err != nil {
+			t.Fatalf("F1Score failed: %v", err)
+		}
+		sum += f1
+	}
+	return sum / float64(len(examples))
+}
+
+func TestLearnGlobal(t *testing.T) {
+	train := annotatedExamples(t, 1, []float64{1}, []int{300, 400, 500, 600})
+	for _, objective := range penalty.Objectives() {
+		t.Run(objective, func(t *testing.T) {
+			m, err := penalty.LearnGlobal(train, penalty.LearnOptions{Objective: objective})
+			if err != nil {
+				t.Fatalf("LearnGlobal failed: %v", err)
+			}
+			if len(m.Weights) != 0 || m.Objective != objective {
+				t.Errorf("expected a global %s model, got %+v", objective, m)
+			}
+			if m.Loss > 0.05 {
+				t.Errorf("expected a small training loss, got %g", m.Loss)
+			}
+			test := annotatedExamples(t, 100, []float64{1}, []int{350, 550})
+			if f1 := meanF1(t, m, test); f1 < 0.9 {
+				t.Errorf("expected a mean F1 of at least 0.9 on new signals, got %g", f1)
+			}
+		})
+	}
+}
+
+func TestLearnLinear(t *testing.T) {
+	// The noise level spans two orders of magnitude of variance: no single penalty
+	// fits all signals, but the penalty scales with the variance.
+	noises := []float64{0.3, 1, 3}
+	train := annotatedExamples(t, 1, noises, []int{300, 500, 700})
+	global, err := penalty.LearnGlobal(train, penalty.LearnOptions{})
+	if err != nil {
+		t.Fatalf("LearnGlobal failed: %v", err)
+	}
+	linear, err := penalty.LearnLinear(train, penalty.LearnOptions{})
+	if err != nil {
+		t.Fatalf("LearnLinear failed: %v", err)
+	}
+	if len(linear.Weights) != 2 {
+		t.Fatalf("expected 2 weights, got %+v", linear)
+	}
+	if linear.Loss >= global.Loss {
+		t.Errorf("expected the linear model to fit better than the global one, got %g > %g", linear.Loss, global.Loss)
+	}
+	if linear.Weights[1] <= 0 {
+		t.Errorf("expected the penalty to increase with the variance, got weights %v", linear.Weights)
+	}
+	test := annotatedExamples(t, 100, noises, []int{400, 600})
+	if f1 := meanF1(t, linear, test); f1 < 0.9 {
+		t.Errorf("expected a mean F1 of at least 0.9 on new signals, got %g", f1)
+	}
+
+	t.Run("JSONRoundTrip", func(t *testing.T) {
+		data, err := json.Marshal(linear)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		var back penalty.Model
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		want, _ := linear.Penalty(test[0].Signal)
+		got, err := back.Penalty(test[0].Signal)
+		if err != nil || math.Abs(got-want) > floatTolerance*want {
+			t.Errorf("expected penalty %g after a JSON round trip, got %g (%v)", want, got, err)
+		}
+	})
+}
+
+func TestLearnLinearNoiseless(t *testing.T) {
+	// A noiseless example has a zero estimated noise level; its log-variance
+	// feature is floored instead of being -Inf.
+	examples := annotatedExamples(t, 1, []float64{0.5, 1, 2}, []int{300})
+	clean := types.Matrix{}
+	for i := 0; i < 300; i++ {
+		clean = append(clean, []float64{float64(i / 100)})
+	}
+	examples = append(examples, penalty.Example{Name: "clean", Signal: clean, Breakpoints: []int{100, 200, 300}})
+
+	m, err := penalty.LearnLinear(examples, penalty.LearnOptions{MinSize: 3, Jump: 2})
+	if err != nil {
+		t.Fatalf("LearnLinear failed: %v", err)
+	}
+	for _, w := range append([]float64{m.Intercept}, m.Weights...) {
+		if math.IsNaN(w) || math.IsInf(w, 0) {
+			t.Fatalf("expected finite coefficients, got %+v", m)
+		}
+	}
+	if m.MinSize != 3 || m.Jump != 2 {
+		t.Errorf("model min size %d and jump %d, want 3 and 2", m.MinSize, m.Jump)
+	}
+	if pen, err := m.Penalty(clean); err != nil || !(pen > 0) {
+		t.Errorf("expected a positive penalty for the noiseless signal, got %g (%v)", pen, err)
+	}
+
+	constant := types.Matrix{{1}, {1}, {1}, {1}}
+	if _, err := m.Penalty(constant); err == nil {
+		t.Error("expected an error for a constant signal")
+	}
+}
+
+func TestLearnErrors(t *testing.T) {
+	examples := annotatedExamples(t, 1, []float64{1}, []int{200})
+	tests := []struct {
+		name     string
+		examples []penalty.Example
+		opts     penalty.LearnOptions
+		target   error
+	}{
+		{"NoExamples", nil, penalty.LearnOptions{}, nil},
+		{"UnknownObjective", examples, penalty.LearnOptions{Objective: "recall"}, penalty.ErrUnknownObjective},
+		{"MismatchedBreakpoints", []penalty.Example{{Signal: examples[0].Signal, Breakpoints: []int{50, 100}}},
+			penalty.LearnOptions{}, metrics.ErrBreakpointsMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := penalty.LearnGlobal(tt.examples, tt.opts)
+			if err == nil || (tt.target != nil && !errors.Is(err, tt.target)) {
+				t.Errorf("expected error %v, got %v", tt.target, err)
+			}
+		})
+	}
+}
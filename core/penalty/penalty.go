@@ -0,0 +1,177 @@
+// Package penalty computes standard penalty values for penalized change point
+// detection (e.g. pelt.Pelt.Predict) from the size of the signal and a robust
+// estimate of its noise level, so that callers do not have to guess the penalty.
+//
+// The penalties are expressed in the units of the L2 cost (sum of squared
+// residuals): for Gaussian noise of standard deviation sigma, twice the negative
+// log-likelihood of a segment equals its L2 cost divided by sigma². A criterion
+// charging k parameters per change point with a weight w therefore translates into
+// the penalty sigma² · w · k. Every change point adds nFeatures + 1 parameters:
+// one new mean per feature, plus its location.
+//
+// Example:
+//
+//	pen, err := penalty.FromSignal(penalty.CriterionBIC, signal)
+//	if err != nil {
+//		return err
+//	}
+//	bkps, err := detector.Predict(pen)
+//
+// When annotated signals are available, LearnGlobal and LearnLinear learn the
+// penalty from the labeled change points instead.
+package penalty
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/stat"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Supported criteria, as accepted by Compute and FromSignal.
+const (
+	CriterionAIC  = "aic"
+	CriterionBIC  = "bic"
+	CriterionMBIC = "mbic"
+)
+
+// madToSigma converts the median absolute deviation into the standard deviation
+// of a Gaussian distribution (1 / Φ⁻¹(3/4)).
+const madToSigma = 1.482602218505602
+
+// ErrUnknownCriterion is returned when a criterion name is not one of Criteria().
+var ErrUnknownCriterion = errors.New("penalty: unknown criterion")
+
+// Criteria returns the names of the supported criteria.
+func Criteria() []string {
+	return []string{CriterionAIC, CriterionBIC, CriterionMBIC}
+}
+
+// AIC returns the Akaike information criterion penalty, 2 · sigma² · (nFeatures + 1).
+// It tends to over-segment long signals and is mostly useful for short ones.
+func AIC(nSamples, nFeatures int, sigma float64) float64 {
+	return 2 * sigma * sigma * float64(nFeatures+1)
+}
+
+// BIC returns the Bayesian (Schwarz) information criterion penalty,
+// sigma² · (nFeatures + 1) · log(nSamples).
+func BIC(nSamples, nFeatures int, sigma float64) float64 {
+	return sigma * sigma * float64(nFeatures+1) * math.Log(float64(nSamples))
+}
+
+// MBIC returns the constant part of the modified BIC of Zhang and Siegmund (2007),
+// sigma² · (nFeatures + 2) · log(nSamples), which charges the change point location
+// more heavily than BIC. The term depending on the segment lengths is omitted,
+// which makes the penalty slightly more conservative than the full criterion.
+func MBIC(nSamples, nFeatures int, sigma float64) float64 {
+	return sigma * sigma * float64(nFeatures+2) * math.Log(float64(nSamples))
+}
+
+// Compute returns the penalty of the named criterion.
+//
+// Parameters:
+//
+//	criterion: One of Criteria() ("aic", "bic", "mbic"), case-insensitive.
+//	nSamples:  Number of samples of the signal; must be at least 2.
+//	nFeatures: Number of features of the signal; must be at least 1.
+//	sigma:     Standard deviation of the noise; must be positive (see EstimateSigma).
+//
+// Returns:
+//
+//	float64: The penalty, in units of the L2 cost.
+//	error:   ErrUnknownCriterion, or an error describing the invalid argument.
+func Compute(criterion string, nSamples, nFeatures int, sigma float64) (float64, error) {
+	if nSamples < 2 || nFeatures < 1 {
+		return 0, fmt.Errorf("penalty: need at least 2 samples and 1 feature, got %d and %d", nSamples, nFeatures)
+	}
+	if !(sigma > 0) || math.IsInf(sigma, 0) {
+		return 0, fmt.Errorf("penalty: noise level must be positive and finite, got %g", sigma)
+	}
+	switch strings.ToLower(criterion) {
+	case CriterionAIC:
+		return AIC(nSamples, nFeatures, sigma), nil
+	case CriterionBIC:
+		return BIC(nSamples, nFeatures, sigma), nil
+	case CriterionMBIC:
+		return MBIC(nSamples, nFeatures, sigma), nil
+	default:
+		return 0, fmt.Errorf("%w %q (available: %s)", ErrUnknownCriterion, criterion, strings.Join(Criteria(), ", "))
+	}
+}
+
+// FromSignal estimates the noise level of the signal with EstimateSigma and
+// returns the penalty of the named criterion for its dimensions.
+func FromSignal(criterion string, signal types.Matrix) (float64, error) {
+	sigma, err := EstimateSigma(signal)
+	if err != nil {
+		return 0, err
+	}
+	return Compute(criterion, len(signal), len(signal[0]), sigma)
+}
+
+// EstimateSigmas estimates the noise standard deviation of every feature from the
+// median absolute deviation (MAD) of its first differences. Differencing removes
+// the piecewise constant mean except at the few change points, which the median
+// ignores; the difference of two independent noise values has variance 2 · sigma².
+//
+// Returns exceptions.ErrInvalidSignal for an empty signal and
+// exceptions.ErrNotEnoughPoints when it has fewer than 2 samples.
+func EstimateSigmas(signal types.Matrix) ([]float64, error) {
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return nil, exceptions.ErrInvalidSignal
+	}
+	if len(signal) < 2 {
+		return nil, exceptions.ErrNotEnoughPoints
+	}
+	nFeatures := len(signal[0])
+	sigmas := make([]float64, nFeatures)
+	diffs := make([]float64, len(signal)-1)
+	for j := 0; j < nFeatures; j++ {
+		for i := 1; i < len(signal); i++ {
+			if len(signal[i]) != nFeatures {
+				return nil, fmt.Errorf("penalty: row %d has %d features, want %d: %w", i, len(signal[i]), nFeatures, exceptions.ErrInvalidSignal)
+			}
+			diffs[i-1] = signal[i][j] - signal[i-1][j]
+		}
+		med, err := stat.Median(diffs)
+		if err != nil {
+			return nil, err
+		}
+		deviations := make([]float64, len(diffs))
+		for i, d := range diffs {
+			deviations[i] = math.Abs(d - med)
+		}
+		mad, err := stat.Median(deviations)
+		if err != nil {
+			return nil, err
+		}
+		sigmas[j] = madToSigma * mad / math.Sqrt2
+	}
+	return sigmas, nil
+}
+
+// EstimateSigma returns a single noise level for the whole signal: the root mean
+// of the squared per-feature estimates of EstimateSigmas, which matches the L2 cost
+// summing the squared residuals of all features.
+//
+// It returns an error when the estimate is zero (e.g. a noiseless or constant
+// signal), since no penalty can then be derived from it.
+func EstimateSigma(signal types.Matrix) (float64, error) {
+	sigmas, err := EstimateSigmas(signal)
+	if err != nil {
+		return 0, err
+	}
+	var sumSquares float64
+	for _, s := range sigmas {
+		sumSquares += s * s
+	}
+	sigma := math.Sqrt(sumSquares / float64(len(sigmas)))
+	if sigma == 0 {
+		return 0, errors.New("penalty: estimated noise level is zero; the signal looks noiseless, set the penalty explicitly")
+	}
+	return sigma, nil
+}
@@ -0,0 +1,124 @@
+package penalty_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/datasets"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/metrics"
+	"github.com/theDataFlowClub/ruptures/core/penalty"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+const floatTolerance = 1e-9
+
+func TestCompute(t *testing.T) {
+	logN := math.Log(100)
+	tests := []struct {
+		criterion string
+		want      float64
+	}{
+		{penalty.CriterionAIC, 2 * 4 * 4},
+		{penalty.CriterionBIC, 4 * 4 * logN},
+		{"BIC", 4 * 4 * logN},
+		{penalty.CriterionMBIC, 4 * 5 * logN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.criterion, func(t *testing.T) {
+			got, err := penalty.Compute(tt.criterion, 100, 3, 2)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(got-tt.want) > floatTolerance {
+				t.Errorf("expected %g, got %g", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("UnknownCriterion", func(t *testing.T) {
+		if _, err := penalty.Compute("hqc", 100, 1, 1); !errors.Is(err, penalty.ErrUnknownCriterion) {
+			t.Errorf("expected ErrUnknownCriterion, got %v", err)
+		}
+	})
+	t.Run("InvalidSigma", func(t *testing.T) {
+		if _, err := penalty.Compute(penalty.CriterionBIC, 100, 1, 0); err == nil {
+			t.Error("expected an error for a zero noise level")
+		}
+	})
+}
+
+func TestEstimateSigma(t *testing.T) {
+	t.Run("PiecewiseConstantWithNoise", func(t *testing.T) {
+		params := datasets.DefaultParams()
+		params.NSamples = 5000
+		params.NFeatures = 2
+		params.NoiseStd = 2
+		signal, _, err := datasets.PwConstant(params)
+		if err != nil {
+			t.Fatalf("PwConstant failed: %v", err)
+		}
+		sigmas, err := penalty.EstimateSigmas(signal)
+		if err != nil {
+			t.Fatalf("EstimateSigmas failed: %v", err)
+		}
+		for j, s := range sigmas {
+			if math.Abs(s-2) > 0.2 {
+				t.Errorf("feature %d: expected sigma close to 2, got %g", j, s)
+			}
+		}
+		sigma, err := penalty.EstimateSigma(signal)
+		if err != nil {
+			t.Fatalf("EstimateSigma failed: %v", err)
+		}
+		if math.Abs(sigma-2) > 0.2 {
+			t.Errorf("expected sigma close to 2, got %g", sigma)
+		}
+	})
+
+	t.Run("NoiselessSignal", func(t *testing.T) {
+		signal := types.Matrix{{1}, {1}, {1}, {5}, {5}}
+		if _, err := penalty.EstimateSigma(signal); err == nil {
+			t.Error("expected an error for a noiseless signal")
+		}
+	})
+
+	t.Run("TooShort", func(t *testing.T) {
+		if _, err := penalty.EstimateSigmas(types.Matrix{{1}}); !errors.Is(err, exceptions.ErrNotEnoughPoints) {
+			t.Errorf("expected ErrNotEnoughPoints, got %v", err)
+		}
+		if _, err := penalty.EstimateSigmas(nil); !errors.Is(err, exceptions.ErrInvalidSignal) {
+			t.Errorf("expected ErrInvalidSignal, got %v", err)
+		}
+	})
+}
+
+func TestFromSignalWithPelt(t *testing.T) {
+	// Con la penalización BIC estimada de la señal, PELT debe recuperar los puntos de cambio.
+	params := datasets.DefaultParams()
+	params.NSamples = 1000
+	params.NoiseStd = 1
+	params.Seed = 7
+	signal, trueBkps, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	pen, err := penalty.FromSignal(penalty.CriterionBIC, signal)
+	if err != nil {
+		t.Fatalf("FromSignal failed: %v", err)
+	}
+	bkps, err := pelt.NewPelt(cost.NewCostL2(), 2, 1).FitPredict(signal, pen)
+	if err != nil {
+		t.Fatalf("FitPredict failed: %v", err)
+	}
+	f1, err := metrics.F1Score(trueBkps, bkps, 10)
+	if err != nil {
+		t.Fatalf("F1Score failed: %v", err)
+	}
+	if f1 < 1 {
+		t.Errorf("expected F1 = 1 with the BIC penalty %g, got %g (true %v, predicted %v)", pen, f1, trueBkps, bkps)
+	}
+}
@@ -0,0 +1,172 @@
+package refine_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/base"
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/datasets"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/refine"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// fitted returns the cost of the model fitted on the signal.
+func fitted(t *testing.T, model string, signal types.Matrix) base.CostFunction {
+	t.Helper()
+	c, err := cost.NewCost(model)
+	if err != nil {
+		t.Fatalf("NewCost failed: %v", err)
+	}
+	if err := c.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	return c
+}
+
+// detect runs Pelt with the given jump on the signal.
+func detect(t *testing.T, model string, signal types.Matrix, jump int, penalty float64) []int {
+	t.Helper()
+	bkps, err := pelt.NewPelt(fitted(t, model, signal), 2, jump).FitPredict(signal, penalty)
+	if err != nil {
+		t.Fatalf("FitPredict failed: %v", err)
+	}
+	return bkps
+}
+
+// totalCost returns the sum of the costs of the segments.
+func totalCost(t *testing.T, c base.CostFunction, bkps []int) float64 {
+	t.Helper()
+	sum, start := 0.0, 0
+	for _, end := range bkps {
+		v, err := c.Error(start, end)
+		if err != nil {
+			t.Fatalf("Error(%d, %d) failed: %v", start, end, err)
+		}
+		sum, start = sum+v, end
+	}
+	return sum
+}
+
+func TestRefineNoiseless(t *testing.T) {
+	// Without noise, a coarse Pelt run followed by the refinement recovers the true
+	// breakpoints exactly.
+	params := datasets.DefaultParams()
+	params.NSamples, params.NBkps, params.Seed = 1000, 5, 1
+	signal, want, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	for _, model := range []string{"l2", "l1"} {
+		t.Run(model, func(t *testing.T) {
+			coarse := detect(t, model, signal, 25, 300)
+			got, err := refine.NewRefiner(25, 2).Refine(signal, fitted(t, model, signal), coarse)
+			if err != nil {
+				t.Fatalf("Refine failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("refined %v to %v, want %v", coarse, got, want)
+			}
+		})
+	}
+}
+
+func TestRefineMatchesFullResolution(t *testing.T) {
+	params := datasets.DefaultParams()
+	params.NSamples, params.NBkps, params.NoiseStd, params.Seed = 1000, 4, 1, 2
+	signal, _, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	tests := []struct {
+		model   string
+		penalty float64
+	}{
+		{model: "l2", penalty: 100},
+		{model: "l1", penalty: 20},
+		{model: "rbf", penalty: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			want := detect(t, tt.model, signal, 1, tt.penalty)
+			coarse := detect(t, tt.model, signal, 10, tt.penalty)
+			c := fitted(t, tt.model, signal)
+			got, err := refine.NewRefiner(10, 2).Refine(signal, c, coarse)
+			if err != nil {
+				t.Fatalf("Refine failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("refined %v to %v, want %v", coarse, got, want)
+			}
+			if totalCost(t, c, got) > totalCost(t, c, coarse) {
+				t.Errorf("refinement increased the cost")
+			}
+		})
+	}
+}
+
+func TestRefineSweeps(t *testing.T) {
+	// Breakpoints far from the changes need several sweeps, and each sweep
+	// decreases the cost. The input is not modified.
+	params := datasets.DefaultParams()
+	params.NSamples, params.NBkps, params.Seed = 300, 2, 3
+	signal, want, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	c := fitted(t, "l2", signal)
+	start := []int{want[0] + 12, want[1] - 12, want[2]}
+	one, err := (&refine.Refiner{Width: 5, MinSize: 2, Sweeps: 1}).Refine(signal, c, start)
+	if err != nil {
+		t.Fatalf("Refine failed: %v", err)
+	}
+	all, err := refine.NewRefiner(5, 2).Refine(signal, c, start)
+	if err != nil {
+		t.Fatalf("Refine failed: %v", err)
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("refined %v to %v, want %v", start, all, want)
+	}
+	if a, b, d := totalCost(t, c, start), totalCost(t, c, one), totalCost(t, c, all); !(a > b && b > d) {
+		t.Errorf("costs = %g, %g, %g, want decreasing", a, b, d)
+	}
+	if !reflect.DeepEqual(start, []int{want[0] + 12, want[1] - 12, want[2]}) {
+		t.Errorf("Refine modified its input: %v", start)
+	}
+}
+
+func TestRefineErrors(t *testing.T) {
+	params := datasets.DefaultParams()
+	signal, bkps, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	c := fitted(t, "l2", signal)
+	tests := []struct {
+		name    string
+		refiner *refine.Refiner
+		bkps    []int
+	}{
+		{name: "Width", refiner: refine.NewRefiner(-1, 2), bkps: bkps},
+		{name: "MinSize", refiner: refine.NewRefiner(5, 0), bkps: bkps},
+		{name: "Sweeps", refiner: &refine.Refiner{Width: 5, MinSize: 2, Sweeps: -1}, bkps: bkps},
+		{name: "LastBreakpoint", refiner: refine.NewRefiner(5, 2), bkps: bkps[:len(bkps)-1]},
+		{name: "Unsorted", refiner: refine.NewRefiner(5, 2), bkps: []int{60, 50, len(signal)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.refiner.Refine(signal, c, tt.bkps); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := refine.NewRefiner(5, 2).RefineContext(ctx, signal, c, bkps); !errors.Is(err, context.Canceled) {
+		t.Errorf("RefineContext error = %v, want %v", err, context.Canceled)
+	}
+}
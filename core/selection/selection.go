@@ -0,0 +1,258 @@
+// Package selection chooses the number of change points K of a segmentation from
+// the costs of the best segmentations with K = 0..Kmax change points, as produced
+// by a dynamic programming detector or by CROPS (pelt.Pelt.Crops), so that
+// detection can run unattended without hand-tuning a penalty per series.
+//
+// Three methods are available:
+//
+//   - MethodElbow picks the knee of the cost curve (the candidate farthest from the
+//     chord joining the first and last candidates, both axes rescaled to [0, 1]).
+//   - MethodSlope applies the slope heuristic (Birgé and Massart 2007, Lebarbier 2005):
+//     the cost of the largest models decreases linearly in the penalty shape
+//     K·(2·log(n/K) + 5), the slope of that line is the minimal penalty constant,
+//     and twice that constant is the penalty used to select K.
+//   - MethodBIC, MethodAIC and MethodMBIC minimize a penalized likelihood, with
+//     the penalties of the penalty package and the noise level estimated from the
+//     signal (penalty.EstimateSigma).
+//
+// The candidates need not cover every K: CROPS only returns the K that are optimal
+// for some penalty. Costs are those of the L2 cost (sums of squared residuals) for
+// the penalized likelihood methods; the elbow and slope heuristics only need the
+// cost to decrease with K.
+package selection
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/exceptions"
+	"github.com/theDataFlowClub/ruptures/core/penalty"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// Selection methods, as accepted by Select.
+const (
+	MethodElbow = "elbow"
+	MethodSlope = "slope"
+	MethodAIC   = penalty.CriterionAIC
+	MethodBIC   = penalty.CriterionBIC
+	MethodMBIC  = penalty.CriterionMBIC
+)
+
+// ErrUnknownMethod is returned when a method name is not one of Methods().
+var ErrUnknownMethod = errors.New("selection: unknown method")
+
+// ErrNotEnoughCandidates is returned when there are too few candidates to apply a method.
+var ErrNotEnoughCandidates = errors.New("selection: not enough candidates")
+
+// Candidate is the best segmentation found with a given number of change points.
+type Candidate struct {
+	NBkps       int     // Number of change points (len(Breakpoints) - 1).
+	Cost        float64 // Sum of the segment costs, without penalty.
+	Breakpoints []int   // Breakpoints; the last one is n_samples. May be nil.
+}
+
+// Result is the outcome of a selection.
+type Result struct {
+	Method   string    // Method used.
+	Selected Candidate // Chosen segmentation.
+	Index    int       // Index of the chosen candidate in Candidates.
+	// Candidates are the input candidates, sorted by increasing NBkps.
+	Candidates []Candidate
+	// Scores holds the criterion of every candidate (same order as Candidates): the
+	// distance to the chord for the elbow, the penalized cost otherwise. The selected
+	// candidate has the largest distance, or the smallest penalized cost.
+	Scores []float64
+	// Penalty is the penalty per change point used to select K: twice the estimated
+	// slope for the slope heuristic, the criterion penalty for the penalized
+	// likelihood methods. It is zero for the elbow method.
+	Penalty float64
+	// Sigma is the noise level used by the penalized likelihood methods.
+	Sigma float64
+}
+
+// Methods returns the names of the supported methods.
+func Methods() []string {
+	return []string{MethodElbow, MethodSlope, MethodAIC, MethodBIC, MethodMBIC}
+}
+
+// FromCrops converts CROPS segmentations into candidates.
+func FromCrops(segs []pelt.CropsSegmentation) []Candidate {
+	cands := make([]Candidate, len(segs))
+	for i, s := range segs {
+		cands[i] = Candidate{NBkps: s.NBkps, Cost: s.Cost, Breakpoints: s.Breakpoints}
+	}
+	return cands
+}
+
+// Select chooses a candidate with the named method.
+//
+// Parameters:
+//
+//	method: One of Methods(), case-insensitive.
+//	cands:  Candidates with distinct numbers of change points, in any order.
+//	signal: The segmented signal, for its dimensions and, with the penalized
+//	        likelihood methods, its noise level.
+//
+// Returns:
+//
+//	Result: The chosen candidate with the diagnostics of the method.
+//	error:  ErrUnknownMethod, ErrNotEnoughCandidates, or an error describing invalid input.
+func Select(method string, cands []Candidate, signal types.Matrix) (Result, error) {
+	if len(signal) == 0 || len(signal[0]) == 0 {
+		return Result{}, exceptions.ErrInvalidSignal
+	}
+	switch m := strings.ToLower(method); m {
+	case MethodElbow:
+		return Elbow(cands)
+	case MethodSlope:
+		return SlopeHeuristic(cands, len(signal))
+	case MethodAIC, MethodBIC, MethodMBIC:
+		sigma, err := penalty.EstimateSigma(signal)
+		if err != nil {
+			return Result{}, err
+		}
+		return PenalizedLikelihood(cands, m, len(signal), len(signal[0]), sigma)
+	default:
+		return Result{}, fmt.Errorf("%w %q (available: %s)", ErrUnknownMethod, method, strings.Join(Methods(), ", "))
+	}
+}
+
+// Elbow selects the knee of the cost curve: after rescaling K and the cost to
+// [0, 1], the candidate farthest below the chord from the first to the last
+// candidate. At least three candidates are required.
+func Elbow(cands []Candidate) (Result, error) {
+	sorted, err := prepare(cands, 3)
+	if err != nil {
+		return Result{}, err
+	}
+	first, last := sorted[0], sorted[len(sorted)-1]
+	kRange := float64(last.NBkps - first.NBkps)
+	costRange := first.Cost - last.Cost
+
+	res := Result{Method: MethodElbow, Candidates: sorted, Scores: make([]float64, len(sorted))}
+	for i, c := range sorted {
+		x := float64(c.NBkps-first.NBkps) / kRange
+		y := 0.0
+		if costRange > 0 {
+			y = (first.Cost - c.Cost) / costRange
+		}
+		// The chord goes from (0, 0) to (1, 1) in the rescaled plane; points above it
+		// (larger cost decrease than linear) are at a positive distance.
+		res.Scores[i] = (y - x) / math.Sqrt2
+		if res.Scores[i] > res.Scores[res.Index] {
+			res.Index = i
+		}
+	}
+	res.Selected = sorted[res.Index]
+	return res, nil
+}
+
+// slopeShape is the penalty shape of the slope heuristic for k change points.
+func slopeShape(k, nSamples int) float64 {
+	if k == 0 {
+		return 0
+	}
+	return float64(k) * (2*math.Log(float64(nSamples)/float64(k)) + 5)
+}
+
+// SlopeHeuristic selects K with the slope heuristic. The slope is fitted by least
+// squares on the largest models, where the cost decrease is dominated by fitting
+// noise: the candidates with Kmax/2 <= K <= Kmax (at least three of them), where
+// Kmax is the largest K not exceeding nSamples/10. Beyond that, segments are so
+// short that the cost saturates and the slope would be underestimated.
+func SlopeHeuristic(cands []Candidate, nSamples int) (Result, error) {
+	sorted, err := prepare(cands, 3)
+	if err != nil {
+		return Result{}, err
+	}
+	if nSamples <= sorted[len(sorted)-1].NBkps {
+		return Result{}, fmt.Errorf("selection: nSamples (%d) must exceed the largest number of change points (%d)",
+			nSamples, sorted[len(sorted)-1].NBkps)
+	}
+
+	end := len(sorted)
+	for end > 3 && sorted[end-1].NBkps > nSamples/10 {
+		end--
+	}
+	maxK := sorted[end-1].NBkps
+	start := end - 3
+	for start > 0 && sorted[start-1].NBkps*2 >= maxK {
+		start--
+	}
+	var sx, sy, sxx, sxy float64
+	tail := sorted[start:end]
+	for _, c := range tail {
+		x := slopeShape(c.NBkps, nSamples)
+		sx += x
+		sy += c.Cost
+		sxx += x * x
+		sxy += x * c.Cost
+	}
+	n := float64(len(tail))
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return Result{}, fmt.Errorf("%w: the largest models have the same penalty shape", ErrNotEnoughCandidates)
+	}
+	slope := (n*sxy - sx*sy) / den
+	if slope >= 0 {
+		return Result{}, errors.New("selection: the cost does not decrease with the number of change points; cannot apply the slope heuristic")
+	}
+
+	res := Result{Method: MethodSlope, Candidates: sorted, Penalty: -2 * slope}
+	res.Scores = make([]float64, len(sorted))
+	for i, c := range sorted {
+		res.Scores[i] = c.Cost + res.Penalty*slopeShape(c.NBkps, nSamples)
+		if res.Scores[i] < res.Scores[res.Index] {
+			res.Index = i
+		}
+	}
+	res.Selected = sorted[res.Index]
+	return res, nil
+}
+
+// PenalizedLikelihood selects the candidate minimizing Cost + K·pen, where pen is
+// the penalty of the criterion for the noise level sigma (see penalty.Compute).
+func PenalizedLikelihood(cands []Candidate, criterion string, nSamples, nFeatures int, sigma float64) (Result, error) {
+	sorted, err := prepare(cands, 1)
+	if err != nil {
+		return Result{}, err
+	}
+	pen, err := penalty.Compute(criterion, nSamples, nFeatures, sigma)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{Method: strings.ToLower(criterion), Candidates: sorted, Penalty: pen, Sigma: sigma}
+	res.Scores = make([]float64, len(sorted))
+	for i, c := range sorted {
+		res.Scores[i] = c.Cost + float64(c.NBkps)*pen
+		if res.Scores[i] < res.Scores[res.Index] {
+			res.Index = i
+		}
+	}
+	res.Selected = sorted[res.Index]
+	return res, nil
+}
+
+// prepare checks the candidates and returns a copy sorted by increasing NBkps.
+func prepare(cands []Candidate, minCount int) ([]Candidate, error) {
+	if len(cands) < minCount {
+		return nil, fmt.Errorf("%w: got %d, need at least %d", ErrNotEnoughCandidates, len(cands), minCount)
+	}
+	sorted := append([]Candidate(nil), cands...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].NBkps < sorted[j].NBkps })
+	for i, c := range sorted {
+		if c.NBkps < 0 || math.IsNaN(c.Cost) || math.IsInf(c.Cost, 0) {
+			return nil, fmt.Errorf("selection: invalid candidate %+v", c)
+		}
+		if i > 0 && c.NBkps == sorted[i-1].NBkps {
+			return nil, fmt.Errorf("selection: two candidates with %d change points", c.NBkps)
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,103 @@
+package selection_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/theDataFlowClub/ruptures/core/cost"
+	"github.com/theDataFlowClub/ruptures/core/datasets"
+	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
+	"github.com/theDataFlowClub/ruptures/core/selection"
+	"github.com/theDataFlowClub/ruptures/core/types"
+)
+
+// kneeCandidates returns costs for K = 0..10 that drop sharply up to K = 3 and
+// decrease slowly afterwards, in shuffled order.
+func kneeCandidates() []selection.Candidate {
+	costs := map[int]float64{0: 1000, 1: 600, 2: 300, 3: 100, 4: 95, 5: 90, 6: 86, 7: 82, 8: 78, 9: 75, 10: 72}
+	var cands []selection.Candidate
+	for _, k := range []int{5, 0, 10, 3, 1, 8, 2, 4, 9, 6, 7} {
+		cands = append(cands, selection.Candidate{NBkps: k, Cost: costs[k]})
+	}
+	return cands
+}
+
+func TestElbow(t *testing.T) {
+	res, err := selection.Elbow(kneeCandidates())
+	if err != nil {
+		t.Fatalf("Elbow failed: %v", err)
+	}
+	if res.Selected.NBkps != 3 {
+		t.Errorf("expected K = 3, got %d (scores %v)", res.Selected.NBkps, res.Scores)
+	}
+	if len(res.Scores) != 11 || res.Candidates[res.Index].NBkps != 3 {
+		t.Errorf("inconsistent diagnostics: index %d, %d scores", res.Index, len(res.Scores))
+	}
+	for i := 1; i < len(res.Candidates); i++ {
+		if res.Candidates[i].NBkps <= res.Candidates[i-1].NBkps {
+			t.Errorf("candidates not sorted by NBkps: %v", res.Candidates)
+		}
+	}
+}
+
+func TestPenalizedLikelihood(t *testing.T) {
+	// With sigma = 1 and n = e^10, BIC charges 2·10 = 20 per change point (1 feature).
+	res, err := selection.PenalizedLikelihood(kneeCandidates(), selection.MethodBIC, 22026, 1, 1)
+	if err != nil {
+		t.Fatalf("PenalizedLikelihood failed: %v", err)
+	}
+	if res.Selected.NBkps != 3 {
+		t.Errorf("expected K = 3, got %d", res.Selected.NBkps)
+	}
+	if res.Penalty < 19.9 || res.Penalty > 20.1 {
+		t.Errorf("expected a penalty close to 20, got %g", res.Penalty)
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	signal := types.Matrix{{0}, {1}, {2}}
+	if _, err := selection.Select("nope", kneeCandidates(), signal); !errors.Is(err, selection.ErrUnknownMethod) {
+		t.Errorf("expected ErrUnknownMethod, got %v", err)
+	}
+	if _, err := selection.Elbow(kneeCandidates()[:2]); !errors.Is(err, selection.ErrNotEnoughCandidates) {
+		t.Errorf("expected ErrNotEnoughCandidates, got %v", err)
+	}
+	dup := []selection.Candidate{{NBkps: 1, Cost: 2}, {NBkps: 1, Cost: 3}, {NBkps: 0, Cost: 5}}
+	if _, err := selection.Elbow(dup); err == nil {
+		t.Error("expected an error for duplicated numbers of change points")
+	}
+}
+
+func TestSelectFromCrops(t *testing.T) {
+	params := datasets.DefaultParams()
+	params.NSamples = 500
+	params.NoiseStd = 1
+	params.Seed = 11
+	signal, trueBkps, err := datasets.PwConstant(params)
+	if err != nil {
+		t.Fatalf("PwConstant failed: %v", err)
+	}
+	p := pelt.NewPelt(cost.NewCostL2(), 2, 1)
+	if err := p.Fit(signal); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	segs, err := p.Crops(0.1, 1e5)
+	if err != nil {
+		t.Fatalf("Crops failed: %v", err)
+	}
+
+	for _, method := range selection.Methods() {
+		if method == selection.MethodAIC {
+			continue // AIC over-segments long signals by design.
+		}
+		t.Run(method, func(t *testing.T) {
+			res, err := selection.Select(method, selection.FromCrops(segs), signal)
+			if err != nil {
+				t.Fatalf("Select failed: %v", err)
+			}
+			if res.Selected.NBkps != len(trueBkps)-1 {
+				t.Errorf("expected %d change points (%v), got %v", len(trueBkps)-1, trueBkps, res.Selected.Breakpoints)
+			}
+		})
+	}
+}
@@ -1,14 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
 
-	// Necesario para convertir string a float64
-	// Para la interfaz CostFunction
-	"github.com/theDataFlowClub/ruptures/core/cmdutils"       // Donde pusimos la función ParseArgs
-	"github.com/theDataFlowClub/ruptures/core/cost"           // Para la fábrica de funciones de costo (NewCost) y CostRbf
+	"github.com/theDataFlowClub/ruptures/core/cmdutils"       // Flags compartidos (-cost, -penalty, -gamma, ...)
 	"github.com/theDataFlowClub/ruptures/core/detection/pelt" // Tu implementación de PELT
 	"github.com/theDataFlowClub/ruptures/core/types"          // Para types.Matrix
 )
@@ -45,37 +43,30 @@ func main() {
 	fmt.Printf("Longitud total de la señal: %d\n", len(signal))
 
 	// --- 2. Obtener parámetros de los argumentos de línea de comandos ---
-	// La función ParseArgs está en el paquete cmdutils y se encarga de leer
-	// el nombre del modelo y la penalización de los argumentos de la terminal.
-	params := cmdutils.ParseArgs(os.Args)
-	fmt.Printf("DEBUG: Parámetro de función de costo recibido: '%s'\n", params.CostFuncName) // <-- Línea de depuración 1
+	// cmdutils registra flags con nombre (-cost, -penalty, -gamma, ...) y los valida.
+	// Ejemplo: go run ./examples/example1 -cost l2 -penalty 3
+	opts := cmdutils.DefaultOptions()
+	opts.Cost = "rbf"
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	cmdutils.SetUsage(fs, os.Args[0]+" [flags]", "Detecta puntos de cambio en una señal sintética con PELT.")
+	opts.RegisterFlags(fs)
+	if err := cmdutils.Parse(fs, os.Args[1:], &opts); err != nil {
+		log.Fatalf("Argumentos inválidos: %v", err)
+	}
 
 	// --- 3. Crear un objeto de la función de costo seleccionada ---
-	// Utilizamos la fábrica NewCost del paquete 'cost' para obtener una instancia
-	// de la función de costo (RBF, L1, L2) basada en el nombre que se obtuvo de los argumentos.
-	selectedCostFunc, err := cost.NewCost(params.CostFuncName)
+	// opts.NewCost usa la fábrica cost.NewCost y aplica las opciones del kernel
+	// (sin -gamma, CostRbf usa su heurística de la mediana).
+	selectedCostFunc, err := opts.NewCost()
 	if err != nil {
-		log.Fatalf("Error al obtener la función de costo '%s': %v. Asegúrate de que esté implementada y registrada.", params.CostFuncName, err)
-	}
-
-	// Si la función de costo seleccionada es RBF, podemos configurar su parámetro Gamma.
-	// La implementación de CostRbf usa una heurística por defecto si Gamma es nil.
-	if rbfCost, ok := selectedCostFunc.(*cost.CostRbf); ok {
-		var gamma *float64 // Lo dejamos como nil para que CostRbf use su heurística por defecto
-		// Podrías añadir lógica aquí para leer 'gamma' desde los argumentos si lo necesitaras:
-		// if len(os.Args) > 3 { /* leer gamma de os.Args[3] */ }
-		rbfCost.Gamma = gamma
-		fmt.Println("Usando CostRbf con gamma heurístico.")
-	} else {
-		fmt.Printf("Usando función de costo: %s\n", selectedCostFunc.Model())
+		log.Fatalf("Error al obtener la función de costo '%s': %v", opts.Cost, err)
 	}
+	fmt.Printf("Usando función de costo: %s\n", selectedCostFunc.Model())
 
 	// --- 4. Configurar y ajustar el detector PELT ---
-	// 'minSize' es el tamaño mínimo de un segmento detectado.
-	// 'jump' es el paso para el subsampling (1 significa sin subsampling, cada punto).
-	minSize := 2
-	jump := 1
-	peltDetector := pelt.NewPelt(selectedCostFunc, minSize, jump)
+	// -min-size es el tamaño mínimo de un segmento detectado.
+	// -jump es el paso para el subsampling (1 significa sin subsampling, cada punto).
+	peltDetector := pelt.NewPelt(selectedCostFunc, opts.MinSize, opts.Jump)
 
 	// Ajustamos el detector a nuestra señal de entrada. Esto prepara el algoritmo para la detección.
 	err = peltDetector.Fit(signal)
@@ -88,8 +79,8 @@ func main() {
 	// La penalización (penalty) es un parámetro crucial:
 	// Un valor más alto resulta en menos puntos de cambio detectados.
 	// Un valor más bajo resulta en más puntos de cambio detectados.
-	fmt.Printf("Prediciendo puntos de cambio con penalización (pen): %.2f\n", params.Penalty)
-	changePoints, err := peltDetector.Predict(params.Penalty)
+	fmt.Printf("Prediciendo puntos de cambio con penalización (pen): %.2f\n", opts.Penalty)
+	changePoints, err := peltDetector.Predict(opts.Penalty)
 	if err != nil {
 		log.Fatalf("Error al predecir los puntos de cambio: %v", err)
 	}
@@ -1,14 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os" // Para manejar argumentos de línea de comandos si queremos flexibilidad de penalización
 
-	// Para convertir strings a float64
-	"github.com/theDataFlowClub/ruptures/core/base"
 	"github.com/theDataFlowClub/ruptures/core/cmdutils"
-	"github.com/theDataFlowClub/ruptures/core/cost"
 	"github.com/theDataFlowClub/ruptures/core/detection/pelt"
 	"github.com/theDataFlowClub/ruptures/core/types"
 )
@@ -45,43 +43,35 @@ func main() {
 	// Tu última implementación de PELT usó RBF en el ejemplo, así que usaremos RBF aquí por consistencia.
 	// Si deseas L2, asegúrate de que CostL2 esté implementada y registrada.
 
-	// Vamos a permitir que se pase el modelo y la penalización como argumentos de línea de comandos.
-	costFuncName := "rbf" // Modelo por defecto para el ejemplo
-	penalty := 5.0        // Penalización por defecto (como en tu script Python)
+	// Vamos a permitir que se pase el modelo y la penalización como flags de línea de comandos.
+	// Los valores por defecto replican el script Python: modelo "rbf", pen=5, min_size=10.
+	opts := cmdutils.DefaultOptions()
+	opts.Cost = "rbf"
+	opts.MinSize = 10
 
 	// --- 2. Obtener parámetros de los argumentos de línea de comandos ---
-	params := cmdutils.ParseArgs(os.Args) // ¡Llama a nuestra nueva función!
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	cmdutils.SetUsage(fs, os.Args[0]+" [flags]", "Traducción a Go del ejercicio de ruptures en Python.")
+	opts.RegisterFlags(fs)
+	if err := cmdutils.Parse(fs, os.Args[1:], &opts); err != nil {
+		log.Fatalf("Argumentos inválidos: %v", err)
+	}
+	costFuncName, penalty := opts.Cost, opts.Penalty
 
 	// Obtener la función de costo basada en el nombre
 	// --- 3. Crear un objeto Detector ---
-	var selectedCostFunc base.CostFunction
-	var err error // Declara la variable para la interfaz
-
-	// ¡CAMBIO CLAVE AQUÍ! Usar cost.NewCost
-	selectedCostFunc, err = cost.NewCost(costFuncName) // <-- ESTO ES LO QUE NECESITAS
+	// opts.NewCost usa la fábrica cost.NewCost; sin -gamma, CostRbf usa su heurística de la mediana.
+	selectedCostFunc, err := opts.NewCost()
 	if err != nil {
-		log.Fatalf("Error al obtener la función de costo '%s': %v. Asegúrate de que esté implementada y registrada.", params.CostFuncName, err)
-	}
-
-	// Si el modelo es RBF, podemos establecer el gamma si es necesario (aquí lo dejamos a nil para la heurística por defecto)
-	if rbfCost, ok := selectedCostFunc.(*cost.CostRbf); ok {
-		var gamma *float64 // nil para usar la heurística de CostRbf
-		rbfCost.Gamma = gamma
-		fmt.Println("Usando CostRbf con gamma heurístico.")
-	} else {
-		fmt.Printf("Usando función de costo: %s\n", selectedCostFunc.Model())
+		log.Fatalf("Error al obtener la función de costo '%s': %v. Asegúrate de que esté implementada y registrada.", costFuncName, err)
 	}
+	fmt.Printf("Usando función de costo: %s\n", selectedCostFunc.Model())
 
 	// Configuración de Pelt. Usamos min_size=10 y jump=1 como en tu comentario original de Python,
-	// o los valores por defecto de tu Pelt Go si no se especifican.
-	// Si quieres replicar `algo = rpt.Pelt().fit(signal)` exactamente, usaríamos los defaults de ruptures.
+	// salvo que se indiquen otros valores con -min-size y -jump.
 	// Los defaults de ruptures para min_size son 1, y jump es 1.
-	minSize := 10 // Replicando el min_size comentado en Python
-	jump := 1     // Replicando el jump comentado en Python
+	minSize, jump := opts.MinSize, opts.Jump
 
-	// Si quieres usar los defaults de tu NewPelt sin especificar min_size o jump en la creación,
-	// necesitarías un constructor de Pelt que no los pida, o pasar los mismos defaults de Go.
-	// Por ahora, usamos los valores explícitos para replicar tu código Python.
 	peltDetector := pelt.NewPelt(selectedCostFunc, minSize, jump)
 
 	// Ajustar el detector a la señal (Fit)
@@ -108,5 +98,5 @@ func main() {
 
 	// Si quieres comparar con el resultado de Python, ejecuta tu script Python y compara el array.
 	// En Python: [13 22] para este caso con pen=5 y modelo "l2".
-	// Para replicar "l2", necesitas implementar CostL2 y usar `go run main.go l2 5.0`
+	// Para replicar "l2", usa `go run ./examples/example2 -cost l2 -penalty 5`
 }